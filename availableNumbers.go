@@ -43,6 +43,9 @@ type GetAvailableNumberQuery struct {
 
 // GetAvailableNumbers looks for available numbers
 func (api *Client) GetAvailableNumbers(numberType AvailableNumberType, query *GetAvailableNumberQuery) ([]*AvailableNumber, error) {
+	if err := api.checkAreaCode(query); err != nil {
+		return nil, err
+	}
 	result, _, err := api.makeRequest(http.MethodGet, fmt.Sprintf("%s/%s", availableNumbersPath, numberType), &[]*AvailableNumber{}, query)
 	if err != nil {
 		return nil, err
@@ -61,6 +64,9 @@ type OrderedNumber struct {
 
 // GetAndOrderAvailableNumbers looks for available numbers and orders them
 func (api *Client) GetAndOrderAvailableNumbers(numberType AvailableNumberType, query *GetAvailableNumberQuery) ([]*OrderedNumber, error) {
+	if err := api.checkAreaCode(query); err != nil {
+		return nil, err
+	}
 	path := fmt.Sprintf("%s/%s", availableNumbersPath, numberType)
 	result, _, err := api.makeRequest(http.MethodPost, path, &[]*OrderedNumber{}, query, true)
 	if err != nil {
@@ -0,0 +1,219 @@
+package bandwidth
+
+import (
+	"fmt"
+	"sync"
+)
+
+// BridgeSession keeps local state of a bridge (its calls and hold flag) and changes it
+// A call can be in one bridge only; adding a call to a bridge removes it from its previous bridge.
+// A bridge on hold has bridgeAudio=false: its calls stay in the bridge but don't hear each other.
+type BridgeSession struct {
+	ID string
+
+	api     *Client
+	mutex   sync.Mutex
+	callIDs []string
+	hold    bool
+	state   string
+}
+
+// CreateBridgeSession creates a bridge with the calls
+// It returns BridgeSession instance or error
+// example: session, err := api.CreateBridgeSession("callId1", "callId2")
+func (api *Client) CreateBridgeSession(callIDs ...string) (*BridgeSession, error) {
+	id, err := api.CreateBridge(&BridgeData{BridgeAudio: true, CallIDs: callIDs})
+	if err != nil {
+		return nil, err
+	}
+	return &BridgeSession{ID: id, api: api, callIDs: append([]string{}, callIDs...)}, nil
+}
+
+// OpenBridgeSession returns BridgeSession for an existing bridge (its state is loaded by Sync())
+// It returns BridgeSession instance or error
+// example: session, err := api.OpenBridgeSession("bridgeId")
+func (api *Client) OpenBridgeSession(id string) (*BridgeSession, error) {
+	session := &BridgeSession{ID: id, api: api}
+	if err := session.Sync(); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// CallIDs returns IDs of calls in the bridge
+func (s *BridgeSession) CallIDs() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string{}, s.callIDs...)
+}
+
+// OnHold returns true if the bridge is on hold
+func (s *BridgeSession) OnHold() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.hold
+}
+
+// State returns state of the bridge received by last Sync() (empty before first Sync())
+func (s *BridgeSession) State() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.state
+}
+
+// update sends full state of the bridge (BridgeData omits false and empty values)
+func (s *BridgeSession) update(callIDs []string, hold bool) error {
+	return s.api.updateBridgeWithMap(s.ID, map[string]interface{}{"bridgeAudio": !hold, "callIds": callIDs})
+}
+
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// change applies modify to copy of the call list and sends the result (nothing is sent if the state is not changed)
+func (s *BridgeSession) change(modify func(callIDs []string, hold bool) ([]string, bool)) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	callIDs, hold := modify(append([]string{}, s.callIDs...), s.hold)
+	if hold == s.hold && sameStrings(callIDs, s.callIDs) {
+		return nil
+	}
+	if err := s.update(callIDs, hold); err != nil {
+		return err
+	}
+	s.callIDs, s.hold = callIDs, hold
+	return nil
+}
+
+// Hold puts the bridge on hold (calls stay in the bridge but don't hear each other)
+// It returns error object
+func (s *BridgeSession) Hold() error {
+	return s.change(func(callIDs []string, hold bool) ([]string, bool) {
+		return callIDs, true
+	})
+}
+
+// Resume takes the bridge off hold
+// It returns error object
+func (s *BridgeSession) Resume() error {
+	return s.change(func(callIDs []string, hold bool) ([]string, bool) {
+		return callIDs, false
+	})
+}
+
+// AddCall adds the call to the bridge (the call leaves its previous bridge, nothing is sent if the call is in the bridge already)
+// It returns error object
+func (s *BridgeSession) AddCall(callID string) error {
+	return s.change(func(callIDs []string, hold bool) ([]string, bool) {
+		for _, id := range callIDs {
+			if id == callID {
+				return callIDs, hold
+			}
+		}
+		return append(callIDs, callID), hold
+	})
+}
+
+// RemoveCall removes the call from the bridge
+// It returns error object
+func (s *BridgeSession) RemoveCall(callID string) error {
+	return s.change(func(callIDs []string, hold bool) ([]string, bool) {
+		result := []string{}
+		for _, id := range callIDs {
+			if id != callID {
+				result = append(result, id)
+			}
+		}
+		return result, hold
+	})
+}
+
+// BridgeSwapError is returned by SwapWith() when the second bridge can't be updated and calls of the first bridge can't be restored
+// States of the bridges are unknown in this case (use Sync() to get them).
+type BridgeSwapError struct {
+	Err         error
+	RollbackErr error
+}
+
+func (e *BridgeSwapError) Error() string {
+	return fmt.Sprintf("Swap of bridge calls has failed: %s (restoring of calls has failed: %s)", e.Err, e.RollbackErr)
+}
+
+// SwapWith exchanges calls of the bridge and the other bridge (hold flags of the bridges are kept)
+// If the other bridge can't be updated, calls of both bridges are moved back.
+// It returns error object (BridgeSwapError if calls can't be moved back)
+func (s *BridgeSession) SwapWith(other *BridgeSession) error {
+	if other == s {
+		return nil
+	}
+	callIDs, hold := s.CallIDs(), s.OnHold()
+	otherCallIDs, otherHold := other.CallIDs(), other.OnHold()
+	if err := s.update(otherCallIDs, hold); err != nil {
+		return err
+	}
+	if err := other.update(callIDs, otherHold); err != nil {
+		// calls of the other bridge have been moved to this bridge already
+		if e := s.update(callIDs, hold); e != nil {
+			s.mutex.Lock()
+			s.callIDs = otherCallIDs
+			s.mutex.Unlock()
+			return &BridgeSwapError{Err: err, RollbackErr: e}
+		}
+		if e := other.update(otherCallIDs, otherHold); e != nil {
+			return &BridgeSwapError{Err: err, RollbackErr: e}
+		}
+		return err
+	}
+	s.mutex.Lock()
+	s.callIDs = otherCallIDs
+	s.mutex.Unlock()
+	other.mutex.Lock()
+	other.callIDs = callIDs
+	other.mutex.Unlock()
+	return nil
+}
+
+// PlayAudio plays an audio or speaks a sentence to all calls of the bridge
+// It returns error object
+func (s *BridgeSession) PlayAudio(data *PlayAudioData) error {
+	return s.api.PlayAudioToBridge(s.ID, data)
+}
+
+// StopAudio stops audio playing in the bridge
+// It returns error object
+func (s *BridgeSession) StopAudio() error {
+	return s.api.stopBridgeAudio(s.ID)
+}
+
+// Sync reconciles local state with GetBridge() and GetBridgeCalls() (calls which have ended are not included)
+// It returns error object
+func (s *BridgeSession) Sync() error {
+	bridge, err := s.api.GetBridge(s.ID)
+	if err != nil {
+		return err
+	}
+	calls, err := s.api.GetBridgeCalls(s.ID)
+	if err != nil {
+		return err
+	}
+	callIDs := []string{}
+	for _, call := range calls {
+		if !CallState(call.State).IsFinal() {
+			callIDs = append(callIDs, call.ID)
+		}
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.callIDs = callIDs
+	s.hold = !bridge.BridgeAudio
+	s.state = bridge.State
+	return nil
+}
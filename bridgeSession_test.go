@@ -0,0 +1,118 @@
+package bandwidth
+
+import (
+	"net/http"
+	"testing"
+)
+
+var bridgeSessionHandlers = []RequestHandler{
+	RequestHandler{
+		PathAndQuery:  "/v1/users/userId/bridges/b1",
+		Method:        http.MethodGet,
+		ContentToSend: `{"id":"b1","state":"active","bridgeAudio":false}`},
+	RequestHandler{
+		PathAndQuery:  "/v1/users/userId/bridges/b1/calls",
+		Method:        http.MethodGet,
+		ContentToSend: `[{"id":"c1","state":"active"},{"id":"c2","state":"completed"}]`}}
+
+func startBridgeSessionMockServer(t *testing.T) (*Client, func() []string, func()) {
+	server, api, log := startMockServerWithHandlersAndLog(t, append([]RequestHandler{RequestHandler{
+		PathAndQuery:     "/v1/users/userId/bridges",
+		Method:           http.MethodPost,
+		HeadersToSend:    map[string]string{"Location": "/v1/users/userId/bridges/b1"},
+		StatusCodeToSend: http.StatusCreated}}, bridgeSessionHandlers...), http.StatusCreated)
+	return api, log, server.Close
+}
+
+func TestBridgeSession(t *testing.T) {
+	api, log, stop := startBridgeSessionMockServer(t)
+	defer stop()
+	session, err := api.CreateBridgeSession("c1", "c2")
+	if err != nil {
+		t.Error("Failed call of CreateBridgeSession()")
+		return
+	}
+	expect(t, session.ID, "b1")
+	expectNil(t, session.Hold())
+	expect(t, session.OnHold(), true)
+	expectNil(t, session.Resume())
+	expectNil(t, session.AddCall("c3"))
+	expectNil(t, session.AddCall("c3"))
+	expectNil(t, session.RemoveCall("c1"))
+	expect(t, session.CallIDs(), []string{"c2", "c3"})
+	expectNil(t, session.PlayAudio(&PlayAudioData{Sentence: "Hello"}))
+	expectNil(t, session.StopAudio())
+	expect(t, log(), []string{
+		`POST /v1/users/userId/bridges {"bridgeAudio":true,"callIds":["c1","c2"]}`,
+		`POST /v1/users/userId/bridges/b1 {"bridgeAudio":false,"callIds":["c1","c2"]}`,
+		`POST /v1/users/userId/bridges/b1 {"bridgeAudio":true,"callIds":["c1","c2"]}`,
+		`POST /v1/users/userId/bridges/b1 {"bridgeAudio":true,"callIds":["c1","c2","c3"]}`,
+		`POST /v1/users/userId/bridges/b1 {"bridgeAudio":true,"callIds":["c2","c3"]}`,
+		`POST /v1/users/userId/bridges/b1/audio {"sentence":"Hello"}`,
+		`POST /v1/users/userId/bridges/b1/audio {"fileUrl":""}`,
+	})
+}
+
+func TestBridgeSessionSwapWith(t *testing.T) {
+	api, log, stop := startBridgeSessionMockServer(t)
+	defer stop()
+	first := &BridgeSession{ID: "b1", api: api, callIDs: []string{"c1", "c2"}}
+	second := &BridgeSession{ID: "b2", api: api, callIDs: []string{"c3"}, hold: true}
+	expectNil(t, first.SwapWith(second))
+	expect(t, first.CallIDs(), []string{"c3"})
+	expect(t, second.CallIDs(), []string{"c1", "c2"})
+	expect(t, log(), []string{
+		`POST /v1/users/userId/bridges/b1 {"bridgeAudio":true,"callIds":["c3"]}`,
+		`POST /v1/users/userId/bridges/b2 {"bridgeAudio":false,"callIds":["c1","c2"]}`,
+	})
+}
+
+func TestBridgeSessionSwapWithFail(t *testing.T) {
+	failures := 1
+	server, api, log := startMockServerWithLog(t, func(w http.ResponseWriter, r *http.Request, body string) {
+		if r.URL.Path == "/v1/users/userId/bridges/b2" && failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+	defer server.Close()
+	first := &BridgeSession{ID: "b1", api: api, callIDs: []string{"c1", "c2"}}
+	second := &BridgeSession{ID: "b2", api: api, callIDs: []string{"c3"}, hold: true}
+	err := first.SwapWith(second)
+	_, ok := err.(*HTTPError)
+	expect(t, ok, true)
+	expect(t, first.CallIDs(), []string{"c1", "c2"})
+	expect(t, second.CallIDs(), []string{"c3"})
+	expect(t, log(), []string{
+		`POST /v1/users/userId/bridges/b1 {"bridgeAudio":true,"callIds":["c3"]}`,
+		`POST /v1/users/userId/bridges/b2 {"bridgeAudio":false,"callIds":["c1","c2"]}`,
+		`POST /v1/users/userId/bridges/b1 {"bridgeAudio":true,"callIds":["c1","c2"]}`,
+		`POST /v1/users/userId/bridges/b2 {"bridgeAudio":false,"callIds":["c3"]}`,
+	})
+	failures = 2
+	err = first.SwapWith(second)
+	_, ok = err.(*BridgeSwapError)
+	expect(t, ok, true)
+}
+
+func TestOpenBridgeSession(t *testing.T) {
+	server, api := startMockServer(t, bridgeSessionHandlers)
+	defer server.Close()
+	session, err := api.OpenBridgeSession("b1")
+	if err != nil {
+		t.Error("Failed call of OpenBridgeSession()")
+		return
+	}
+	expect(t, session.CallIDs(), []string{"c1"})
+	expect(t, session.OnHold(), true)
+	expect(t, session.State(), "active")
+}
+
+func TestOpenBridgeSessionFail(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery:     "/v1/users/userId/bridges/b1",
+		Method:           http.MethodGet,
+		StatusCodeToSend: http.StatusNotFound}})
+	defer server.Close()
+	shouldFail(t, func() (interface{}, error) { return api.OpenBridgeSession("b1") })
+}
@@ -71,6 +71,17 @@ func (api *Client) UpdateBridge(id string, changedData *BridgeData) error {
 	return err
 }
 
+// updateBridgeWithMap is used to send false and empty values of fields (BridgeData omits them)
+func (api *Client) updateBridgeWithMap(id string, data map[string]interface{}) error {
+	_, _, err := api.makeRequest(http.MethodPost, fmt.Sprintf("%s/%s", api.concatUserPath(bridgesPath), id), nil, data)
+	return err
+}
+
+// removeBridgeCalls removes all calls from the bridge
+func (api *Client) removeBridgeCalls(id string) error {
+	return api.updateBridgeWithMap(id, map[string]interface{}{"callIds": []string{}})
+}
+
 // PlayAudioData struct
 type PlayAudioData struct {
 	FileURL     string `json:"fileUrl,omitempty"`
@@ -89,6 +100,12 @@ func (api *Client) PlayAudioToBridge(id string, data *PlayAudioData) error {
 	return err
 }
 
+// stopBridgeAudio stops playing of audio in the bridge (PlayAudioData omits empty FileURL)
+func (api *Client) stopBridgeAudio(id string) error {
+	_, _, err := api.makeRequest(http.MethodPost, fmt.Sprintf("%s/%s/%s", api.concatUserPath(bridgesPath), id, "audio"), nil, map[string]interface{}{"fileUrl": ""})
+	return err
+}
+
 // GetBridgeCalls returns bridge's calls
 // It returns list of Call instances or error
 func (api *Client) GetBridgeCalls(id string) ([]*Call, error) {
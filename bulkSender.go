@@ -0,0 +1,428 @@
+package bandwidth
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxBulkBatchSize is max count of messages in one request of CreateMessages()
+const MaxBulkBatchSize = 2500
+
+// RecipientIterator returns recipients of bulk messages one by one
+type RecipientIterator interface {
+	// Next returns next recipient (ok is false after the last one)
+	Next() (recipient string, ok bool, err error)
+}
+
+type sliceRecipientIterator struct {
+	list  []string
+	index int
+}
+
+func (i *sliceRecipientIterator) Next() (string, bool, error) {
+	if i.index >= len(i.list) {
+		return "", false, nil
+	}
+	i.index++
+	return i.list[i.index-1], true, nil
+}
+
+// SliceRecipientIterator returns RecipientIterator over a list of numbers
+func SliceRecipientIterator(list []string) RecipientIterator {
+	return &sliceRecipientIterator{list: list}
+}
+
+type readerRecipientIterator struct {
+	scanner *bufio.Scanner
+}
+
+func (i *readerRecipientIterator) Next() (string, bool, error) {
+	for i.scanner.Scan() {
+		if line := strings.TrimSpace(i.scanner.Text()); line != "" {
+			return line, true, nil
+		}
+	}
+	return "", false, i.scanner.Err()
+}
+
+// ReaderRecipientIterator returns RecipientIterator which reads numbers (one per line, empty lines are skipped)
+// example: file, _ := os.Open("numbers.txt"); recipients := bandwidth.ReaderRecipientIterator(file)
+func ReaderRecipientIterator(r io.Reader) RecipientIterator {
+	return &readerRecipientIterator{scanner: bufio.NewScanner(r)}
+}
+
+// BulkProgressStore persists count of handled recipients to resume a bulk job after a crash
+type BulkProgressStore interface {
+	Load() (int64, error)
+	Save(offset int64) error
+}
+
+// MemoryBulkProgressStore keeps progress in memory
+type MemoryBulkProgressStore struct {
+	mutex  sync.Mutex
+	offset int64
+}
+
+// Load returns saved offset
+func (s *MemoryBulkProgressStore) Load() (int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.offset, nil
+}
+
+// Save stores the offset
+func (s *MemoryBulkProgressStore) Save(offset int64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.offset = offset
+	return nil
+}
+
+// FileBulkProgressStore keeps progress in a file
+type FileBulkProgressStore struct {
+	Path string
+}
+
+// Load returns saved offset (0 if the file doesn't exist)
+func (s *FileBulkProgressStore) Load() (int64, error) {
+	data, err := ioutil.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+}
+
+// Save stores the offset (the file is replaced atomically)
+func (s *FileBulkProgressStore) Save(offset int64) error {
+	tmp := s.Path + ".tmp"
+	if err := ioutil.WriteFile(tmp, []byte(strconv.FormatInt(offset, 10)), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.Path)
+}
+
+// BulkResult is result of sending a message to one recipient
+type BulkResult struct {
+	// Index is position of the recipient in the iterator
+	Index     int64
+	Recipient string
+	MessageID string
+	Attempts  int
+	Err       error
+}
+
+// BulkSender sends the same message to many recipients
+// v1 API (CreateMessages()) is used by default; set MessageV2 to use CreateMessageV2().
+// Messages are sent at least once: after resuming a crashed job, messages which were in flight can be sent again.
+type BulkSender struct {
+	API *Client
+	// Message is template of v1 messages (To is replaced by recipients)
+	Message CreateMessageData
+	// MessageV2 is template of v2 messages (To is replaced by recipients)
+	MessageV2 *CreateMessageDataV2
+	// V2EndPoint is optional messaging endpoint passed to CreateMessageV2()
+	V2EndPoint string
+	// BatchSize is count of messages in one v1 request (0 means MaxBulkBatchSize)
+	BatchSize int
+	// Workers is count of simultaneous requests
+	Workers int
+	// MessagesPerSecond limits rate of sending (0 means unlimited)
+	MessagesPerSecond float64
+	// MaxRetries is count of retries of transient failures
+	MaxRetries int
+	// RetryDelay is delay before retry (rate limit errors are retried after reset time)
+	RetryDelay time.Duration
+	// IsTransient returns true for errors which should be retried (rate limits, network and 5xx errors by default)
+	IsTransient func(err error) bool
+	// Progress stores progress of the job (nil means no resumption)
+	Progress BulkProgressStore
+}
+
+// NewBulkSender creates new BulkSender instance for v1 messages
+// example: sender := bandwidth.NewBulkSender(api, bandwidth.CreateMessageData{From: "+19195551212", Text: "Hello"})
+func NewBulkSender(api *Client, message CreateMessageData) *BulkSender {
+	return &BulkSender{
+		API:         api,
+		Message:     message,
+		Workers:     4,
+		MaxRetries:  3,
+		RetryDelay:  5 * time.Second,
+		IsTransient: IsTransientError,
+	}
+}
+
+// BulkJob is a running bulk sending
+type BulkJob struct {
+	// Results receives result for each recipient; it is closed when the job finishes
+	// The channel should be drained by caller
+	Results <-chan *BulkResult
+
+	done chan struct{}
+	err  error
+}
+
+// Wait blocks until the job finishes
+// It returns error of the job (error of the iterator, progress store or ctx)
+func (j *BulkJob) Wait() error {
+	<-j.done
+	return j.err
+}
+
+// Start begins sending of messages to recipients (skipping recipients handled by previous run saved in Progress)
+// It returns BulkJob instance
+// example: job := sender.Start(ctx, bandwidth.SliceRecipientIterator(numbers)); for r := range job.Results {...}; err := job.Wait()
+func (s *BulkSender) Start(ctx context.Context, recipients RecipientIterator) *BulkJob {
+	results := make(chan *BulkResult, 100)
+	job := &BulkJob{Results: results, done: make(chan struct{})}
+	go func() {
+		job.err = s.run(ctx, recipients, results)
+		close(results)
+		close(job.done)
+	}()
+	return job
+}
+
+type bulkUnit struct {
+	first      int64
+	recipients []string
+}
+
+// bulkProgress tracks count of recipients handled without gaps
+type bulkProgress struct {
+	mutex sync.Mutex
+	next  int64
+	done  map[int64]int64
+	store BulkProgressStore
+	err   error
+}
+
+func (p *bulkProgress) complete(unit *bulkUnit) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.done[unit.first] = int64(len(unit.recipients))
+	advanced := false
+	for {
+		count, ok := p.done[p.next]
+		if !ok {
+			break
+		}
+		delete(p.done, p.next)
+		p.next += count
+		advanced = true
+	}
+	if advanced && p.store != nil {
+		if err := p.store.Save(p.next); err != nil && p.err == nil {
+			p.err = err
+		}
+	}
+}
+
+type bulkLimiter struct {
+	mutex    sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func (l *bulkLimiter) wait(ctx context.Context, count int) error {
+	if l.interval == 0 {
+		return nil
+	}
+	l.mutex.Lock()
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(time.Duration(count) * l.interval)
+	l.mutex.Unlock()
+	return sleepContext(ctx, at.Sub(now))
+}
+
+func (s *BulkSender) run(ctx context.Context, recipients RecipientIterator, results chan<- *BulkResult) error {
+	workers := s.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+	batchSize := s.BatchSize
+	if batchSize <= 0 || batchSize > MaxBulkBatchSize {
+		batchSize = MaxBulkBatchSize
+	}
+	if s.MessageV2 != nil {
+		batchSize = 1
+	}
+	var offset int64
+	if s.Progress != nil {
+		var err error
+		if offset, err = s.Progress.Load(); err != nil {
+			return err
+		}
+	}
+	limiter := &bulkLimiter{}
+	if s.MessagesPerSecond > 0 {
+		limiter.interval = time.Duration(float64(time.Second) / s.MessagesPerSecond)
+	}
+	progress := &bulkProgress{next: offset, done: map[int64]int64{}, store: s.Progress}
+	units := make(chan *bulkUnit)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for unit := range units {
+				for _, result := range s.send(ctx, limiter, unit) {
+					select {
+					case results <- result:
+					case <-ctx.Done():
+					}
+				}
+				if ctx.Err() == nil {
+					progress.complete(unit)
+				}
+			}
+		}()
+	}
+	err := s.feed(ctx, recipients, offset, batchSize, units)
+	close(units)
+	wg.Wait()
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return progress.err
+}
+
+func (s *BulkSender) feed(ctx context.Context, recipients RecipientIterator, offset int64, batchSize int, units chan<- *bulkUnit) error {
+	var index int64
+	unit := &bulkUnit{}
+	for {
+		recipient, ok, err := recipients.Next()
+		if err != nil {
+			return err
+		}
+		if ok && index < offset {
+			index++
+			continue
+		}
+		if ok {
+			if len(unit.recipients) == 0 {
+				unit.first = index
+			}
+			unit.recipients = append(unit.recipients, recipient)
+			index++
+		}
+		if len(unit.recipients) > 0 && (len(unit.recipients) == batchSize || !ok) {
+			select {
+			case units <- unit:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			unit = &bulkUnit{}
+		}
+		if !ok {
+			return nil
+		}
+	}
+}
+
+// retry calls send until it succeeds, fails with non-transient error or MaxRetries is reached
+// It returns count of attempts and last error
+func (s *BulkSender) retry(ctx context.Context, limiter *bulkLimiter, count int, send func() error) (int, error) {
+	isTransient := s.IsTransient
+	if isTransient == nil {
+		isTransient = IsTransientError
+	}
+	attempts := 0
+	for {
+		if err := limiter.wait(ctx, count); err != nil {
+			return attempts, err
+		}
+		attempts++
+		err := send()
+		if err == nil || attempts > s.MaxRetries || !isTransient(err) {
+			return attempts, err
+		}
+		delay := s.RetryDelay
+		if e, ok := err.(*RateLimitError); ok {
+			delay = e.Reset.Sub(time.Now())
+		}
+		if e := sleepContext(ctx, delay); e != nil {
+			return attempts, err
+		}
+	}
+}
+
+func (s *BulkSender) send(ctx context.Context, limiter *bulkLimiter, unit *bulkUnit) []*BulkResult {
+	results := make([]*BulkResult, len(unit.recipients))
+	for i, recipient := range unit.recipients {
+		results[i] = &BulkResult{Index: unit.first + int64(i), Recipient: recipient}
+	}
+	if s.MessageV2 != nil {
+		data := *s.MessageV2
+		data.To = Recipients(unit.recipients[0])
+		endPoint := []string{}
+		if s.V2EndPoint != "" {
+			endPoint = append(endPoint, s.V2EndPoint)
+		}
+		results[0].Attempts, results[0].Err = s.retry(ctx, limiter, 1, func() error {
+			message, err := s.API.CreateMessageV2(&data, endPoint...)
+			if err == nil {
+				results[0].MessageID = message.ID
+			}
+			return err
+		})
+		return results
+	}
+	// invalid messages are reported separately to not fail whole batch
+	batch := []*CreateMessageData{}
+	batchResults := []*BulkResult{}
+	for _, result := range results {
+		data := s.Message
+		data.To = result.Recipient
+		err := s.API.checkNumbers(data.From, data.To)
+		if err == nil {
+			err = s.API.validateMessage(data.From, data.To, data.Text, data.Media)
+		}
+		if err != nil {
+			result.Err = err
+			continue
+		}
+		batch = append(batch, &data)
+		batchResults = append(batchResults, result)
+	}
+	if len(batch) == 0 {
+		return results
+	}
+	var list []*CreateMessageResult
+	attempts, err := s.retry(ctx, limiter, len(batch), func() error {
+		var err error
+		list, err = s.API.CreateMessages(batch...)
+		return err
+	})
+	for i, result := range batchResults {
+		result.Attempts = attempts
+		switch {
+		case err != nil:
+			result.Err = err
+		case i >= len(list):
+			result.Err = fmt.Errorf("Missing result of message to %s", result.Recipient)
+		case list[i].Result != "accepted":
+			result.Err = fmt.Errorf("Message to %s is not accepted: %s", result.Recipient, list[i].Result)
+		default:
+			result.MessageID = list[i].ID
+		}
+	}
+	return results
+}
@@ -0,0 +1,167 @@
+package bandwidth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func collectBulkResults(job *BulkJob) ([]*BulkResult, error) {
+	results := []*BulkResult{}
+	for r := range job.Results {
+		results = append(results, r)
+	}
+	return results, job.Wait()
+}
+
+func TestSliceRecipientIterator(t *testing.T) {
+	it := SliceRecipientIterator([]string{"1"})
+	r, ok, err := it.Next()
+	expect(t, r, "1")
+	expect(t, ok, true)
+	expectNil(t, err)
+	_, ok, _ = it.Next()
+	expect(t, ok, false)
+}
+
+func TestReaderRecipientIterator(t *testing.T) {
+	it := ReaderRecipientIterator(strings.NewReader("+19195551212\n\n +19195551213 \n"))
+	list := []string{}
+	for {
+		r, ok, err := it.Next()
+		expectNil(t, err)
+		if !ok {
+			break
+		}
+		list = append(list, r)
+	}
+	expect(t, list, []string{"+19195551212", "+19195551213"})
+}
+
+func TestFileBulkProgressStore(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "bulk")
+	defer os.RemoveAll(dir)
+	store := &FileBulkProgressStore{Path: filepath.Join(dir, "progress")}
+	offset, err := store.Load()
+	expectNil(t, err)
+	expect(t, offset, int64(0))
+	expectNil(t, store.Save(42))
+	offset, err = store.Load()
+	expectNil(t, err)
+	expect(t, offset, int64(42))
+	ioutil.WriteFile(store.Path, []byte("bad"), 0644)
+	_, err = store.Load()
+	if err == nil {
+		t.Error("Expected error for corrupted file")
+	}
+}
+
+func TestBulkSenderV1(t *testing.T) {
+	var mutex sync.Mutex
+	calls := 0
+	server, api, log := startMockServerWithLog(t, func(w http.ResponseWriter, r *http.Request, body string) {
+		mutex.Lock()
+		calls++
+		call := calls
+		mutex.Unlock()
+		if call == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		list := []string{}
+		for i, item := range strings.Split(body, "},{") {
+			if strings.Contains(item, "+19195550003") {
+				list = append(list, `{"result":"error"}`)
+				continue
+			}
+			list = append(list, fmt.Sprintf(`{"result":"accepted","location":"/v1/users/userId/messages/m%d-%d"}`, call, i))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, "["+strings.Join(list, ",")+"]")
+	})
+	defer server.Close()
+	api.MessageValidators = []MessageValidator{MessageValidatorFunc(func(m *OutgoingMessage) error {
+		if m.To[0] == "bad" {
+			return errors.New("bad recipient")
+		}
+		return nil
+	})}
+	sender := NewBulkSender(api, CreateMessageData{From: "+19195551212", Text: "Hi"})
+	sender.Workers = 1
+	sender.BatchSize = 2
+	sender.RetryDelay = time.Millisecond
+	progress := &MemoryBulkProgressStore{}
+	sender.Progress = progress
+	results, err := collectBulkResults(sender.Start(context.Background(), SliceRecipientIterator([]string{"+19195550001", "bad", "+19195550002", "+19195550003", "+19195550004"})))
+	expectNil(t, err)
+	expect(t, len(results), 5)
+	expect(t, results[0].MessageID, "m2-0")
+	expect(t, results[0].Attempts, 2)
+	expect(t, results[1].Err.Error(), "bad recipient")
+	expect(t, results[2].MessageID, "m3-0")
+	expect(t, results[3].Err.Error(), "Message to +19195550003 is not accepted: error")
+	expect(t, results[4].Index, int64(4))
+	expect(t, results[4].MessageID, "m4-0")
+	offset, _ := progress.Load()
+	expect(t, offset, int64(5))
+	expect(t, len(log()), 4)
+	expect(t, log()[1], `POST /v1/users/userId/messages [{"from":"+19195551212","to":"+19195550001","text":"Hi"}]`)
+}
+
+func TestBulkSenderV2WithResume(t *testing.T) {
+	var mutex sync.Mutex
+	sent := []string{}
+	server, api, _ := startMockServerWithLog(t, func(w http.ResponseWriter, r *http.Request, body string) {
+		mutex.Lock()
+		sent = append(sent, body)
+		mutex.Unlock()
+		if strings.Contains(body, "+19195550003") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, `{"id":"id"}`)
+	})
+	defer server.Close()
+	sender := NewBulkSender(api, CreateMessageData{})
+	sender.MessageV2 = &CreateMessageDataV2{From: "+19195551212", Text: "Hi"}
+	sender.V2EndPoint = api.APIEndPoint
+	sender.MessagesPerSecond = 1000
+	sender.Progress = &MemoryBulkProgressStore{offset: 1}
+	results, err := collectBulkResults(sender.Start(context.Background(), SliceRecipientIterator([]string{"+19195550001", "+19195550002", "+19195550003"})))
+	expectNil(t, err)
+	expect(t, len(results), 2)
+	expect(t, len(sent), 2)
+	for _, r := range results {
+		if r.Recipient == "+19195550002" {
+			expect(t, r.MessageID, "id")
+		} else {
+			expect(t, r.Attempts, 1)
+			expect(t, r.Err != nil, true)
+		}
+	}
+}
+
+type failingRecipientIterator struct{}
+
+func (failingRecipientIterator) Next() (string, bool, error) {
+	return "", false, errors.New("read error")
+}
+
+func TestBulkSenderFail(t *testing.T) {
+	sender := NewBulkSender(getAPI(), CreateMessageData{})
+	_, err := collectBulkResults(sender.Start(context.Background(), failingRecipientIterator{}))
+	expect(t, err.Error(), "read error")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = collectBulkResults(sender.Start(ctx, SliceRecipientIterator([]string{"+19195550001"})))
+	expect(t, err, context.Canceled)
+}
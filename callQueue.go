@@ -0,0 +1,561 @@
+package bandwidth
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	callQueueAgentTagPrefix  = "queue-agent:"
+	callQueueAnnouncementTag = "queue-announcement"
+)
+
+// CallQueueMode is order of serving queued calls
+type CallQueueMode string
+
+const (
+	// CallQueueFIFO serves calls in order of arrival
+	CallQueueFIFO CallQueueMode = "fifo"
+	// CallQueuePriority serves calls with higher priority first (in order of arrival for same priority)
+	CallQueuePriority CallQueueMode = "priority"
+)
+
+// AgentState is availability of a queue agent
+type AgentState string
+
+const (
+	// AgentStateAvailable is state of an agent who can take a call
+	AgentStateAvailable AgentState = "available"
+	// AgentStateRinging is state of an agent who is being called
+	AgentStateRinging AgentState = "ringing"
+	// AgentStateBusy is state of an agent who talks with a caller
+	AgentStateBusy AgentState = "busy"
+	// AgentStateOffline is state of an agent who can't take calls
+	AgentStateOffline AgentState = "offline"
+)
+
+// Agent struct
+type Agent struct {
+	ID     string
+	Number string
+	State  AgentState
+	// CallID is ID of the call to the agent
+	CallID string
+	// CallerCallID is ID of the caller's call the agent is connected to
+	CallerCallID string
+	// NoAnswerTime is time when the agent has missed a call (it is cleared by SetAgentState)
+	NoAnswerTime time.Time
+}
+
+// AgentNotFoundError is returned for unknown agent ID
+type AgentNotFoundError struct {
+	ID string
+}
+
+func (e *AgentNotFoundError) Error() string {
+	return fmt.Sprintf("Agent %s is not found", e.ID)
+}
+
+// QueuedCall struct
+type QueuedCall struct {
+	CallID            string
+	From              string
+	Priority          int
+	BridgeID          string
+	EnqueuedTime      time.Time
+	LastAnnouncedTime time.Time
+}
+
+// QueueStore keeps state of CallQueue
+type QueueStore interface {
+	// SaveCall adds or updates a queued call
+	SaveCall(call *QueuedCall) error
+	RemoveCall(callID string) error
+	// ListCalls returns queued calls in order of service (higher priority first, then by EnqueuedTime)
+	ListCalls() ([]*QueuedCall, error)
+	// SaveAgent adds or updates an agent
+	SaveAgent(agent *Agent) error
+	ListAgents() ([]*Agent, error)
+}
+
+// MemoryQueueStore keeps state of CallQueue in memory
+type MemoryQueueStore struct {
+	mutex  sync.Mutex
+	calls  map[string]QueuedCall
+	agents map[string]Agent
+}
+
+// NewMemoryQueueStore creates new MemoryQueueStore instance
+func NewMemoryQueueStore() *MemoryQueueStore {
+	return &MemoryQueueStore{calls: map[string]QueuedCall{}, agents: map[string]Agent{}}
+}
+
+// SaveCall adds or updates a queued call
+func (s *MemoryQueueStore) SaveCall(call *QueuedCall) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.calls[call.CallID] = *call
+	return nil
+}
+
+// RemoveCall removes a call from the queue
+func (s *MemoryQueueStore) RemoveCall(callID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.calls, callID)
+	return nil
+}
+
+// ListCalls returns queued calls in order of service
+func (s *MemoryQueueStore) ListCalls() ([]*QueuedCall, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	list := make([]*QueuedCall, 0, len(s.calls))
+	for _, call := range s.calls {
+		c := call
+		list = append(list, &c)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Priority != list[j].Priority {
+			return list[i].Priority > list[j].Priority
+		}
+		return list[i].EnqueuedTime.Before(list[j].EnqueuedTime)
+	})
+	return list, nil
+}
+
+// SaveAgent adds or updates an agent
+func (s *MemoryQueueStore) SaveAgent(agent *Agent) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.agents[agent.ID] = *agent
+	return nil
+}
+
+// ListAgents returns all agents ordered by ID
+func (s *MemoryQueueStore) ListAgents() ([]*Agent, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	list := make([]*Agent, 0, len(s.agents))
+	for _, agent := range s.agents {
+		a := agent
+		list = append(list, &a)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list, nil
+}
+
+// CallQueue holds incoming calls with music until an agent is free and then bridges them
+type CallQueue struct {
+	API   *Client
+	Store QueueStore
+	Mode  CallQueueMode
+	// From is caller ID of calls to agents
+	From string
+	// CallbackURL receives callbacks of calls to agents (they should be passed to HandleCallback)
+	CallbackURL string
+	// HoldMusic is played in a loop to queued callers
+	HoldMusic *PlayAudioData
+	// Announcement returns audio with position of a caller in the queue (nil disables announcements)
+	Announcement     func(position int) *PlayAudioData
+	AnnounceInterval time.Duration
+	// MaxWait is max time of waiting in the queue before Overflow is called (0 means unlimited)
+	// Hold music of the call is stopped and the call is removed from the queue bridge before Overflow is called.
+	MaxWait  time.Duration
+	Overflow func(call *QueuedCall) error
+	// OverflowCallback receives further callbacks of calls moved to Overflow (e.g. VoicemailBox.HandleCallback)
+	// Overflowed calls are tracked in memory until they hang up.
+	OverflowCallback func(event *CallbackEvent) error
+	// NoAnswerState is state of an agent who has not answered a call (AgentStateOffline by default)
+	NoAnswerState AgentState
+	// NoAnswerPause makes agents in NoAnswerState available again after this time (0 means the agent keeps
+	// NoAnswerState until SetAgentState is called)
+	NoAnswerPause time.Duration
+
+	mutex      sync.Mutex
+	overflowed map[string]bool
+}
+
+// NewCallQueue creates new CallQueue instance
+// example: queue := bandwidth.NewCallQueue(api, bandwidth.NewMemoryQueueStore(), "+19195551212", "https://example.com/queue")
+func NewCallQueue(api *Client, store QueueStore, from, callbackURL string) *CallQueue {
+	return &CallQueue{API: api, Store: store, Mode: CallQueueFIFO, From: from, CallbackURL: callbackURL, AnnounceInterval: time.Minute}
+}
+
+// OverflowToVoicemail sets Overflow and OverflowCallback of the queue to move callers to the voicemail box
+// example: queue.OverflowToVoicemail(bandwidth.NewVoicemailBox(api, greeting, sink))
+func (q *CallQueue) OverflowToVoicemail(box *VoicemailBox) {
+	q.Overflow = func(call *QueuedCall) error {
+		return box.HandleCallback(&CallbackEvent{EventType: CallbackEventAnswer, CallID: call.CallID})
+	}
+	q.OverflowCallback = box.HandleCallback
+}
+
+// AddAgent registers an agent (or updates the agent) and connects queued calls if the agent is available
+// It returns error object
+func (q *CallQueue) AddAgent(agent *Agent) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if err := q.Store.SaveAgent(agent); err != nil {
+		return err
+	}
+	return q.dispatch()
+}
+
+// SetAgentState changes availability of the agent
+// It returns error object
+func (q *CallQueue) SetAgentState(id string, state AgentState) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	agent, err := q.findAgent(func(a *Agent) bool { return a.ID == id })
+	if err != nil {
+		return err
+	}
+	if agent == nil {
+		return &AgentNotFoundError{ID: id}
+	}
+	agent.State, agent.NoAnswerTime = state, time.Time{}
+	if err := q.Store.SaveAgent(agent); err != nil {
+		return err
+	}
+	return q.dispatch()
+}
+
+// Enqueue puts an answered call into the queue and starts hold music
+// priority is ignored in CallQueueFIFO mode
+// It returns error object
+func (q *CallQueue) Enqueue(callID, from string, priority int) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.Mode != CallQueuePriority {
+		priority = 0
+	}
+	bridgeID, err := q.API.CreateBridge(&BridgeData{BridgeAudio: true, CallIDs: []string{callID}})
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	call := &QueuedCall{CallID: callID, From: from, Priority: priority, BridgeID: bridgeID, EnqueuedTime: now, LastAnnouncedTime: now}
+	if err := q.Store.SaveCall(call); err != nil {
+		return err
+	}
+	if err := q.playHoldMusic(callID); err != nil {
+		return err
+	}
+	return q.dispatch()
+}
+
+// Position returns 1-based position of the call in the queue (0 if the call is not queued)
+func (q *CallQueue) Position(callID string) (int, error) {
+	calls, err := q.Store.ListCalls()
+	if err != nil {
+		return 0, err
+	}
+	for i, call := range calls {
+		if call.CallID == callID {
+			return i + 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func (q *CallQueue) playHoldMusic(callID string) error {
+	if q.HoldMusic == nil {
+		return nil
+	}
+	music := *q.HoldMusic
+	music.LoopEnabled = true
+	return q.API.PlayAudioToCall(callID, &music)
+}
+
+// stopQueueAudio stops hold music and announcements played to the queued caller
+func (q *CallQueue) stopQueueAudio(callID string) error {
+	return q.API.stopCallAudio(callID)
+}
+
+func (q *CallQueue) findAgent(match func(agent *Agent) bool) (*Agent, error) {
+	agents, err := q.Store.ListAgents()
+	if err != nil {
+		return nil, err
+	}
+	for _, agent := range agents {
+		if match(agent) {
+			return agent, nil
+		}
+	}
+	return nil, nil
+}
+
+func (q *CallQueue) findCall(callID string) (*QueuedCall, error) {
+	calls, err := q.Store.ListCalls()
+	if err != nil {
+		return nil, err
+	}
+	for _, call := range calls {
+		if call.CallID == callID {
+			return call, nil
+		}
+	}
+	return nil, nil
+}
+
+// dispatch calls free agents for waiting callers
+func (q *CallQueue) dispatch() error {
+	calls, err := q.Store.ListCalls()
+	if err != nil {
+		return err
+	}
+	for _, call := range calls {
+		taken, err := q.findAgent(func(a *Agent) bool { return a.CallerCallID == call.CallID })
+		if err != nil {
+			return err
+		}
+		if taken != nil {
+			continue
+		}
+		agent, err := q.findAgent(func(a *Agent) bool { return a.State == AgentStateAvailable })
+		if err != nil || agent == nil {
+			return err
+		}
+		agentCallID, err := q.API.CreateCall(&CreateCallData{
+			From:        q.From,
+			To:          agent.Number,
+			CallbackURL: q.CallbackURL,
+			Tag:         callQueueAgentTagPrefix + call.CallID,
+		})
+		if err != nil {
+			return err
+		}
+		agent.State = AgentStateRinging
+		agent.CallID = agentCallID
+		agent.CallerCallID = call.CallID
+		if err := q.Store.SaveAgent(agent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HandleCallback processes callbacks of queued calls and calls to agents
+// It returns error object
+func (q *CallQueue) HandleCallback(event *CallbackEvent) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.overflowed[event.CallID] {
+		switch event.EventType {
+		case CallbackEventHangup, CallbackEventTimeout, CallbackEventReject:
+			delete(q.overflowed, event.CallID)
+		}
+		if q.OverflowCallback == nil {
+			return nil
+		}
+		return q.OverflowCallback(event)
+	}
+	switch event.EventType {
+	case CallbackEventAnswer:
+		if !strings.HasPrefix(event.Tag, callQueueAgentTagPrefix) {
+			return nil
+		}
+		return q.connectAgent(event.CallID, strings.TrimPrefix(event.Tag, callQueueAgentTagPrefix))
+	case CallbackEventSpeak, CallbackEventPlayback:
+		if event.Status != "done" || event.Tag != callQueueAnnouncementTag {
+			return nil
+		}
+		call, err := q.findCall(event.CallID)
+		if err != nil || call == nil {
+			return err
+		}
+		return q.playHoldMusic(call.CallID)
+	case CallbackEventHangup, CallbackEventTimeout, CallbackEventReject:
+		return q.handleHangup(event.CallID)
+	}
+	return nil
+}
+
+func (q *CallQueue) connectAgent(agentCallID, callerCallID string) error {
+	agent, err := q.findAgent(func(a *Agent) bool { return a.CallID == agentCallID })
+	if err != nil {
+		return err
+	}
+	call, err := q.findCall(callerCallID)
+	if err != nil {
+		return err
+	}
+	if agent == nil || call == nil {
+		// the caller has left the queue while the agent's phone was ringing
+		return q.API.HangUpCall(agentCallID)
+	}
+	if err := q.stopQueueAudio(callerCallID); err != nil {
+		return err
+	}
+	if err := q.API.UpdateBridge(call.BridgeID, &BridgeData{BridgeAudio: true, CallIDs: []string{callerCallID, agentCallID}}); err != nil {
+		return err
+	}
+	agent.State = AgentStateBusy
+	if err := q.Store.SaveAgent(agent); err != nil {
+		return err
+	}
+	return q.Store.RemoveCall(callerCallID)
+}
+
+func (q *CallQueue) handleHangup(callID string) error {
+	call, err := q.findCall(callID)
+	if err != nil {
+		return err
+	}
+	if call != nil {
+		// the caller has hung up while waiting
+		if err := q.Store.RemoveCall(callID); err != nil {
+			return err
+		}
+		agent, err := q.findAgent(func(a *Agent) bool { return a.CallerCallID == callID })
+		if err != nil || agent == nil {
+			return err
+		}
+		q.API.HangUpCall(agent.CallID)
+		agent.State, agent.CallID, agent.CallerCallID = AgentStateAvailable, "", ""
+		if err := q.Store.SaveAgent(agent); err != nil {
+			return err
+		}
+		return q.dispatch()
+	}
+	agent, err := q.findAgent(func(a *Agent) bool { return a.CallID == callID || a.CallerCallID == callID })
+	if err != nil || agent == nil {
+		return err
+	}
+	if agent.State == AgentStateRinging {
+		// the agent has not answered: let other agents take the call
+		agent.State, agent.NoAnswerTime = q.noAnswerState(), time.Now()
+	} else {
+		if agent.CallID == callID {
+			q.API.HangUpCall(agent.CallerCallID)
+		} else {
+			q.API.HangUpCall(agent.CallID)
+		}
+		agent.State = AgentStateAvailable
+	}
+	agent.CallID, agent.CallerCallID = "", ""
+	if err := q.Store.SaveAgent(agent); err != nil {
+		return err
+	}
+	return q.dispatch()
+}
+
+func (q *CallQueue) noAnswerState() AgentState {
+	if q.NoAnswerState != "" {
+		return q.NoAnswerState
+	}
+	return AgentStateOffline
+}
+
+// restoreAgents makes agents who have missed a call longer than NoAnswerPause ago available again
+func (q *CallQueue) restoreAgents(now time.Time) error {
+	if q.NoAnswerPause <= 0 {
+		return nil
+	}
+	agents, err := q.Store.ListAgents()
+	if err != nil {
+		return err
+	}
+	restored := false
+	for _, agent := range agents {
+		if agent.State != q.noAnswerState() || agent.NoAnswerTime.IsZero() || now.Sub(agent.NoAnswerTime) < q.NoAnswerPause {
+			continue
+		}
+		agent.State, agent.NoAnswerTime = AgentStateAvailable, time.Time{}
+		if err := q.Store.SaveAgent(agent); err != nil {
+			return err
+		}
+		restored = true
+	}
+	if !restored {
+		return nil
+	}
+	return q.dispatch()
+}
+
+// overflow takes the call out of the queue (hold music and the queue bridge) and passes it to Overflow
+func (q *CallQueue) overflow(call *QueuedCall) error {
+	if err := q.Store.RemoveCall(call.CallID); err != nil {
+		return err
+	}
+	if q.Overflow == nil {
+		return nil
+	}
+	if err := q.stopQueueAudio(call.CallID); err != nil {
+		return err
+	}
+	if err := q.API.removeBridgeCalls(call.BridgeID); err != nil {
+		return err
+	}
+	if q.overflowed == nil {
+		q.overflowed = map[string]bool{}
+	}
+	q.overflowed[call.CallID] = true
+	return q.Overflow(call)
+}
+
+// Tick announces positions to waiting callers, moves calls waiting longer than MaxWait to Overflow
+// and makes agents available after NoAnswerPause
+// It returns error object
+func (q *CallQueue) Tick() error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	now := time.Now()
+	if err := q.restoreAgents(now); err != nil {
+		return err
+	}
+	calls, err := q.Store.ListCalls()
+	if err != nil {
+		return err
+	}
+	position := 0
+	for _, call := range calls {
+		if q.MaxWait > 0 && now.Sub(call.EnqueuedTime) > q.MaxWait {
+			ringing, err := q.findAgent(func(a *Agent) bool { return a.CallerCallID == call.CallID })
+			if err != nil {
+				return err
+			}
+			if ringing == nil {
+				if err := q.overflow(call); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		position++
+		if q.Announcement != nil && now.Sub(call.LastAnnouncedTime) >= q.AnnounceInterval {
+			audio := *q.Announcement(position)
+			audio.Tag = callQueueAnnouncementTag
+			if err := q.API.PlayAudioToCall(call.CallID, &audio); err != nil {
+				return err
+			}
+			call.LastAnnouncedTime = now
+			if err := q.Store.SaveCall(call); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Run calls Tick() every interval until ctx is done
+// It returns error of Tick() or ctx.Err()
+func (q *CallQueue) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := q.Tick(); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package bandwidth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func startCallQueueMockServer(t *testing.T) (*CallQueue, func()) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery:     "/v1/users/userId/bridges",
+		Method:           http.MethodPost,
+		EstimatedContent: `{"bridgeAudio":true,"callIds":["c1"]}`,
+		HeadersToSend:    map[string]string{"Location": "/v1/users/userId/bridges/b1"},
+	}, RequestHandler{
+		PathAndQuery: "/v1/users/userId/calls/c1/audio",
+		Method:       http.MethodPost,
+	}, RequestHandler{
+		PathAndQuery:     "/v1/users/userId/calls",
+		Method:           http.MethodPost,
+		EstimatedContent: `{"from":"+19195550000","to":"+19195551111","tag":"queue-agent:c1","callbackUrl":"http://localhost/queue"}`,
+		HeadersToSend:    map[string]string{"Location": "/v1/users/userId/calls/a1"},
+	}, RequestHandler{
+		PathAndQuery:     "/v1/users/userId/bridges/b1",
+		Method:           http.MethodPost,
+		EstimatedContent: `{"bridgeAudio":true,"callIds":["c1","a1"]}`,
+	}, RequestHandler{
+		PathAndQuery:     "/v1/users/userId/calls/c1",
+		Method:           http.MethodPost,
+		EstimatedContent: `{"state":"completed"}`,
+	}, RequestHandler{
+		PathAndQuery:     "/v1/users/userId/calls/a1",
+		Method:           http.MethodPost,
+		EstimatedContent: `{"state":"completed"}`}})
+	queue := NewCallQueue(api, NewMemoryQueueStore(), "+19195550000", "http://localhost/queue")
+	queue.HoldMusic = &PlayAudioData{FileURL: "http://localhost/music.mp3"}
+	return queue, server.Close
+}
+
+func getAgent(t *testing.T, queue *CallQueue, id string) *Agent {
+	agent, _ := queue.findAgent(func(a *Agent) bool { return a.ID == id })
+	if agent == nil {
+		t.Fatalf("Agent %s is missing", id)
+	}
+	return agent
+}
+
+func TestCallQueue(t *testing.T) {
+	queue, stop := startCallQueueMockServer(t)
+	defer stop()
+	expectNil(t, queue.AddAgent(&Agent{ID: "1", Number: "+19195551111", State: AgentStateOffline}))
+	expectNil(t, queue.Enqueue("c1", "+19195552222", 0))
+	position, _ := queue.Position("c1")
+	expect(t, position, 1)
+	expectNil(t, queue.SetAgentState("1", AgentStateAvailable))
+	agent := getAgent(t, queue, "1")
+	expect(t, agent.State, AgentStateRinging)
+	expect(t, agent.CallID, "a1")
+	expectNil(t, queue.HandleCallback(&CallbackEvent{EventType: CallbackEventAnswer, CallID: "a1", Tag: "queue-agent:c1"}))
+	expect(t, getAgent(t, queue, "1").State, AgentStateBusy)
+	position, _ = queue.Position("c1")
+	expect(t, position, 0)
+	expectNil(t, queue.HandleCallback(&CallbackEvent{EventType: CallbackEventHangup, CallID: "a1"}))
+	agent = getAgent(t, queue, "1")
+	expect(t, agent.State, AgentStateAvailable)
+	expect(t, agent.CallID, "")
+}
+
+func TestCallQueueWithMissedAgentCall(t *testing.T) {
+	queue, stop := startCallQueueMockServer(t)
+	defer stop()
+	expectNil(t, queue.Enqueue("c1", "+19195552222", 0))
+	expectNil(t, queue.AddAgent(&Agent{ID: "1", Number: "+19195551111", State: AgentStateAvailable}))
+	expectNil(t, queue.HandleCallback(&CallbackEvent{EventType: CallbackEventTimeout, CallID: "a1"}))
+	expect(t, getAgent(t, queue, "1").State, AgentStateOffline)
+	position, _ := queue.Position("c1")
+	expect(t, position, 1)
+	expectNil(t, queue.HandleCallback(&CallbackEvent{EventType: CallbackEventHangup, CallID: "c1"}))
+	position, _ = queue.Position("c1")
+	expect(t, position, 0)
+}
+
+func TestCallQueueSetAgentStateFail(t *testing.T) {
+	queue, stop := startCallQueueMockServer(t)
+	defer stop()
+	err := queue.SetAgentState("1", AgentStateAvailable)
+	expect(t, err.Error(), "Agent 1 is not found")
+}
+
+func TestCallQueueTick(t *testing.T) {
+	server, api, log := startMockServerWithLog(t, func(w http.ResponseWriter, r *http.Request, body string) {
+		if r.URL.Path == "/v1/users/userId/bridges" {
+			w.Header().Set("Location", "/v1/users/userId/bridges/b1")
+		}
+	})
+	defer server.Close()
+	queue := NewCallQueue(api, NewMemoryQueueStore(), "+19195550000", "http://localhost/queue")
+	var overflowed *QueuedCall
+	queue.Announcement = func(position int) *PlayAudioData {
+		return &PlayAudioData{Sentence: fmt.Sprintf("You are number %d", position)}
+	}
+	queue.AnnounceInterval = 0
+	queue.MaxWait = time.Hour
+	queue.Overflow = func(call *QueuedCall) error {
+		overflowed = call
+		return nil
+	}
+	expectNil(t, queue.Enqueue("c1", "+19195552222", 0))
+	expectNil(t, queue.Tick())
+	expect(t, overflowed == nil, true)
+	expectNil(t, queue.HandleCallback(&CallbackEvent{EventType: CallbackEventSpeak, CallID: "c1", Status: "done", Tag: callQueueAnnouncementTag}))
+	queue.MaxWait = time.Nanosecond
+	expectNil(t, queue.Tick())
+	expect(t, overflowed.CallID, "c1")
+	position, _ := queue.Position("c1")
+	expect(t, position, 0)
+	expect(t, log()[2:], []string{
+		`POST /v1/users/userId/calls/c1/audio {"fileUrl":""}`,
+		`POST /v1/users/userId/bridges/b1 {"callIds":[]}`,
+	})
+}
+
+func TestCallQueueOverflowToVoicemail(t *testing.T) {
+	server, api, log := startMockServerWithLog(t, func(w http.ResponseWriter, r *http.Request, body string) {
+		if r.URL.Path == "/v1/users/userId/bridges" {
+			w.Header().Set("Location", "/v1/users/userId/bridges/b1")
+		}
+	})
+	defer server.Close()
+	queue := NewCallQueue(api, NewMemoryQueueStore(), "+19195550000", "http://localhost/queue")
+	queue.MaxWait = time.Nanosecond
+	queue.OverflowToVoicemail(NewVoicemailBox(api, &PlayAudioData{Sentence: "Leave a message"}, nil))
+	expectNil(t, queue.Enqueue("c1", "+19195552222", 0))
+	expectNil(t, queue.Tick())
+	expectNil(t, queue.HandleCallback(&CallbackEvent{EventType: CallbackEventSpeak, CallID: "c1", Status: "done", Tag: voicemailGreetingTag}))
+	expect(t, log()[1:], []string{
+		`POST /v1/users/userId/calls/c1/audio {"fileUrl":""}`,
+		`POST /v1/users/userId/bridges/b1 {"callIds":[]}`,
+		`POST /v1/users/userId/calls/c1/audio {"sentence":"Leave a message","tag":"voicemail-greeting"}`,
+		`POST /v1/users/userId/calls/c1 {"recordingEnabled":"true"}`,
+	})
+	expect(t, queue.overflowed["c1"], true)
+	queue.OverflowCallback = nil
+	expectNil(t, queue.HandleCallback(&CallbackEvent{EventType: CallbackEventHangup, CallID: "c1"}))
+	expect(t, queue.overflowed["c1"], false)
+}
+
+func TestCallQueueWithNoAnswerPause(t *testing.T) {
+	queue, stop := startCallQueueMockServer(t)
+	defer stop()
+	queue.NoAnswerPause = time.Hour
+	expectNil(t, queue.Enqueue("c1", "+19195552222", 0))
+	expectNil(t, queue.AddAgent(&Agent{ID: "1", Number: "+19195551111", State: AgentStateAvailable}))
+	expectNil(t, queue.HandleCallback(&CallbackEvent{EventType: CallbackEventTimeout, CallID: "a1"}))
+	agent := getAgent(t, queue, "1")
+	expect(t, agent.State, AgentStateOffline)
+	expectNil(t, queue.Tick())
+	expect(t, getAgent(t, queue, "1").State, AgentStateOffline)
+	queue.NoAnswerPause = time.Nanosecond
+	expectNil(t, queue.Tick())
+	agent = getAgent(t, queue, "1")
+	expect(t, agent.State, AgentStateRinging)
+	expect(t, agent.NoAnswerTime.IsZero(), true)
+}
+
+func TestCallQueueWithNoAnswerState(t *testing.T) {
+	queue, stop := startCallQueueMockServer(t)
+	defer stop()
+	queue.NoAnswerState = AgentStateBusy
+	expectNil(t, queue.Enqueue("c1", "+19195552222", 0))
+	expectNil(t, queue.AddAgent(&Agent{ID: "1", Number: "+19195551111", State: AgentStateAvailable}))
+	expectNil(t, queue.HandleCallback(&CallbackEvent{EventType: CallbackEventTimeout, CallID: "a1"}))
+	expect(t, getAgent(t, queue, "1").State, AgentStateBusy)
+	expectNil(t, queue.SetAgentState("1", AgentStateOffline))
+	expect(t, getAgent(t, queue, "1").NoAnswerTime.IsZero(), true)
+}
+
+func TestCallQueueRun(t *testing.T) {
+	queue, stop := startCallQueueMockServer(t)
+	defer stop()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	expect(t, queue.Run(ctx, time.Millisecond), context.DeadlineExceeded)
+}
+
+func TestMemoryQueueStore(t *testing.T) {
+	store := NewMemoryQueueStore()
+	now := time.Now()
+	store.SaveCall(&QueuedCall{CallID: "1", EnqueuedTime: now})
+	store.SaveCall(&QueuedCall{CallID: "2", EnqueuedTime: now.Add(-time.Second)})
+	store.SaveCall(&QueuedCall{CallID: "3", EnqueuedTime: now, Priority: 1})
+	calls, _ := store.ListCalls()
+	ids := []string{}
+	for _, call := range calls {
+		ids = append(ids, call.CallID)
+	}
+	expect(t, ids, []string{"3", "2", "1"})
+	store.RemoveCall("3")
+	calls, _ = store.ListCalls()
+	expect(t, len(calls), 2)
+	store.SaveAgent(&Agent{ID: "b"})
+	store.SaveAgent(&Agent{ID: "a"})
+	agents, _ := store.ListAgents()
+	expect(t, agents[0].ID, "a")
+}
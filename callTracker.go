@@ -0,0 +1,366 @@
+package bandwidth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// CallState is state of a call
+type CallState string
+
+const (
+	// CallStateStarted is state of a call which is ringing
+	CallStateStarted CallState = "started"
+	// CallStateActive is state of an answered call
+	CallStateActive CallState = "active"
+	// CallStateTransferring is state of a call which is being transferred
+	CallStateTransferring CallState = "transferring"
+	// CallStateCompleted is state of a finished call
+	CallStateCompleted CallState = "completed"
+	// CallStateRejected is state of a rejected call
+	CallStateRejected CallState = "rejected"
+	// CallStateError is state of a failed call
+	CallStateError CallState = "error"
+)
+
+var callStateTransitions = map[CallState][]CallState{
+	CallStateStarted:      {CallStateActive, CallStateTransferring, CallStateCompleted, CallStateRejected, CallStateError},
+	CallStateActive:       {CallStateTransferring, CallStateCompleted, CallStateError},
+	CallStateTransferring: {CallStateActive, CallStateCompleted, CallStateError},
+}
+
+// IsFinal returns true if the call can't change its state anymore
+func (s CallState) IsFinal() bool {
+	return s == CallStateCompleted || s == CallStateRejected || s == CallStateError
+}
+
+// CanTransitionTo returns true if a call can move from state s to state next
+func (s CallState) CanTransitionTo(next CallState) bool {
+	if s == "" {
+		return true
+	}
+	for _, state := range callStateTransitions[s] {
+		if state == next {
+			return true
+		}
+	}
+	return false
+}
+
+// CallStateTransitionError is returned when a call can't move to requested state
+type CallStateTransitionError struct {
+	CallID   string
+	From, To CallState
+}
+
+func (e *CallStateTransitionError) Error() string {
+	return fmt.Sprintf("Invalid state transition of call %s: %s -> %s", e.CallID, e.From, e.To)
+}
+
+// CallStateUnreachableError is returned by WaitForState() when the call ends without reaching wanted state
+type CallStateUnreachableError struct {
+	CallID string
+	State  CallState
+	Wanted CallState
+}
+
+func (e *CallStateUnreachableError) Error() string {
+	return fmt.Sprintf("Call %s ended in state %s without reaching state %s", e.CallID, e.State, e.Wanted)
+}
+
+// CallStateChange describes a change of call state
+type CallStateChange struct {
+	CallID string
+	From   CallState
+	To     CallState
+	Time   time.Time
+	Event  *CallbackEvent
+}
+
+type trackedCall struct {
+	state       CallState
+	reached     map[CallState]bool
+	subscribers []chan *CallStateChange
+}
+
+// CallTracker keeps states of calls up to date using callbacks and polling
+type CallTracker struct {
+	// PollInterval is interval of polling GetCall() while waiting for a state (0 disables polling)
+	PollInterval time.Duration
+	// ForgetAfter is delay after which calls in a final state are removed from the tracker (0 means never)
+	ForgetAfter time.Duration
+
+	api   *Client
+	mutex sync.Mutex
+	calls map[string]*trackedCall
+}
+
+// NewCallTracker creates new CallTracker instance
+// api can be nil if only callbacks should be used
+// example: tracker := bandwidth.NewCallTracker(api)
+func NewCallTracker(api *Client) *CallTracker {
+	return &CallTracker{PollInterval: 5 * time.Second, ForgetAfter: 10 * time.Minute, api: api, calls: map[string]*trackedCall{}}
+}
+
+func (t *CallTracker) getCall(id string) *trackedCall {
+	call, ok := t.calls[id]
+	if !ok {
+		call = &trackedCall{reached: map[CallState]bool{}}
+		t.calls[id] = call
+	}
+	return call
+}
+
+// Track registers a call with known state (e.g. CallStateStarted after CreateCall())
+func (t *CallTracker) Track(id string, state CallState) error {
+	return t.setState(id, state, nil)
+}
+
+// State returns last known state of the call
+func (t *CallTracker) State(id string) (CallState, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	call, ok := t.calls[id]
+	if !ok || call.state == "" {
+		return "", false
+	}
+	return call.state, true
+}
+
+// Forget removes the call from the tracker and closes its subscriptions
+func (t *CallTracker) Forget(id string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if call, ok := t.calls[id]; ok {
+		for _, ch := range call.subscribers {
+			close(ch)
+		}
+		delete(t.calls, id)
+	}
+}
+
+// Subscribe returns a channel of state changes of the call and function to cancel the subscription
+// The channel is closed after the call reaches a final state
+func (t *CallTracker) Subscribe(id string) (<-chan *CallStateChange, func()) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	ch := make(chan *CallStateChange, 16)
+	call := t.getCall(id)
+	if call.state.IsFinal() {
+		close(ch)
+		return ch, func() {}
+	}
+	call.subscribers = append(call.subscribers, ch)
+	return ch, func() {
+		t.mutex.Lock()
+		defer t.mutex.Unlock()
+		call, ok := t.calls[id]
+		if !ok {
+			return
+		}
+		for i, c := range call.subscribers {
+			if c == ch {
+				call.subscribers = append(call.subscribers[:i], call.subscribers[i+1:]...)
+				close(ch)
+				break
+			}
+		}
+		// a call which is not known yet is not kept after its last subscriber leaves
+		if call.state == "" && len(call.subscribers) == 0 {
+			delete(t.calls, id)
+		}
+	}
+}
+
+// forgetFinished removes the call if it is still the same tracked call (it could be forgotten and tracked again)
+func (t *CallTracker) forgetFinished(id string, call *trackedCall) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.calls[id] == call {
+		delete(t.calls, id)
+	}
+}
+
+func (t *CallTracker) setState(id string, state CallState, event *CallbackEvent) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	call := t.getCall(id)
+	if call.state == state {
+		return nil
+	}
+	if !call.state.CanTransitionTo(state) {
+		return &CallStateTransitionError{CallID: id, From: call.state, To: state}
+	}
+	change := &CallStateChange{CallID: id, From: call.state, To: state, Time: time.Now(), Event: event}
+	call.state = state
+	call.reached[state] = true
+	for _, ch := range call.subscribers {
+		select {
+		case ch <- change:
+		default:
+			if state.IsFinal() {
+				// the final change is always delivered: the oldest unread change is dropped to make room for it
+				select {
+				case <-ch:
+				default:
+				}
+				ch <- change
+			}
+		}
+		if state.IsFinal() {
+			close(ch)
+		}
+	}
+	if state.IsFinal() {
+		call.subscribers = nil
+		if t.ForgetAfter > 0 {
+			time.AfterFunc(t.ForgetAfter, func() { t.forgetFinished(id, call) })
+		}
+	}
+	return nil
+}
+
+func callStateFromEvent(event *CallbackEvent) CallState {
+	if event.CallState != "" {
+		return CallState(event.CallState)
+	}
+	switch event.EventType {
+	case CallbackEventIncomingCall:
+		return CallStateStarted
+	case CallbackEventAnswer:
+		return CallStateActive
+	case CallbackEventHangup, CallbackEventTimeout:
+		return CallStateCompleted
+	case CallbackEventReject:
+		return CallStateRejected
+	}
+	return ""
+}
+
+// HandleCallback updates state of the call using data of a callback
+// It returns error object (CallStateTransitionError for out of order callbacks)
+func (t *CallTracker) HandleCallback(event *CallbackEvent) error {
+	if event.CallID == "" {
+		return nil
+	}
+	state := callStateFromEvent(event)
+	if state == "" {
+		return nil
+	}
+	return t.setState(event.CallID, state, event)
+}
+
+// ServeHTTP allows to use CallTracker as handler of call callbacks
+// Out of order callbacks (CallStateTransitionError) are answered with status 409, other errors with status 500
+// example: http.Handle("/callbacks/calls", tracker)
+func (t *CallTracker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	event, err := ParseCallbackEvent(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := t.HandleCallback(event); err != nil {
+		status := http.StatusInternalServerError
+		if _, ok := err.(*CallStateTransitionError); ok {
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// Poll requests current state of the call from the server (via GetCall() and GetCallEvents())
+// Polled state which is older than already known state (e.g. applied from a callback) is ignored.
+// It returns current state of the call or error
+func (t *CallTracker) Poll(id string) (CallState, error) {
+	if t.api == nil {
+		return "", fmt.Errorf("CallTracker has no Client to poll call %s", id)
+	}
+	call, err := t.api.GetCall(id)
+	if err != nil {
+		return "", err
+	}
+	state := CallState(call.State)
+	if state == "" {
+		events, err := t.api.GetCallEvents(id)
+		if err != nil {
+			return "", err
+		}
+		for _, e := range events {
+			if s := callStateFromEvent(&CallbackEvent{EventType: e.Name}); s != "" {
+				state = s
+			}
+		}
+	}
+	if state == "" {
+		return "", nil
+	}
+	if err := t.setState(id, state, nil); err != nil {
+		if _, ok := err.(*CallStateTransitionError); !ok {
+			return "", err
+		}
+		// the server has returned stale state
+		state, _ = t.State(id)
+	}
+	return state, nil
+}
+
+// WaitForState blocks until the call reaches the state, ends or ctx is done
+// Transient errors of polling (see IsTransientError()) are ignored, polling continues with next tick.
+// It returns error object (CallStateUnreachableError if the call has ended in other state)
+// example: err := tracker.WaitForState(ctx, callID, bandwidth.CallStateActive)
+func (t *CallTracker) WaitForState(ctx context.Context, id string, state CallState) error {
+	changes, cancel := t.Subscribe(id)
+	defer cancel()
+	check := func() (bool, error) {
+		t.mutex.Lock()
+		defer t.mutex.Unlock()
+		call, ok := t.calls[id]
+		if !ok {
+			return false, nil
+		}
+		if call.reached[state] {
+			return true, nil
+		}
+		if call.state.IsFinal() {
+			return true, &CallStateUnreachableError{CallID: id, State: call.state, Wanted: state}
+		}
+		return false, nil
+	}
+	poll := func() error {
+		if _, err := t.Poll(id); err != nil && !IsTransientError(err) {
+			return err
+		}
+		return nil
+	}
+	var ticks <-chan time.Time
+	if t.api != nil && t.PollInterval > 0 {
+		ticker := time.NewTicker(t.PollInterval)
+		defer ticker.Stop()
+		ticks = ticker.C
+		if err := poll(); err != nil {
+			return err
+		}
+	}
+	for {
+		if done, err := check(); done {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case _, ok := <-changes:
+			if !ok {
+				changes = nil
+			}
+		case <-ticks:
+			if err := poll(); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package bandwidth
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCallStateCanTransitionTo(t *testing.T) {
+	expect(t, CallState("").CanTransitionTo(CallStateActive), true)
+	expect(t, CallStateStarted.CanTransitionTo(CallStateActive), true)
+	expect(t, CallStateActive.CanTransitionTo(CallStateTransferring), true)
+	expect(t, CallStateTransferring.CanTransitionTo(CallStateActive), true)
+	expect(t, CallStateActive.CanTransitionTo(CallStateStarted), false)
+	expect(t, CallStateCompleted.CanTransitionTo(CallStateActive), false)
+	expect(t, CallStateRejected.IsFinal(), true)
+	expect(t, CallStateActive.IsFinal(), false)
+}
+
+func TestCallTrackerHandleCallback(t *testing.T) {
+	tracker := NewCallTracker(nil)
+	expectNil(t, tracker.HandleCallback(&CallbackEvent{EventType: CallbackEventIncomingCall, CallID: "123"}))
+	state, ok := tracker.State("123")
+	expect(t, ok, true)
+	expect(t, state, CallStateStarted)
+	expectNil(t, tracker.HandleCallback(&CallbackEvent{EventType: CallbackEventAnswer, CallID: "123", CallState: "active"}))
+	expectNil(t, tracker.HandleCallback(&CallbackEvent{EventType: CallbackEventGather, CallID: "123", CallState: "active"}))
+	expectNil(t, tracker.HandleCallback(&CallbackEvent{EventType: CallbackEventHangup, CallID: "123"}))
+	state, _ = tracker.State("123")
+	expect(t, state, CallStateCompleted)
+	_, ok = tracker.State("456")
+	expect(t, ok, false)
+}
+
+func TestCallTrackerHandleCallbackFail(t *testing.T) {
+	tracker := NewCallTracker(nil)
+	tracker.Track("123", CallStateCompleted)
+	err := tracker.HandleCallback(&CallbackEvent{EventType: CallbackEventAnswer, CallID: "123"})
+	e := err.(*CallStateTransitionError)
+	expect(t, e.From, CallStateCompleted)
+	expect(t, e.To, CallStateActive)
+}
+
+func TestCallTrackerServeHTTP(t *testing.T) {
+	tracker := NewCallTracker(nil)
+	w := httptest.NewRecorder()
+	tracker.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/callback", bytes.NewReader([]byte(`{"eventType": "answer", "callId": "123"}`))))
+	expect(t, w.Code, http.StatusOK)
+	state, _ := tracker.State("123")
+	expect(t, state, CallStateActive)
+	w = httptest.NewRecorder()
+	tracker.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/callback", bytes.NewReader([]byte(`{}`))))
+	expect(t, w.Code, http.StatusBadRequest)
+	tracker.Track("123", CallStateCompleted)
+	w = httptest.NewRecorder()
+	tracker.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/callback", bytes.NewReader([]byte(`{"eventType": "answer", "callId": "123"}`))))
+	expect(t, w.Code, http.StatusConflict)
+}
+
+func TestCallTrackerSubscribe(t *testing.T) {
+	tracker := NewCallTracker(nil)
+	changes, cancel := tracker.Subscribe("123")
+	defer cancel()
+	tracker.Track("123", CallStateStarted)
+	tracker.HandleCallback(&CallbackEvent{EventType: CallbackEventAnswer, CallID: "123"})
+	tracker.HandleCallback(&CallbackEvent{EventType: CallbackEventHangup, CallID: "123"})
+	list := []CallState{}
+	for change := range changes {
+		list = append(list, change.To)
+	}
+	expect(t, list, []CallState{CallStateStarted, CallStateActive, CallStateCompleted})
+}
+
+func TestCallTrackerSubscribeWithFullBuffer(t *testing.T) {
+	tracker := NewCallTracker(nil)
+	changes, cancel := tracker.Subscribe("123")
+	defer cancel()
+	tracker.Track("123", CallStateStarted)
+	for i := 0; i < 20; i++ {
+		tracker.Track("123", CallStateActive)
+		tracker.Track("123", CallStateTransferring)
+	}
+	tracker.Track("123", CallStateCompleted)
+	var last *CallStateChange
+	count := 0
+	for change := range changes {
+		last = change
+		count++
+	}
+	expect(t, count, 16)
+	expect(t, last.To, CallStateCompleted)
+}
+
+func TestCallTrackerForgetAfter(t *testing.T) {
+	tracker := NewCallTracker(nil)
+	tracker.ForgetAfter = 10 * time.Millisecond
+	tracker.Track("123", CallStateActive)
+	tracker.Track("123", CallStateCompleted)
+	_, ok := tracker.State("123")
+	expect(t, ok, true)
+	time.Sleep(50 * time.Millisecond)
+	_, ok = tracker.State("123")
+	expect(t, ok, false)
+	// unknown calls are not kept by subscriptions
+	_, cancel := tracker.Subscribe("456")
+	cancel()
+	tracker.mutex.Lock()
+	expect(t, len(tracker.calls), 0)
+	tracker.mutex.Unlock()
+}
+
+func TestCallTrackerWaitForState(t *testing.T) {
+	tracker := NewCallTracker(nil)
+	tracker.Track("123", CallStateStarted)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		tracker.HandleCallback(&CallbackEvent{EventType: CallbackEventAnswer, CallID: "123"})
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	expectNil(t, tracker.WaitForState(ctx, "123", CallStateActive))
+	tracker.HandleCallback(&CallbackEvent{EventType: CallbackEventHangup, CallID: "123"})
+	expectNil(t, tracker.WaitForState(ctx, "123", CallStateActive))
+}
+
+func TestCallTrackerWaitForStateFail(t *testing.T) {
+	tracker := NewCallTracker(nil)
+	tracker.Track("123", CallStateStarted)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		tracker.HandleCallback(&CallbackEvent{EventType: CallbackEventHangup, CallID: "123", Cause: "NO_ANSWER"})
+	}()
+	err := tracker.WaitForState(context.Background(), "123", CallStateActive)
+	e := err.(*CallStateUnreachableError)
+	expect(t, e.State, CallStateCompleted)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	expect(t, tracker.WaitForState(ctx, "456", CallStateActive), context.DeadlineExceeded)
+}
+
+func TestCallTrackerWaitForStateWithPolling(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery:  "/v1/users/userId/calls/123",
+		Method:        http.MethodGet,
+		ContentToSend: `{"id": "123", "state": "active"}`}})
+	defer server.Close()
+	tracker := NewCallTracker(api)
+	tracker.PollInterval = time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	expectNil(t, tracker.WaitForState(ctx, "123", CallStateActive))
+}
+
+func TestCallTrackerPollWithEvents(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery:  "/v1/users/userId/calls/123",
+		Method:        http.MethodGet,
+		ContentToSend: `{"id": "123"}`,
+	}, RequestHandler{
+		PathAndQuery:  "/v1/users/userId/calls/123/events",
+		Method:        http.MethodGet,
+		ContentToSend: `[{"id": "1", "name": "create"}, {"id": "2", "name": "answer"}, {"id": "3", "name": "hangup"}]`}})
+	defer server.Close()
+	tracker := NewCallTracker(api)
+	state, err := tracker.Poll("123")
+	expectNil(t, err)
+	expect(t, state, CallStateCompleted)
+}
+
+func TestCallTrackerPollFail(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery:     "/v1/users/userId/calls/123",
+		Method:           http.MethodGet,
+		StatusCodeToSend: http.StatusNotFound}})
+	defer server.Close()
+	shouldFail(t, func() (interface{}, error) { return NewCallTracker(api).Poll("123") })
+	shouldFail(t, func() (interface{}, error) { return NewCallTracker(nil).Poll("123") })
+}
+
+func TestCallTrackerPollWithStaleState(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery:  "/v1/users/userId/calls/123",
+		Method:        http.MethodGet,
+		ContentToSend: `{"id": "123", "state": "started"}`}})
+	defer server.Close()
+	tracker := NewCallTracker(api)
+	expectNil(t, tracker.Track("123", CallStateActive))
+	state, err := tracker.Poll("123")
+	expectNil(t, err)
+	expect(t, state, CallStateActive)
+}
+
+func TestCallTrackerWaitForStateWithTransientErrors(t *testing.T) {
+	var mutex sync.Mutex
+	count := 0
+	server, api, _ := startMockServerWithLog(t, func(w http.ResponseWriter, r *http.Request, body string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		count++
+		switch {
+		case count <= 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case count == 3:
+			fmt.Fprint(w, `{"id": "123", "state": "started"}`)
+		default:
+			fmt.Fprint(w, `{"id": "123", "state": "active"}`)
+		}
+	})
+	defer server.Close()
+	tracker := NewCallTracker(api)
+	tracker.PollInterval = time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	expectNil(t, tracker.WaitForState(ctx, "123", CallStateActive))
+}
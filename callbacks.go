@@ -0,0 +1,154 @@
+package bandwidth
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// CallbackEvent struct (payload of Catapult callbacks for calls, conferences, recordings and messages)
+type CallbackEvent struct {
+	EventType           string `json:"eventType"`
+	CallID              string `json:"callId"`
+	CallURI             string `json:"callUri"`
+	CallState           string `json:"callState"`
+	From                string `json:"from"`
+	To                  string `json:"to"`
+	Time                string `json:"time"`
+	Tag                 string `json:"tag"`
+	Cause               string `json:"cause"`
+	State               string `json:"state"`
+	Status              string `json:"status"`
+	Reason              string `json:"reason"`
+	Digits              string `json:"digits"`
+	DTMFDigit           string `json:"dtmfDigit"`
+	GatherID            string `json:"gatherId"`
+	RecordingID         string `json:"recordingId"`
+	RecordingURI        string `json:"recordingUri"`
+	TranscriptionID     string `json:"transcriptionId"`
+	TranscriptionURI    string `json:"transcriptionUri"`
+	Text                string `json:"text"`
+	TextSize            int    `json:"textSize"`
+	TextURL             string `json:"textUrl"`
+	ConferenceID        string `json:"conferenceId"`
+	ConferenceURI       string `json:"conferenceUri"`
+	MemberID            string `json:"memberId"`
+	MemberURI           string `json:"memberUri"`
+	ActiveMembers       int    `json:"activeMembers"`
+	StartTime           string `json:"startTime"`
+	EndTime             string `json:"endTime"`
+	MessageID           string `json:"messageId"`
+	MessageURI          string `json:"messageUri"`
+	Direction           string `json:"direction"`
+	ApplicationID       string `json:"applicationId"`
+	DeliveryState       string `json:"deliveryState"`
+	DeliveryCode        string `json:"deliveryCode"`
+	DeliveryDescription string `json:"deliveryDescription"`
+}
+
+// Callback event types
+const (
+	CallbackEventIncomingCall     = "incomingcall"
+	CallbackEventAnswer           = "answer"
+	CallbackEventHangup           = "hangup"
+	CallbackEventReject           = "reject"
+	CallbackEventTimeout          = "timeout"
+	CallbackEventTransferComplete = "transferComplete"
+	CallbackEventGather           = "gather"
+	CallbackEventDTMF             = "dtmf"
+	CallbackEventSpeak            = "speak"
+	CallbackEventPlayback         = "playback"
+	CallbackEventRecording        = "recording"
+	CallbackEventTranscription    = "transcription"
+	CallbackEventConference       = "conference"
+	CallbackEventConferenceMember = "conference-member"
+	CallbackEventSMS              = "sms"
+	CallbackEventMMS              = "mms"
+)
+
+// ParseCallbackEvent reads a callback sent by Catapult to your callbackUrl
+// Both POST (JSON body) and GET (query string) callbacks are supported
+// It returns CallbackEvent instance or error
+func ParseCallbackEvent(r *http.Request) (*CallbackEvent, error) {
+	event := &CallbackEvent{}
+	if r.Method == http.MethodGet {
+		fillCallbackEventFromQuery(event, r.URL.Query())
+	} else {
+		rawJSON, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		if len(rawJSON) > 0 {
+			if err := json.Unmarshal(rawJSON, event); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if event.EventType == "" {
+		return nil, errors.New("Missing eventType in callback data")
+	}
+	return event, nil
+}
+
+func fillCallbackEventFromQuery(event *CallbackEvent, query map[string][]string) {
+	structType := reflect.TypeOf(event).Elem()
+	structValue := reflect.ValueOf(event).Elem()
+	for i := 0; i < structType.NumField(); i++ {
+		name := strings.Split(structType.Field(i).Tag.Get("json"), ",")[0]
+		values, ok := query[name]
+		if !ok || len(values) == 0 {
+			continue
+		}
+		field := structValue.Field(i)
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(values[0])
+		case reflect.Int:
+			if value, err := strconv.ParseInt(values[0], 10, 64); err == nil {
+				field.SetInt(value)
+			}
+		}
+	}
+}
+
+// MessageCallbackV2 struct (payload of v2 messaging callbacks)
+type MessageCallbackV2 struct {
+	Type        string                 `json:"type"`
+	Time        string                 `json:"time"`
+	Description string                 `json:"description"`
+	To          string                 `json:"to"`
+	ErrorCode   int                    `json:"errorCode"`
+	Message     *CreateMessageResultV2 `json:"message"`
+}
+
+// Types of v2 messaging callbacks
+const (
+	MessageCallbackV2Received  = "message-received"
+	MessageCallbackV2Delivered = "message-delivered"
+	MessageCallbackV2Failed    = "message-failed"
+)
+
+// ParseMessageCallbacksV2 reads callbacks sent by v2 messaging API (it sends a list of callbacks in one request)
+// Callbacks without type or message are skipped, so other callbacks of the request are still handled.
+// It returns list of MessageCallbackV2 instances or error
+func ParseMessageCallbacksV2(r *http.Request) ([]*MessageCallbackV2, error) {
+	rawJSON, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	list := []*MessageCallbackV2{}
+	if err := json.Unmarshal(rawJSON, &list); err != nil {
+		return nil, err
+	}
+	result := make([]*MessageCallbackV2, 0, len(list))
+	for _, callback := range list {
+		if callback != nil && callback.Type != "" && callback.Message != nil {
+			result = append(result, callback)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,90 @@
+package bandwidth
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseCallbackEvent(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/callback", bytes.NewReader([]byte(`{
+		"eventType": "answer",
+		"callId": "123",
+		"callState": "active",
+		"from": "+19195551212",
+		"to": "+19195551213",
+		"time": "2012-11-14T16:21:59.616Z"
+	}`)))
+	event, err := ParseCallbackEvent(r)
+	if err != nil {
+		t.Error("Failed call of ParseCallbackEvent()")
+		return
+	}
+	expect(t, event.EventType, CallbackEventAnswer)
+	expect(t, event.CallID, "123")
+	expect(t, event.CallState, "active")
+	expect(t, event.From, "+19195551212")
+}
+
+func TestParseCallbackEventWithQuery(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/callback?eventType=conference-member&conferenceId=123&memberId=456&activeMembers=2", nil)
+	event, err := ParseCallbackEvent(r)
+	if err != nil {
+		t.Error("Failed call of ParseCallbackEvent()")
+		return
+	}
+	expect(t, event.EventType, CallbackEventConferenceMember)
+	expect(t, event.ConferenceID, "123")
+	expect(t, event.MemberID, "456")
+	expect(t, event.ActiveMembers, 2)
+}
+
+func TestParseCallbackEventFail(t *testing.T) {
+	shouldFail(t, func() (interface{}, error) {
+		return ParseCallbackEvent(httptest.NewRequest(http.MethodPost, "/callback", bytes.NewReader([]byte("invalid\njson"))))
+	})
+	shouldFail(t, func() (interface{}, error) {
+		return ParseCallbackEvent(httptest.NewRequest(http.MethodPost, "/callback", bytes.NewReader([]byte(`{"callId": "123"}`))))
+	})
+}
+
+func TestParseMessageCallbacksV2(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/callback", bytes.NewReader([]byte(`[{
+		"type": "message-received",
+		"time": "2016-09-14T18:20:16Z",
+		"description": "Incoming message received",
+		"to": "+12345678902",
+		"message": {
+			"id": "14762070468292kw2fuqty55yp2b2",
+			"time": "2016-09-14T18:20:16Z",
+			"to": ["+12345678902"],
+			"from": "+12345678901",
+			"text": "Hey",
+			"direction": "in"
+		}
+	}]`)))
+	list, err := ParseMessageCallbacksV2(r)
+	if err != nil {
+		t.Error("Failed call of ParseMessageCallbacksV2()")
+		return
+	}
+	expect(t, len(list), 1)
+	expect(t, list[0].Type, MessageCallbackV2Received)
+	expect(t, list[0].Message.ID, "14762070468292kw2fuqty55yp2b2")
+	expect(t, list[0].Message.Text, "Hey")
+}
+
+func TestParseMessageCallbacksV2WithInvalidCallbacks(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/callback", bytes.NewReader([]byte(`[{"type":"message-received"},{"message":{"id":"1"}},{"type":"message-delivered","message":{"id":"2"}}]`)))
+	list, err := ParseMessageCallbacksV2(r)
+	expectNil(t, err)
+	expect(t, len(list), 1)
+	expect(t, list[0].Message.ID, "2")
+}
+
+func TestParseMessageCallbacksV2Fail(t *testing.T) {
+	shouldFail(t, func() (interface{}, error) {
+		return ParseMessageCallbacksV2(httptest.NewRequest(http.MethodPost, "/callback", bytes.NewReader([]byte(`{}`))))
+	})
+}
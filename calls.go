@@ -82,6 +82,9 @@ type CreateCallData struct {
 // CreateCall creates an outbound phone call
 // It returns ID of created call
 func (api *Client) CreateCall(data *CreateCallData) (string, error) {
+	if err := api.checkNumbers(data.From, data.To); err != nil {
+		return "", err
+	}
 	_, headers, err := api.makeRequest(http.MethodPost, api.concatUserPath(callsPath), nil, data)
 	if err != nil {
 		return "", err
@@ -115,6 +118,15 @@ type UpdateCallData struct {
 // UpdateCall manage an active phone call. E.g. Answer an incoming call, reject an incoming call, turn on / off recording, transfer, hang up
 // It returns error object
 func (api *Client) UpdateCall(id string, changedData *UpdateCallData) (string, error) {
+	if changedData != nil {
+		if err := api.checkNumbers(changedData.TransferTo); err != nil {
+			return "", err
+		}
+		// caller ID of transfer can be a text like "private"
+		if err := api.checkIDOrNumber(changedData.TransferCallerID); err != nil {
+			return "", err
+		}
+	}
 	_, headers, err := api.makeRequest(http.MethodPost, fmt.Sprintf("%s/%s", api.concatUserPath(callsPath), id), nil, changedData)
 	return getIDFromLocationHeader(headers), err
 }
@@ -54,3 +54,8 @@ func (api *Client) StopGather(id string, gatherID string) error {
 func (api *Client) SendDTMFCharactersToCall(id string, dtmfOut string) error {
 	return api.SendDTMFToCall(id, &SendDTMFToCallData{DTMFOut: dtmfOut})
 }
+
+// stopCallAudio stops playing of audio (e.g. looped hold music) in the call
+func (api *Client) stopCallAudio(id string) error {
+	return api.PlayAudioToCallWithMap(id, map[string]interface{}{"fileUrl": ""})
+}
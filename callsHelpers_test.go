@@ -83,3 +83,16 @@ func TestSendDTMFCharactersToCalll(t *testing.T) {
 		return
 	}
 }
+
+func TestStopCallAudio(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery:     "/v1/users/userId/calls/123/audio",
+		Method:           http.MethodPost,
+		EstimatedContent: `{"fileUrl":""}`}})
+	defer server.Close()
+	err := api.stopCallAudio("123")
+	if err != nil {
+		t.Error("Failed call of stopCallAudio()")
+		return
+	}
+}
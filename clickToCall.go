@@ -0,0 +1,277 @@
+package bandwidth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ClickToCallStatus is status of click-to-call session
+type ClickToCallStatus string
+
+const (
+	// ClickToCallCallingAgent means the agent's phone is ringing
+	ClickToCallCallingAgent ClickToCallStatus = "calling-agent"
+	// ClickToCallCallingCustomer means the customer's phone is ringing
+	ClickToCallCallingCustomer ClickToCallStatus = "calling-customer"
+	// ClickToCallConnected means both legs are bridged
+	ClickToCallConnected ClickToCallStatus = "connected"
+	// ClickToCallCompleted means the conversation has finished
+	ClickToCallCompleted ClickToCallStatus = "completed"
+	// ClickToCallFailed means one of legs has not been answered or has failed
+	ClickToCallFailed ClickToCallStatus = "failed"
+)
+
+// ClickToCallOptions is optional parameters of ClickToCall()
+type ClickToCallOptions struct {
+	// From is caller ID of both legs
+	From string
+	// CallbackURL receives callbacks of both legs (they should be passed to Tracker)
+	CallbackURL string
+	// Tracker is used to detect answers (a tracker polling every 2 seconds is used if nil)
+	Tracker *CallTracker
+	// CallTimeout is time (in seconds) to wait for an answer of each leg
+	CallTimeout int
+	// Whisper is played to the agent (via UpdateCallData.WhisperAudio) before the customer is called
+	Whisper *PlayAudioData
+}
+
+// ClickToCallEvent is a change of status of click-to-call session
+type ClickToCallEvent struct {
+	Status ClickToCallStatus
+	Time   time.Time
+	Err    error
+}
+
+// ClickToCallSession is a call between an agent and a customer
+type ClickToCallSession struct {
+	AgentCallID string
+	// Events receives changes of status; it is closed when the session finishes
+	Events <-chan *ClickToCallEvent
+
+	events         chan *ClickToCallEvent
+	tracker        *CallTracker
+	api            *Client
+	mutex          sync.Mutex
+	customerCallID string
+	bridgeID       string
+	status         ClickToCallStatus
+	err            error
+	done           chan struct{}
+}
+
+// CustomerCallID returns ID of the call to the customer (empty before the customer is called)
+func (s *ClickToCallSession) CustomerCallID() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.customerCallID
+}
+
+// BridgeID returns ID of the bridge between the agent and the customer (empty before they are connected)
+func (s *ClickToCallSession) BridgeID() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.bridgeID
+}
+
+// Status returns current status of the session
+func (s *ClickToCallSession) Status() ClickToCallStatus {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.status
+}
+
+// Wait blocks until the session finishes or ctx is done
+// It returns final status and error of the session
+func (s *ClickToCallSession) Wait(ctx context.Context) (ClickToCallStatus, error) {
+	select {
+	case <-ctx.Done():
+		return s.Status(), ctx.Err()
+	case <-s.done:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		return s.status, s.err
+	}
+}
+
+// HangUp finishes the session hanging up both legs
+func (s *ClickToCallSession) HangUp() error {
+	s.mutex.Lock()
+	ids := []string{s.AgentCallID, s.customerCallID}
+	s.mutex.Unlock()
+	var err error
+	for _, id := range ids {
+		if id == "" {
+			continue
+		}
+		if state, ok := s.tracker.State(id); ok && state.IsFinal() {
+			continue
+		}
+		if e := s.api.HangUpCall(id); e != nil {
+			err = e
+		}
+	}
+	return err
+}
+
+func (s *ClickToCallSession) setStatus(status ClickToCallStatus, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.status == ClickToCallCompleted || s.status == ClickToCallFailed {
+		return
+	}
+	s.status, s.err = status, err
+	event := &ClickToCallEvent{Status: status, Time: time.Now(), Err: err}
+	final := status == ClickToCallCompleted || status == ClickToCallFailed
+	select {
+	case s.events <- event:
+	default:
+		if final {
+			// the final event is always delivered: the oldest unread event is dropped to make room for it
+			select {
+			case <-s.events:
+			default:
+			}
+			s.events <- event
+		}
+	}
+	if final {
+		close(s.events)
+		close(s.done)
+	}
+}
+
+// ClickToCall calls the agent, then (after the agent answers) calls the customer and bridges both legs.
+// If any leg fails or hangs up the other leg is hung up.
+// It returns ClickToCallSession instance or error (if the agent can't be called)
+// example: session, err := api.ClickToCall(ctx, "+19195551212", "+19195551213", &bandwidth.ClickToCallOptions{From: "+19195550000"})
+func (api *Client) ClickToCall(ctx context.Context, agent, customer string, options *ClickToCallOptions) (*ClickToCallSession, error) {
+	if options == nil {
+		options = &ClickToCallOptions{}
+	}
+	tracker := options.Tracker
+	if tracker == nil {
+		tracker = NewCallTracker(api)
+		tracker.PollInterval = 2 * time.Second
+	}
+	agentCallID, err := api.CreateCall(&CreateCallData{
+		From:        options.From,
+		To:          agent,
+		CallbackURL: options.CallbackURL,
+		CallTimeout: options.CallTimeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	tracker.Track(agentCallID, CallStateStarted)
+	events := make(chan *ClickToCallEvent, 8)
+	session := &ClickToCallSession{
+		AgentCallID: agentCallID,
+		Events:      events,
+		events:      events,
+		tracker:     tracker,
+		api:         api,
+		done:        make(chan struct{}),
+	}
+	session.setStatus(ClickToCallCallingAgent, nil)
+	go session.run(ctx, customer, options)
+	return session, nil
+}
+
+func (s *ClickToCallSession) fail(err error) {
+	s.HangUp()
+	s.setStatus(ClickToCallFailed, err)
+}
+
+func (s *ClickToCallSession) run(ctx context.Context, customer string, options *ClickToCallOptions) {
+	if err := s.tracker.WaitForState(ctx, s.AgentCallID, CallStateActive); err != nil {
+		s.fail(err)
+		return
+	}
+	if options.Whisper != nil {
+		if _, err := s.api.UpdateCall(s.AgentCallID, &UpdateCallData{WhisperAudio: options.Whisper}); err != nil {
+			s.fail(err)
+			return
+		}
+	}
+	customerCallID, err := s.api.CreateCall(&CreateCallData{
+		From:        options.From,
+		To:          customer,
+		CallbackURL: options.CallbackURL,
+		CallTimeout: options.CallTimeout,
+	})
+	if err != nil {
+		s.fail(err)
+		return
+	}
+	s.mutex.Lock()
+	s.customerCallID = customerCallID
+	s.mutex.Unlock()
+	s.tracker.Track(customerCallID, CallStateStarted)
+	s.setStatus(ClickToCallCallingCustomer, nil)
+	agentChanges, cancelAgent := s.tracker.Subscribe(s.AgentCallID)
+	defer cancelAgent()
+	// the customer's call is polled by WaitForState(), the agent's call and then both calls are polled here
+	var poll <-chan time.Time
+	if s.tracker.api != nil && s.tracker.PollInterval > 0 {
+		ticker := time.NewTicker(s.tracker.PollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+	answered := make(chan error, 1)
+	waitCtx, cancelWait := context.WithCancel(ctx)
+	defer cancelWait()
+	go func() { answered <- s.tracker.WaitForState(waitCtx, customerCallID, CallStateActive) }()
+	for answered != nil {
+		select {
+		case <-poll:
+			s.tracker.Poll(s.AgentCallID)
+		case change, ok := <-agentChanges:
+			if !ok || change.To.IsFinal() {
+				// the agent has hung up while the customer's phone was ringing
+				s.fail(nil)
+				return
+			}
+		case err := <-answered:
+			if err != nil {
+				s.fail(err)
+				return
+			}
+			answered = nil
+		}
+	}
+	bridgeID, err := s.api.CreateBridge(&BridgeData{BridgeAudio: true, CallIDs: []string{s.AgentCallID, customerCallID}})
+	if err != nil {
+		s.fail(err)
+		return
+	}
+	s.mutex.Lock()
+	s.bridgeID = bridgeID
+	s.mutex.Unlock()
+	s.setStatus(ClickToCallConnected, nil)
+	customerChanges, cancelCustomer := s.tracker.Subscribe(customerCallID)
+	defer cancelCustomer()
+	for {
+		select {
+		case <-poll:
+			s.tracker.Poll(s.AgentCallID)
+			s.tracker.Poll(customerCallID)
+		case <-ctx.Done():
+			s.HangUp()
+			s.setStatus(ClickToCallCompleted, ctx.Err())
+			return
+		case change, ok := <-agentChanges:
+			if !ok || change.To.IsFinal() {
+				s.HangUp()
+				s.setStatus(ClickToCallCompleted, nil)
+				return
+			}
+		case change, ok := <-customerChanges:
+			if !ok || change.To.IsFinal() {
+				s.HangUp()
+				s.setStatus(ClickToCallCompleted, nil)
+				return
+			}
+		}
+	}
+}
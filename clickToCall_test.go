@@ -0,0 +1,159 @@
+package bandwidth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func startClickToCallMockServer(t *testing.T) (*Client, func() []string, func()) {
+	var mutex sync.Mutex
+	count := 0
+	server, api, log := startMockServerWithLog(t, func(w http.ResponseWriter, r *http.Request, body string) {
+		switch r.URL.Path {
+		case "/v1/users/userId/calls":
+			mutex.Lock()
+			count++
+			w.Header().Set("Location", fmt.Sprintf("/v1/users/userId/calls/c%d", count))
+			mutex.Unlock()
+		case "/v1/users/userId/bridges":
+			w.Header().Set("Location", "/v1/users/userId/bridges/b1")
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+	return api, log, server.Close
+}
+
+func waitClickToCallStatus(t *testing.T, session *ClickToCallSession, status ClickToCallStatus) {
+	for event := range session.Events {
+		if event.Status == status {
+			return
+		}
+	}
+	t.Fatalf("Status %s is not reached", status)
+}
+
+func TestClickToCall(t *testing.T) {
+	api, log, stop := startClickToCallMockServer(t)
+	defer stop()
+	tracker := NewCallTracker(nil)
+	session, err := api.ClickToCall(context.Background(), "+19195551111", "+19195552222", &ClickToCallOptions{
+		From:    "+19195550000",
+		Tracker: tracker,
+		Whisper: &PlayAudioData{Sentence: "Calling customer"}})
+	if err != nil {
+		t.Error("Failed call of ClickToCall()")
+		return
+	}
+	expect(t, session.AgentCallID, "c1")
+	waitClickToCallStatus(t, session, ClickToCallCallingAgent)
+	tracker.HandleCallback(&CallbackEvent{EventType: CallbackEventAnswer, CallID: "c1"})
+	waitClickToCallStatus(t, session, ClickToCallCallingCustomer)
+	expect(t, session.CustomerCallID(), "c2")
+	tracker.HandleCallback(&CallbackEvent{EventType: CallbackEventAnswer, CallID: "c2"})
+	waitClickToCallStatus(t, session, ClickToCallConnected)
+	expect(t, session.BridgeID(), "b1")
+	tracker.HandleCallback(&CallbackEvent{EventType: CallbackEventHangup, CallID: "c2"})
+	status, err := session.Wait(context.Background())
+	expect(t, status, ClickToCallCompleted)
+	expectNil(t, err)
+	expect(t, log(), []string{
+		`POST /v1/users/userId/calls {"from":"+19195550000","to":"+19195551111"}`,
+		`POST /v1/users/userId/calls/c1 {"whisperAudio":{"sentence":"Calling customer"}}`,
+		`POST /v1/users/userId/calls {"from":"+19195550000","to":"+19195552222"}`,
+		`POST /v1/users/userId/bridges {"bridgeAudio":true,"callIds":["c1","c2"]}`,
+		`POST /v1/users/userId/calls/c1 {"state":"completed"}`,
+	})
+}
+
+func TestClickToCallWithUnansweredCustomer(t *testing.T) {
+	api, log, stop := startClickToCallMockServer(t)
+	defer stop()
+	tracker := NewCallTracker(nil)
+	session, _ := api.ClickToCall(context.Background(), "+19195551111", "+19195552222", &ClickToCallOptions{Tracker: tracker})
+	tracker.HandleCallback(&CallbackEvent{EventType: CallbackEventAnswer, CallID: "c1"})
+	waitClickToCallStatus(t, session, ClickToCallCallingCustomer)
+	tracker.HandleCallback(&CallbackEvent{EventType: CallbackEventTimeout, CallID: "c2"})
+	status, err := session.Wait(context.Background())
+	expect(t, status, ClickToCallFailed)
+	_, ok := err.(*CallStateUnreachableError)
+	expect(t, ok, true)
+	requests := log()
+	expect(t, requests[len(requests)-1], `POST /v1/users/userId/calls/c1 {"state":"completed"}`)
+}
+
+func TestClickToCallWithAgentHangupWhileCustomerRings(t *testing.T) {
+	var mutex sync.Mutex
+	count := 0
+	server, api, log := startMockServerWithLog(t, func(w http.ResponseWriter, r *http.Request, body string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		switch r.Method + " " + r.URL.Path {
+		case "POST /v1/users/userId/calls":
+			count++
+			w.Header().Set("Location", fmt.Sprintf("/v1/users/userId/calls/c%d", count))
+			w.WriteHeader(http.StatusCreated)
+		case "GET /v1/users/userId/calls/c1":
+			// the agent hangs up after the customer is called (no callbacks are received)
+			state := "active"
+			if count > 1 {
+				state = "completed"
+			}
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"id": "c1", "state": "%s"}`, state)
+		case "GET /v1/users/userId/calls/c2":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"id": "c2", "state": "started"}`)
+		}
+	})
+	defer server.Close()
+	tracker := NewCallTracker(api)
+	tracker.PollInterval = time.Millisecond
+	session, _ := api.ClickToCall(context.Background(), "+19195551111", "+19195552222", &ClickToCallOptions{Tracker: tracker})
+	status, err := session.Wait(context.Background())
+	expect(t, status, ClickToCallFailed)
+	expectNil(t, err)
+	hungUp := false
+	for _, request := range log() {
+		hungUp = hungUp || request == `POST /v1/users/userId/calls/c2 {"state":"completed"}`
+	}
+	expect(t, hungUp, true)
+}
+
+func TestClickToCallSessionFinalEvent(t *testing.T) {
+	events := make(chan *ClickToCallEvent, 1)
+	session := &ClickToCallSession{Events: events, events: events, done: make(chan struct{})}
+	session.setStatus(ClickToCallCallingAgent, nil)
+	session.setStatus(ClickToCallCallingCustomer, nil)
+	session.setStatus(ClickToCallFailed, nil)
+	list := []ClickToCallStatus{}
+	for event := range session.Events {
+		list = append(list, event.Status)
+	}
+	expect(t, list, []ClickToCallStatus{ClickToCallFailed})
+}
+
+func TestClickToCallWithCanceledContext(t *testing.T) {
+	api, _, stop := startClickToCallMockServer(t)
+	defer stop()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	session, _ := api.ClickToCall(ctx, "+19195551111", "+19195552222", &ClickToCallOptions{Tracker: NewCallTracker(nil)})
+	status, err := session.Wait(context.Background())
+	expect(t, status, ClickToCallFailed)
+	expect(t, err, context.DeadlineExceeded)
+}
+
+func TestClickToCallFail(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery:     "/v1/users/userId/calls",
+		Method:           http.MethodPost,
+		StatusCodeToSend: http.StatusBadRequest}})
+	defer server.Close()
+	shouldFail(t, func() (interface{}, error) {
+		return api.ClickToCall(context.Background(), "+19195551111", "+19195552222", nil)
+	})
+}
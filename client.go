@@ -24,11 +24,42 @@ func (e *RateLimitError) Error() string {
 	return fmt.Sprintf("RateLimitError: reset at %v", e.Reset)
 }
 
+// HTTPError is error of failed http request (except rate limit errors)
+type HTTPError struct {
+	StatusCode int
+	// Message is error message (or error code) returned by API
+	Message string
+	// Body is text of a response which is not JSON
+	Body string
+}
+
+func (e *HTTPError) Error() string {
+	switch {
+	case e.Message != "":
+		return e.Message
+	case e.Body != "":
+		return fmt.Sprintf("Http code %d: %s", e.StatusCode, e.Body)
+	}
+	return fmt.Sprintf("Http code %d", e.StatusCode)
+}
+
+// isNotFoundError returns true if the requested object doesn't exist (http code 404)
+func isNotFoundError(err error) bool {
+	e, ok := err.(*HTTPError)
+	return ok && e.StatusCode == http.StatusNotFound
+}
+
 // Client is main API object
 type Client struct {
 	UserID, APIToken, APISecret string
 	APIEndPoint                 string
 	HTTPClient                  *http.Client
+	// StrictNumbers enables validation of phone numbers (they should be in E.164 format) before sending of requests
+	StrictNumbers bool
+	// MessageValidators check messages before sending (see SMSSegmentLimit)
+	MessageValidators []MessageValidator
+	// RetryPolicy is used to retry failed media requests (nil disables retries)
+	RetryPolicy *RetryPolicy
 }
 
 // New creates new instances of api
@@ -43,7 +74,7 @@ func New(userID, apiToken, apiSecret string, other ...string) (*Client, error) {
 	if l > 0 {
 		apiEndPoint = other[0]
 	}
-	client := &Client{userID, apiToken, apiSecret, apiEndPoint, http.DefaultClient}
+	client := &Client{UserID: userID, APIToken: apiToken, APISecret: apiSecret, APIEndPoint: apiEndPoint, HTTPClient: http.DefaultClient}
 	return client, nil
 }
 
@@ -55,12 +86,15 @@ func (c *Client) concatUserPath(path string) string {
 }
 
 func (c *Client) prepareURL(path string, version string) string {
+	return c.prepareURLWithEndPoint(c.APIEndPoint, path, version)
+}
+
+func (c *Client) prepareURLWithEndPoint(endPoint, path string, version string) string {
 	if path[0] != '/' {
 		path = "/" + path
 	}
 
     //Workaround for the V1/V2 base url split
-    var endPoint = c.APIEndPoint
     var apiExtension = ""
     if version == "v2" {
         apiExtension = "/api"
@@ -70,7 +104,11 @@ func (c *Client) prepareURL(path string, version string) string {
 }
 
 func (c *Client) createRequest(method, path string, version string) (*http.Request, error) {
-	request, err := http.NewRequest(method, c.prepareURL(path, version), nil)
+	return c.createRequestWithEndPoint(c.APIEndPoint, method, path, version)
+}
+
+func (c *Client) createRequestWithEndPoint(endPoint, method, path string, version string) (*http.Request, error) {
+	request, err := http.NewRequest(method, c.prepareURLWithEndPoint(endPoint, path, version), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -115,13 +153,29 @@ func (c *Client) checkResponse(response *http.Response, responseBody interface{}
 		message = errorBody["code"]
 	}
 	if message == nil {
-		return nil, nil, fmt.Errorf("Http code %d", response.StatusCode)
+		return nil, nil, &HTTPError{StatusCode: response.StatusCode}
 	}
-	return nil, nil, errors.New(message.(string))
+	return nil, nil, &HTTPError{StatusCode: response.StatusCode, Message: fmt.Sprint(message)}
 }
 
-func (c *Client) makeRequestInternal(method, path string, version string, data ...interface{}) (interface{}, http.Header, error) {
-	request, err := c.createRequest(method, path, version)
+// responseError returns error of failed response of a request which doesn't use JSON (e.g. media files)
+func (c *Client) responseError(response *http.Response) error {
+	text, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return err
+	}
+	text = bytes.TrimSpace(text)
+	if response.StatusCode == 429 || len(text) == 0 || text[0] == '{' {
+		response.Body = nopCloser{bytes.NewReader(text)}
+		_, _, err = c.checkResponse(response, nil)
+		return err
+	}
+	return &HTTPError{StatusCode: response.StatusCode, Body: string(text)}
+}
+
+func (c *Client) makeRequestInternal(endPoint, method, path string, version string, data ...interface{}) (interface{}, http.Header, error) {
+	request, err := c.createRequestWithEndPoint(endPoint, method, path, version)
 	var responseBody interface{}
 	treatDataAsQuery := false
 	if err != nil {
@@ -182,11 +236,16 @@ func (c *Client) makeRequestInternal(method, path string, version string, data .
 }
 
 func (c *Client) makeRequest(method, path string, data ...interface{}) (interface{}, http.Header, error) {
-	return c.makeRequestInternal(method, path, "v1", data...)
+	return c.makeRequestInternal(c.APIEndPoint, method, path, "v1", data...)
 }
 
 func (c *Client) makeRequestV2(method, path string, data ...interface{}) (interface{}, http.Header, error) {
-	return c.makeRequestInternal(method, path, "v2", data...)
+	return c.makeRequestInternal(c.APIEndPoint, method, path, "v2", data...)
+}
+
+// makeRequestV2ToEndPoint is used to send requests to v2 API hosted on other endpoint (e.g. messaging API)
+func (c *Client) makeRequestV2ToEndPoint(endPoint, method, path string, data ...interface{}) (interface{}, http.Header, error) {
+	return c.makeRequestInternal(endPoint, method, path, "v2", data...)
 }
 
 func getIDFromLocationHeader(headers http.Header) string {
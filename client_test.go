@@ -98,6 +98,12 @@ func TestCheckResponseFail(t *testing.T) {
 		return api.checkResponse(createFakeResponse(`{"code": "400", "message": "some error"}`, 400), nil)
 	})
 	expect(t, err.Error(), "some error")
+	expect(t, err.(*HTTPError).StatusCode, 400)
+	err = fail(func() (interface{}, http.Header, error) {
+		return api.checkResponse(createFakeResponse(`{"message": "server error"}`, 503), nil)
+	})
+	expect(t, err.(*HTTPError).StatusCode, 503)
+	expect(t, IsTransientError(err), true)
 	err = fail(func() (interface{}, http.Header, error) {
 		return api.checkResponse(createFakeResponse(`{"code": "400"}`, 400), nil)
 	})
@@ -0,0 +1,382 @@
+package bandwidth
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// ConferenceRoomData struct
+type ConferenceRoomData struct {
+	Conference CreateConferenceData
+	// ModeratorCallID is ID of the moderator's call
+	ModeratorCallID string
+	// WaitForModerator holds other members until the moderator joins
+	WaitForModerator bool
+	// MaxParticipants limits count of active members including the moderator (0 means unlimited)
+	MaxParticipants int
+	// Announcement returns audio played to a member after joining (nil result means no announcement)
+	Announcement func(member *RoomMember) *PlayAudioData
+}
+
+// RoomMember is a member of ConferenceRoom
+type RoomMember struct {
+	ID        string
+	CallID    string
+	Moderator bool
+	Hold      bool
+	Mute      bool
+	Active    bool
+}
+
+// ConferenceRoomFullError is returned by Join() when the room has MaxParticipants active members
+type ConferenceRoomFullError struct {
+	ConferenceID    string
+	MaxParticipants int
+}
+
+func (e *ConferenceRoomFullError) Error() string {
+	return fmt.Sprintf("Conference %s is full (max %d participants)", e.ConferenceID, e.MaxParticipants)
+}
+
+// ConferenceRoom is a conference with a moderator
+// Member callbacks of the conference should be passed to HandleCallback() (or ServeHTTP())
+type ConferenceRoom struct {
+	ID string
+
+	api        *Client
+	data       *ConferenceRoomData
+	mutex      sync.Mutex
+	members    map[string]*RoomMember
+	started    bool
+	mutedAll   bool
+	terminated bool
+	// joining keeps members being added by Join() by their call IDs
+	joining map[string]*RoomMember
+}
+
+// CreateConferenceRoom creates a conference and returns ConferenceRoom to manage it
+// It returns ConferenceRoom instance or error
+// example: room, err := api.CreateConferenceRoom(&bandwidth.ConferenceRoomData{Conference: bandwidth.CreateConferenceData{From: "+19195551212"}, ModeratorCallID: "callId", WaitForModerator: true})
+func (api *Client) CreateConferenceRoom(data *ConferenceRoomData) (*ConferenceRoom, error) {
+	id, err := api.CreateConference(&data.Conference)
+	if err != nil {
+		return nil, err
+	}
+	return &ConferenceRoom{ID: id, api: api, data: data, members: map[string]*RoomMember{}, joining: map[string]*RoomMember{}}, nil
+}
+
+// Started returns true if the moderator has joined the room (or the room doesn't wait for the moderator)
+func (r *ConferenceRoom) Started() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.isStarted()
+}
+
+func (r *ConferenceRoom) isStarted() bool {
+	return r.started || !r.data.WaitForModerator
+}
+
+// Members returns active members of the room
+func (r *ConferenceRoom) Members() []*RoomMember {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	list := make([]*RoomMember, 0, len(r.members))
+	for _, m := range r.members {
+		if m.Active {
+			member := *m
+			list = append(list, &member)
+		}
+	}
+	return list
+}
+
+func (r *ConferenceRoom) activeCount() int {
+	count := 0
+	for _, m := range r.members {
+		if m.Active {
+			count++
+		}
+	}
+	return count
+}
+
+// newMember creates a member with hold and mute flags required by current state of the room
+func (r *ConferenceRoom) newMember(id, callID string) *RoomMember {
+	moderator := callID != "" && callID == r.data.ModeratorCallID
+	return &RoomMember{
+		ID:        id,
+		CallID:    callID,
+		Moderator: moderator,
+		Hold:      !moderator && !r.isStarted(),
+		Mute:      !moderator && r.mutedAll,
+		Active:    true,
+	}
+}
+
+// Join adds the call to the room
+// Other members are held until the moderator joins if WaitForModerator is set
+// It returns added member or error (ConferenceRoomFullError if the room is full)
+func (r *ConferenceRoom) Join(callID string) (*RoomMember, error) {
+	r.mutex.Lock()
+	if r.data.MaxParticipants > 0 && r.activeCount()+len(r.joining) >= r.data.MaxParticipants {
+		r.mutex.Unlock()
+		return nil, &ConferenceRoomFullError{ConferenceID: r.ID, MaxParticipants: r.data.MaxParticipants}
+	}
+	member := r.newMember("", callID)
+	// the member reserves its place in the room while it is being created (see joined())
+	r.joining[callID] = member
+	r.mutex.Unlock()
+	id, err := r.api.CreateConferenceMember(r.ID, &CreateConferenceMemberData{CallID: callID, Hold: member.Hold, Mute: member.Mute})
+	r.mutex.Lock()
+	delete(r.joining, callID)
+	if err != nil {
+		r.mutex.Unlock()
+		return nil, err
+	}
+	if registered, ok := r.members[id]; ok {
+		// callback of the member has been received before CreateConferenceMember() returned
+		member = registered
+	} else {
+		member.ID = id
+		r.members[id] = member
+	}
+	result := *member
+	r.mutex.Unlock()
+	if err := r.admitted(&result); err != nil {
+		return &result, err
+	}
+	return &result, nil
+}
+
+// admitted starts the room if the moderator has joined and plays the announcement to the member
+func (r *ConferenceRoom) admitted(member *RoomMember) error {
+	if member.Moderator {
+		if err := r.start(); err != nil {
+			return err
+		}
+	}
+	if r.data.Announcement != nil {
+		if audio := r.data.Announcement(member); audio != nil {
+			return r.Announce(member.ID, audio)
+		}
+	}
+	return nil
+}
+
+// start releases held members
+func (r *ConferenceRoom) start() error {
+	r.mutex.Lock()
+	r.started = true
+	held := []string{}
+	for id, m := range r.members {
+		if m.Active && m.Hold {
+			held = append(held, id)
+		}
+	}
+	r.mutex.Unlock()
+	var err error
+	for _, id := range held {
+		if e := r.api.HoldConferenceMember(r.ID, id, false); e != nil {
+			err = e
+			continue
+		}
+		r.mutex.Lock()
+		r.members[id].Hold = false
+		r.mutex.Unlock()
+	}
+	return err
+}
+
+// MuteAll mutes (or unmutes) all members except the moderator
+// Members joining later are muted too until MuteAll(false) is called
+// It returns error object
+func (r *ConferenceRoom) MuteAll(mute bool) error {
+	r.mutex.Lock()
+	r.mutedAll = mute
+	ids := []string{}
+	for id, m := range r.members {
+		if m.Active && !m.Moderator && m.Mute != mute {
+			ids = append(ids, id)
+		}
+	}
+	r.mutex.Unlock()
+	var err error
+	for _, id := range ids {
+		if e := r.api.MuteConferenceMember(r.ID, id, mute); e != nil {
+			err = e
+			continue
+		}
+		r.mutex.Lock()
+		r.members[id].Mute = mute
+		r.mutex.Unlock()
+	}
+	return err
+}
+
+// Kick removes the member from the room
+// It returns error object
+func (r *ConferenceRoom) Kick(memberID string) error {
+	if err := r.api.DeleteConferenceMember(r.ID, memberID); err != nil {
+		return err
+	}
+	return r.left(memberID)
+}
+
+// Announce plays an audio or speaks a sentence to one member of the room
+// It returns error object
+func (r *ConferenceRoom) Announce(memberID string, data *PlayAudioData) error {
+	return r.api.PlayAudioToConferenceMember(r.ID, memberID, data)
+}
+
+// Terminate finishes the conference
+// It returns error object
+func (r *ConferenceRoom) Terminate() error {
+	r.mutex.Lock()
+	if r.terminated {
+		r.mutex.Unlock()
+		return nil
+	}
+	r.terminated = true
+	r.mutex.Unlock()
+	return r.api.TerminateConference(r.ID)
+}
+
+// left marks the member as removed and terminates the conference if the moderator has left
+func (r *ConferenceRoom) left(memberID string) error {
+	r.mutex.Lock()
+	member, ok := r.members[memberID]
+	moderator := ok && member.Active && member.Moderator
+	if ok {
+		member.Active = false
+	}
+	r.mutex.Unlock()
+	if moderator {
+		return r.Terminate()
+	}
+	return nil
+}
+
+// joined registers a member added outside of Join() (e.g. by BXML) and applies rules of the room to it
+// hold and mute are current flags of the member
+func (r *ConferenceRoom) joined(memberID, callID string, hold, mute bool) error {
+	r.mutex.Lock()
+	if _, ok := r.members[memberID]; ok {
+		r.mutex.Unlock()
+		return nil
+	}
+	if joining, ok := r.joining[callID]; ok && callID != "" {
+		// the member is being added by Join() which applies rules of the room
+		member := *joining
+		member.ID = memberID
+		r.members[memberID] = &member
+		r.mutex.Unlock()
+		return nil
+	}
+	member := r.newMember(memberID, callID)
+	full := r.data.MaxParticipants > 0 && r.activeCount()+len(r.joining) >= r.data.MaxParticipants
+	r.members[memberID] = member
+	result := *member
+	r.mutex.Unlock()
+	if full {
+		return r.Kick(memberID)
+	}
+	changes := map[string]interface{}{}
+	if result.Hold != hold {
+		changes["hold"] = result.Hold
+	}
+	if result.Mute != mute {
+		changes["mute"] = result.Mute
+	}
+	if len(changes) > 0 {
+		if err := r.api.updateConferenceMemberWithMap(r.ID, memberID, changes); err != nil {
+			return err
+		}
+	}
+	return r.admitted(&result)
+}
+
+// HandleCallback updates the roster of the room using data of a conference member callback
+// It returns error object
+func (r *ConferenceRoom) HandleCallback(event *CallbackEvent) error {
+	if event.EventType != CallbackEventConferenceMember || event.MemberID == "" {
+		return nil
+	}
+	if event.ConferenceID != "" && event.ConferenceID != r.ID {
+		return nil
+	}
+	switch event.State {
+	case "active":
+		// members join without hold and mute
+		return r.joined(event.MemberID, event.CallID, false, false)
+	case "completed":
+		return r.left(event.MemberID)
+	}
+	return nil
+}
+
+// ServeHTTP allows to use ConferenceRoom as handler of conference callbacks
+func (r *ConferenceRoom) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	event, err := ParseCallbackEvent(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := r.HandleCallback(event); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// Sync reconciles the roster of the room with GetConferenceMembers()
+// New active members get rules of the room (hold, mute, MaxParticipants and announcement) like members added by callbacks.
+// It releases held members if the moderator has joined and terminates the conference if the moderator has left
+// It returns error object
+func (r *ConferenceRoom) Sync() error {
+	list, err := r.api.GetConferenceMembers(r.ID)
+	if err != nil {
+		return err
+	}
+	moderatorLeft, moderatorJoined := false, false
+	added := []*ConferenceMember{}
+	r.mutex.Lock()
+	for _, m := range list {
+		active := m.State == "active"
+		member, ok := r.members[m.ID]
+		if !ok {
+			if active {
+				added = append(added, m)
+			} else {
+				member = r.newMember(m.ID, m.GetCallID())
+				member.Active = false
+				r.members[m.ID] = member
+			}
+			continue
+		}
+		if member.Moderator && member.Active && !active {
+			moderatorLeft = true
+		}
+		if member.Moderator && active && !r.started {
+			moderatorJoined = true
+		}
+		member.Active = active
+		member.Hold = m.Hold
+		member.Mute = m.Mute
+	}
+	r.mutex.Unlock()
+	if moderatorLeft {
+		return r.Terminate()
+	}
+	if moderatorJoined {
+		if err := r.start(); err != nil {
+			return err
+		}
+	}
+	for _, m := range added {
+		if e := r.joined(m.ID, m.GetCallID(), m.Hold, m.Mute); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
@@ -0,0 +1,162 @@
+package bandwidth
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func startConferenceRoomMockServer(t *testing.T) (*Client, func() []string, func()) {
+	handlers := []RequestHandler{
+		RequestHandler{
+			PathAndQuery:     "/v1/users/userId/conferences",
+			Method:           http.MethodPost,
+			HeadersToSend:    map[string]string{"Location": "/v1/users/userId/conferences/conf1"},
+			StatusCodeToSend: http.StatusCreated},
+		RequestHandler{
+			PathAndQuery:  "/v1/users/userId/conferences/conf1/members",
+			Method:        http.MethodGet,
+			ContentToSend: `[{"id":"m1","call":"/v1/users/userId/calls/c1","state":"active","hold":true},{"id":"m2","call":"/v1/users/userId/calls/mod","state":"active"}]`}}
+	count := 0
+	server, api, log := startMockServerWithLog(t, func(w http.ResponseWriter, r *http.Request, body string) {
+		if serveRequestHandlers(t, handlers, w, r, body) {
+			return
+		}
+		if r.URL.Path == "/v1/users/userId/conferences/conf1/members" && r.Method == http.MethodPost {
+			// members get sequential IDs
+			count++
+			w.Header().Set("Location", fmt.Sprintf("/v1/users/userId/conferences/conf1/members/m%d", count))
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+	return api, log, server.Close
+}
+
+func TestConferenceRoomWaitForModerator(t *testing.T) {
+	api, log, stop := startConferenceRoomMockServer(t)
+	defer stop()
+	room, err := api.CreateConferenceRoom(&ConferenceRoomData{
+		Conference:       CreateConferenceData{From: "+19195551212"},
+		ModeratorCallID:  "mod",
+		WaitForModerator: true,
+		Announcement: func(member *RoomMember) *PlayAudioData {
+			if member.Moderator {
+				return nil
+			}
+			return &PlayAudioData{Sentence: "Welcome"}
+		}})
+	if err != nil {
+		t.Error("Failed call of CreateConferenceRoom()")
+		return
+	}
+	expect(t, room.ID, "conf1")
+	member, err := room.Join("c1")
+	expectNil(t, err)
+	expect(t, member.Hold, true)
+	expect(t, room.Started(), false)
+	member, err = room.Join("mod")
+	expectNil(t, err)
+	expect(t, member.Moderator, true)
+	expect(t, room.Started(), true)
+	expect(t, len(room.Members()), 2)
+	expect(t, log(), []string{
+		`POST /v1/users/userId/conferences {"from":"+19195551212"}`,
+		`POST /v1/users/userId/conferences/conf1/members {"callId":"c1","hold":true}`,
+		`POST /v1/users/userId/conferences/conf1/members/m1/audio {"sentence":"Welcome"}`,
+		`POST /v1/users/userId/conferences/conf1/members {"callId":"mod"}`,
+		`POST /v1/users/userId/conferences/conf1/members/m1 {"hold":false}`,
+	})
+}
+
+func TestConferenceRoomMuteAllAndKick(t *testing.T) {
+	api, log, stop := startConferenceRoomMockServer(t)
+	defer stop()
+	room, _ := api.CreateConferenceRoom(&ConferenceRoomData{ModeratorCallID: "mod", MaxParticipants: 2})
+	room.Join("mod")
+	room.Join("c1")
+	_, err := room.Join("c2")
+	expect(t, err, &ConferenceRoomFullError{ConferenceID: "conf1", MaxParticipants: 2})
+	expectNil(t, room.MuteAll(true))
+	expectNil(t, room.Kick("m2"))
+	expect(t, len(room.Members()), 1)
+	_, err = room.Join("c2")
+	expectNil(t, err)
+	expect(t, log()[3:], []string{
+		`POST /v1/users/userId/conferences/conf1/members/m2 {"mute":true}`,
+		`POST /v1/users/userId/conferences/conf1/members/m2 {"state":"completed"}`,
+		`POST /v1/users/userId/conferences/conf1/members {"callId":"c2","mute":true}`,
+	})
+}
+
+func TestConferenceRoomHandleCallback(t *testing.T) {
+	api, log, stop := startConferenceRoomMockServer(t)
+	defer stop()
+	room, _ := api.CreateConferenceRoom(&ConferenceRoomData{ModeratorCallID: "mod", WaitForModerator: true})
+	expectNil(t, room.HandleCallback(&CallbackEvent{EventType: CallbackEventConferenceMember, ConferenceID: "conf1", MemberID: "m1", CallID: "c1", State: "active"}))
+	expectNil(t, room.HandleCallback(&CallbackEvent{EventType: CallbackEventConferenceMember, ConferenceID: "other", MemberID: "m9", CallID: "c9", State: "active"}))
+	expectNil(t, room.HandleCallback(&CallbackEvent{EventType: CallbackEventConferenceMember, ConferenceID: "conf1", MemberID: "m2", CallID: "mod", State: "active"}))
+	expectNil(t, room.HandleCallback(&CallbackEvent{EventType: CallbackEventConferenceMember, ConferenceID: "conf1", MemberID: "m2", CallID: "mod", State: "completed"}))
+	expectNil(t, room.HandleCallback(&CallbackEvent{EventType: CallbackEventConferenceMember, ConferenceID: "conf1", MemberID: "m2", CallID: "mod", State: "completed"}))
+	expect(t, log()[1:], []string{
+		`POST /v1/users/userId/conferences/conf1/members/m1 {"hold":true}`,
+		`POST /v1/users/userId/conferences/conf1/members/m1 {"hold":false}`,
+		`POST /v1/users/userId/conferences/conf1 {"state":"completed"}`,
+	})
+}
+
+func TestConferenceRoomSync(t *testing.T) {
+	api, log, stop := startConferenceRoomMockServer(t)
+	defer stop()
+	room, _ := api.CreateConferenceRoom(&ConferenceRoomData{ModeratorCallID: "mod", WaitForModerator: true})
+	expectNil(t, room.Sync())
+	expect(t, room.Started(), true)
+	expect(t, len(room.Members()), 2)
+	expect(t, log()[1:], []string{
+		`GET /v1/users/userId/conferences/conf1/members`,
+		`POST /v1/users/userId/conferences/conf1/members/m1 {"hold":false}`,
+	})
+}
+
+func TestConferenceRoomSyncWithRules(t *testing.T) {
+	api, log, stop := startConferenceRoomMockServer(t)
+	defer stop()
+	room, _ := api.CreateConferenceRoom(&ConferenceRoomData{
+		ModeratorCallID: "other",
+		MaxParticipants: 1,
+		Announcement:    func(member *RoomMember) *PlayAudioData { return &PlayAudioData{Sentence: "Welcome"} }})
+	expectNil(t, room.MuteAll(true))
+	expectNil(t, room.Sync())
+	members := room.Members()
+	expect(t, len(members), 1)
+	expect(t, *members[0], RoomMember{ID: "m1", CallID: "c1", Mute: true, Active: true})
+	expect(t, log()[1:], []string{
+		`GET /v1/users/userId/conferences/conf1/members`,
+		`POST /v1/users/userId/conferences/conf1/members/m1 {"hold":false,"mute":true}`,
+		`POST /v1/users/userId/conferences/conf1/members/m1/audio {"sentence":"Welcome"}`,
+		`POST /v1/users/userId/conferences/conf1/members/m2 {"state":"completed"}`,
+	})
+}
+
+func TestConferenceRoomJoinWithEarlyCallback(t *testing.T) {
+	var room *ConferenceRoom
+	server, api, log := startMockServerWithLog(t, func(w http.ResponseWriter, r *http.Request, body string) {
+		switch r.URL.Path {
+		case "/v1/users/userId/conferences":
+			w.Header().Set("Location", "/v1/users/userId/conferences/conf1")
+		case "/v1/users/userId/conferences/conf1/members":
+			// the callback is handled before the response of member creation
+			room.HandleCallback(&CallbackEvent{EventType: CallbackEventConferenceMember, ConferenceID: "conf1", MemberID: "m1", CallID: "c1", State: "active"})
+			w.Header().Set("Location", "/v1/users/userId/conferences/conf1/members/m1")
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+	defer server.Close()
+	room, _ = api.CreateConferenceRoom(&ConferenceRoomData{ModeratorCallID: "mod", WaitForModerator: true, MaxParticipants: 1})
+	member, err := room.Join("c1")
+	expectNil(t, err)
+	expect(t, *member, RoomMember{ID: "m1", CallID: "c1", Hold: true, Active: true})
+	expect(t, len(room.Members()), 1)
+	expect(t, log()[1:], []string{
+		`POST /v1/users/userId/conferences/conf1/members {"callId":"c1","hold":true}`,
+	})
+}
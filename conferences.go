@@ -75,6 +75,12 @@ func (api *Client) UpdateConference(id string, data *UpdateConferenceData) error
 	return err
 }
 
+// updateConferenceWithMap is used to send false values of boolean fields (UpdateConferenceData omits them)
+func (api *Client) updateConferenceWithMap(id string, data map[string]interface{}) error {
+	_, _, err := api.makeRequest(http.MethodPost, fmt.Sprintf("%s/%s", api.concatUserPath(conferencesPath), id), nil, data)
+	return err
+}
+
 // PlayAudioToConference plays an audio or speak a sentence in a conference
 // It returns error object
 func (api *Client) PlayAudioToConference(id string, data *PlayAudioData) error {
@@ -155,6 +161,12 @@ func (api *Client) UpdateConferenceMember(id string, memberID string, data *Upda
 	return err
 }
 
+// updateConferenceMemberWithMap is used to send false values of boolean fields (UpdateConferenceMemberData omits them)
+func (api *Client) updateConferenceMemberWithMap(id string, memberID string, data map[string]interface{}) error {
+	_, _, err := api.makeRequest(http.MethodPost, fmt.Sprintf("%s/%s/%s/%s", api.concatUserPath(conferencesPath), id, "members", memberID), nil, data)
+	return err
+}
+
 // PlayAudioToConferenceMember plays an audio or speak a sentence to a conference member
 // It returns error object
 func (api *Client) PlayAudioToConferenceMember(id string, memberID string, data *PlayAudioData) error {
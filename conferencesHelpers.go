@@ -9,7 +9,7 @@ func (api *Client) TerminateConference(id string) error{
 // MuteConference mutes/unmutes a  conference
 // example: api.MuteConference("conferenceId", false) //unmute it
 func (api *Client) MuteConference(id string, mute bool) error{
-	return api.UpdateConference(id, &UpdateConferenceData{Mute: mute})
+	return api.updateConferenceWithMap(id, map[string]interface{}{"mute": mute})
 }
 
 // DeleteConferenceMember removes the member from the conference
@@ -21,11 +21,11 @@ func (api *Client) DeleteConferenceMember(id string, memberID string) error{
 // MuteConferenceMember mute/unmute the conference member
 // example: api.MuteConferenceMember("conferenceId", "memberId", true) //mute member
 func (api *Client) MuteConferenceMember(id string, memberID string, mute bool) error{
-	return api.UpdateConferenceMember(id, memberID, &UpdateConferenceMemberData{Mute: mute})
+	return api.updateConferenceMemberWithMap(id, memberID, map[string]interface{}{"mute": mute})
 }
 
 // HoldConferenceMember hold/unhold the conference member
 // example: api.HoldConferenceMember("conferenceId", "memberId", true) //hold member
 func (api *Client) HoldConferenceMember(id string, memberID string, hold bool) error{
-	return api.UpdateConferenceMember(id, memberID, &UpdateConferenceMemberData{Hold: hold})
+	return api.updateConferenceMemberWithMap(id, memberID, map[string]interface{}{"hold": hold})
 }
@@ -64,3 +64,27 @@ func TestHoldConferenceMember(t *testing.T) {
 		t.Error("Failed call of HoldConferenceMember()")
 	}
 }
+
+func TestUnmuteConference(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery:     "/v1/users/userId/conferences/123",
+		Method:           http.MethodPost,
+		EstimatedContent: `{"mute":false}`}})
+	defer server.Close()
+	err := api.MuteConference("123", false)
+	if err != nil {
+		t.Error("Failed call of MuteConference()")
+	}
+}
+
+func TestUnholdConferenceMember(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery:     "/v1/users/userId/conferences/123/members/456",
+		Method:           http.MethodPost,
+		EstimatedContent: `{"hold":false}`}})
+	defer server.Close()
+	err := api.HoldConferenceMember("123", "456", false)
+	if err != nil {
+		t.Error("Failed call of HoldConferenceMember()")
+	}
+}
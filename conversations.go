@@ -0,0 +1,296 @@
+package bandwidth
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ConversationMessage is a message of a conversation
+type ConversationMessage struct {
+	ID        string    `json:"id"`
+	From      string    `json:"from"`
+	To        []string  `json:"to"`
+	Text      string    `json:"text"`
+	Media     []string  `json:"media,omitempty"`
+	Direction string    `json:"direction"`
+	Time      time.Time `json:"time"`
+}
+
+// Conversation is a thread of messages between the same participants
+type Conversation struct {
+	Key          string                 `json:"key"`
+	Participants []string               `json:"participants"`
+	Unread       int                    `json:"unread"`
+	LastTime     time.Time              `json:"lastTime"`
+	Messages     []*ConversationMessage `json:"messages,omitempty"`
+}
+
+type conversation struct {
+	key          string
+	participants []string
+	messages     []*ConversationMessage
+	// ids contains IDs of kept messages
+	ids      map[string]bool
+	readTime time.Time
+}
+
+func (c *conversation) unread() int {
+	count := 0
+	for _, m := range c.messages {
+		if m.Direction == "in" && m.Time.After(c.readTime) {
+			count++
+		}
+	}
+	return count
+}
+
+func (c *conversation) summary(withMessages bool) *Conversation {
+	result := &Conversation{Key: c.key, Participants: c.participants, Unread: c.unread()}
+	if len(c.messages) > 0 {
+		result.LastTime = c.messages[len(c.messages)-1].Time
+	}
+	if withMessages {
+		result.Messages = append([]*ConversationMessage{}, c.messages...)
+	}
+	return result
+}
+
+// Conversations groups messages (v1 history and v2 callbacks) into threads keyed by their participants
+type Conversations struct {
+	// MaxMessages limits count of kept messages of a conversation (0 means unlimited)
+	// The oldest messages are forgotten.
+	MaxMessages int
+
+	mutex   sync.Mutex
+	threads map[string]*conversation
+}
+
+// NewConversations creates new Conversations instance
+func NewConversations() *Conversations {
+	return &Conversations{threads: map[string]*conversation{}}
+}
+
+// ConversationKey returns key of conversation between the numbers (sorted unique numbers joined with ",")
+func ConversationKey(numbers ...string) string {
+	return strings.Join(conversationParticipants(numbers), ",")
+}
+
+func conversationParticipants(numbers []string) []string {
+	unique := map[string]bool{}
+	list := []string{}
+	for _, n := range numbers {
+		if n != "" && !unique[n] {
+			unique[n] = true
+			list = append(list, n)
+		}
+	}
+	sort.Strings(list)
+	return list
+}
+
+// Add adds the message to its conversation (messages with ID of a kept message are ignored)
+// It returns key of the conversation
+func (c *Conversations) Add(message *ConversationMessage) string {
+	participants := conversationParticipants(append([]string{message.From}, message.To...))
+	key := strings.Join(participants, ",")
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.threads == nil {
+		c.threads = map[string]*conversation{}
+	}
+	thread, ok := c.threads[key]
+	if !ok {
+		thread = &conversation{key: key, participants: participants, ids: map[string]bool{}}
+		c.threads[key] = thread
+	}
+	if message.ID != "" {
+		if thread.ids[message.ID] {
+			return key
+		}
+		thread.ids[message.ID] = true
+	}
+	// messages are kept ordered by time
+	index := sort.Search(len(thread.messages), func(i int) bool {
+		return thread.messages[i].Time.After(message.Time)
+	})
+	thread.messages = append(thread.messages, nil)
+	copy(thread.messages[index+1:], thread.messages[index:])
+	thread.messages[index] = message
+	if c.MaxMessages > 0 && len(thread.messages) > c.MaxMessages {
+		oldest := thread.messages[0]
+		delete(thread.ids, oldest.ID)
+		thread.messages = append(thread.messages[:0], thread.messages[1:]...)
+	}
+	return key
+}
+
+// AddMessage adds a message of v1 API (see GetMessages())
+// Messages without valid time get current time.
+func (c *Conversations) AddMessage(message *Message) string {
+	t, err := time.Parse(time.RFC3339, message.Time)
+	if err != nil {
+		t = time.Now().UTC()
+	}
+	return c.Add(&ConversationMessage{
+		ID:        message.ID,
+		From:      message.From,
+		To:        []string{message.To},
+		Text:      message.Text,
+		Media:     message.Media,
+		Direction: message.Direction,
+		Time:      t,
+	})
+}
+
+// AddMessageV2 adds a message of v2 API (a sent message or a message from MessageCallbackV2)
+// Messages without time get current time.
+func (c *Conversations) AddMessageV2(message *CreateMessageResultV2) string {
+	t := time.Now().UTC()
+	if message.Time != nil {
+		t = *message.Time
+	}
+	return c.Add(&ConversationMessage{
+		ID:        message.ID,
+		From:      message.From,
+		To:        message.Recipients(),
+		Text:      message.Text,
+		Media:     message.Media,
+		Direction: message.Direction,
+		Time:      t,
+	})
+}
+
+// HandleCallbacksV2 adds received messages of v2 callbacks (callbacks without message are skipped)
+func (c *Conversations) HandleCallbacksV2(callbacks []*MessageCallbackV2) {
+	for _, callback := range callbacks {
+		if callback.Type == MessageCallbackV2Received && callback.Message != nil {
+			c.AddMessageV2(callback.Message)
+		}
+	}
+}
+
+// Load adds all messages returned by GetMessages() (all pages are requested)
+// It returns error object
+func (c *Conversations) Load(api *Client, query *GetMessagesQuery) error {
+	options := GetMessagesQuery{}
+	if query != nil {
+		options = *query
+	}
+	if options.Size == 0 {
+		options.Size = 1000
+	}
+	for {
+		list, err := api.GetMessages(&options)
+		if err != nil {
+			return err
+		}
+		for _, message := range list {
+			c.AddMessage(message)
+		}
+		if len(list) < options.Size {
+			return nil
+		}
+		options.Page++
+	}
+}
+
+// List returns conversations (without messages) ordered by time of last message (newest first)
+func (c *Conversations) List() []*Conversation {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	list := make([]*Conversation, 0, len(c.threads))
+	for _, thread := range c.threads {
+		list = append(list, thread.summary(false))
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].LastTime.Equal(list[j].LastTime) {
+			return list[i].LastTime.After(list[j].LastTime)
+		}
+		return list[i].Key < list[j].Key
+	})
+	return list
+}
+
+// Messages returns a page of messages of the conversation ordered by time (page starts from 0)
+func (c *Conversations) Messages(key string, page, size int) []*ConversationMessage {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	thread, ok := c.threads[key]
+	if !ok || page < 0 || size <= 0 || page*size >= len(thread.messages) {
+		return []*ConversationMessage{}
+	}
+	end := (page + 1) * size
+	if end > len(thread.messages) {
+		end = len(thread.messages)
+	}
+	return append([]*ConversationMessage{}, thread.messages[page*size:end]...)
+}
+
+// MarkRead marks inbound messages of the conversation received until t as read
+func (c *Conversations) MarkRead(key string, t time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if thread, ok := c.threads[key]; ok && t.After(thread.readTime) {
+		thread.readTime = t
+	}
+}
+
+// MarkAllRead marks all messages of the conversation as read
+func (c *Conversations) MarkAllRead(key string) {
+	c.mutex.Lock()
+	thread, ok := c.threads[key]
+	var t time.Time
+	if ok && len(thread.messages) > 0 {
+		t = thread.messages[len(thread.messages)-1].Time
+	}
+	c.mutex.Unlock()
+	c.MarkRead(key, t)
+}
+
+// Unread returns count of unread inbound messages of the conversation
+func (c *Conversations) Unread(key string) int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if thread, ok := c.threads[key]; ok {
+		return thread.unread()
+	}
+	return 0
+}
+
+func (c *Conversations) export() []*Conversation {
+	list := c.List()
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for i, item := range list {
+		list[i] = c.threads[item.Key].summary(true)
+	}
+	return list
+}
+
+// WriteJSON writes all conversations with their messages as JSON
+func (c *Conversations) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(c.export())
+}
+
+// WriteCSV writes all messages as CSV (one row per message)
+func (c *Conversations) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"conversation", "id", "time", "direction", "from", "to", "text", "media"}); err != nil {
+		return err
+	}
+	for _, thread := range c.export() {
+		for _, m := range thread.Messages {
+			row := []string{thread.Key, m.ID, m.Time.Format(time.RFC3339), m.Direction, m.From, strings.Join(m.To, " "), m.Text, strings.Join(m.Media, " ")}
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,125 @@
+package bandwidth
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConversationKey(t *testing.T) {
+	expect(t, ConversationKey("+2", "+1", "+2", ""), "+1,+2")
+}
+
+func TestConversations(t *testing.T) {
+	c := NewConversations()
+	t1 := time.Date(2017, 1, 1, 10, 0, 0, 0, time.UTC)
+	key := c.Add(&ConversationMessage{ID: "2", From: "+1", To: []string{"+2"}, Text: "second", Direction: "in", Time: t1.Add(time.Minute)})
+	expect(t, key, "+1,+2")
+	c.Add(&ConversationMessage{ID: "1", From: "+2", To: []string{"+1"}, Text: "first", Direction: "out", Time: t1})
+	c.Add(&ConversationMessage{ID: "3", From: "+1", To: []string{"+2"}, Text: "third", Direction: "in", Time: t1.Add(2 * time.Minute)})
+	c.Add(&ConversationMessage{ID: "3", From: "+1", To: []string{"+2"}, Text: "duplicate", Direction: "in", Time: t1})
+	group := c.Add(&ConversationMessage{ID: "4", From: "+2", To: []string{"+3", "+1"}, Text: "group", Direction: "out", Time: t1.Add(time.Hour)})
+	expect(t, group, "+1,+2,+3")
+	list := c.List()
+	expect(t, len(list), 2)
+	expect(t, list[0].Key, "+1,+2,+3")
+	expect(t, list[1].Unread, 2)
+	texts := []string{}
+	for _, m := range c.Messages(key, 0, 2) {
+		texts = append(texts, m.Text)
+	}
+	expect(t, texts, []string{"first", "second"})
+	expect(t, len(c.Messages(key, 1, 2)), 1)
+	expect(t, len(c.Messages(key, 2, 2)), 0)
+	expect(t, len(c.Messages("unknown", 0, 2)), 0)
+	c.MarkRead(key, t1.Add(time.Minute))
+	expect(t, c.Unread(key), 1)
+	c.MarkAllRead(key)
+	expect(t, c.Unread(key), 0)
+	expect(t, c.Unread("unknown"), 0)
+}
+
+func TestConversationsLoad(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{
+		RequestHandler{
+			PathAndQuery:  "/v1/users/userId/messages?from=%2B1&size=2",
+			Method:        http.MethodGet,
+			ContentToSend: `[{"id":"1","from":"+1","to":"+2","text":"a","direction":"out","time":"2017-01-01T10:00:00Z"},{"id":"2","from":"+2","to":"+1","text":"b","direction":"in","time":"2017-01-01T10:01:00Z"}]`},
+		RequestHandler{
+			PathAndQuery:  "/v1/users/userId/messages?from=%2B1&page=1&size=2",
+			Method:        http.MethodGet,
+			ContentToSend: `[{"id":"3","from":"+1","to":"+3","text":"c","direction":"out","time":"2017-01-01T10:02:00Z"}]`}})
+	defer server.Close()
+	c := NewConversations()
+	expectNil(t, c.Load(api, &GetMessagesQuery{From: "+1", Size: 2}))
+	list := c.List()
+	expect(t, len(list), 2)
+	expect(t, list[0].Key, "+1,+3")
+	expect(t, list[1].Unread, 1)
+}
+
+func TestConversationsAddMessageWithoutTime(t *testing.T) {
+	c := NewConversations()
+	key := c.AddMessage(&Message{ID: "1", From: "+1", To: "+2", Text: "a", Direction: "in", Time: "2017-01-01T10:00:00Z"})
+	c.AddMessage(&Message{ID: "2", From: "+1", To: "+2", Text: "b", Direction: "in", Time: "invalid"})
+	c.AddMessageV2(&CreateMessageResultV2{ID: "3", From: "+1", To: "+2", Text: "c", Direction: "in"})
+	messages := c.Messages(key, 0, 3)
+	expect(t, len(messages), 3)
+	expect(t, messages[2].Text, "c")
+	expect(t, messages[1].Time.Year() > 2017, true)
+	expect(t, messages[2].Time.Year() > 2017, true)
+}
+
+func TestConversationsLoadFail(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery:     "/v1/users/userId/messages?size=1000",
+		Method:           http.MethodGet,
+		StatusCodeToSend: http.StatusBadRequest}})
+	defer server.Close()
+	shouldFail(t, func() (interface{}, error) { return nil, NewConversations().Load(api, nil) })
+}
+
+func TestConversationsHandleCallbacksV2(t *testing.T) {
+	c := NewConversations()
+	tm := time.Date(2017, 1, 1, 10, 0, 0, 0, time.UTC)
+	c.HandleCallbacksV2([]*MessageCallbackV2{
+		&MessageCallbackV2{Type: MessageCallbackV2Received, Message: &CreateMessageResultV2{ID: "1", From: "+1", To: []interface{}{"+2", "+3"}, Text: "hi", Direction: "in", Time: &tm}},
+		&MessageCallbackV2{Type: MessageCallbackV2Delivered, Message: &CreateMessageResultV2{ID: "2", From: "+2", To: "+1"}},
+		&MessageCallbackV2{Type: MessageCallbackV2Received},
+	})
+	list := c.List()
+	expect(t, len(list), 1)
+	expect(t, list[0].Key, "+1,+2,+3")
+	expect(t, list[0].LastTime, tm)
+	expect(t, list[0].Unread, 1)
+}
+
+func TestConversationsWithMaxMessages(t *testing.T) {
+	c := &Conversations{MaxMessages: 2}
+	tm := time.Date(2017, 1, 1, 10, 0, 0, 0, time.UTC)
+	for i, id := range []string{"2", "3", "1", "4"} {
+		c.Add(&ConversationMessage{ID: id, From: "+1", To: []string{"+2"}, Time: tm.Add(time.Duration(i) * time.Minute)})
+	}
+	messages := c.Messages("+1,+2", 0, 10)
+	expect(t, len(messages), 2)
+	expect(t, messages[0].ID, "1")
+	expect(t, messages[1].ID, "4")
+	// IDs of forgotten messages are forgotten too
+	c.Add(&ConversationMessage{ID: "2", From: "+1", To: []string{"+2"}, Time: tm.Add(time.Hour)})
+	messages = c.Messages("+1,+2", 0, 10)
+	expect(t, messages[0].ID, "4")
+	expect(t, messages[1].ID, "2")
+}
+
+func TestConversationsExport(t *testing.T) {
+	c := NewConversations()
+	c.Add(&ConversationMessage{ID: "1", From: "+1", To: []string{"+2"}, Text: "hi, there", Direction: "out", Time: time.Date(2017, 1, 1, 10, 0, 0, 0, time.UTC)})
+	buffer := &bytes.Buffer{}
+	expectNil(t, c.WriteCSV(buffer))
+	expect(t, buffer.String(), "conversation,id,time,direction,from,to,text,media\n\"+1,+2\",1,2017-01-01T10:00:00Z,out,+1,+2,\"hi, there\",\n")
+	buffer.Reset()
+	expectNil(t, c.WriteJSON(buffer))
+	expect(t, strings.TrimSpace(buffer.String()), `[{"key":"+1,+2","participants":["+1","+2"],"unread":0,"lastTime":"2017-01-01T10:00:00Z","messages":[{"id":"1","from":"+1","to":["+2"],"text":"hi, there","direction":"out","time":"2017-01-01T10:00:00Z"}]}]`)
+}
@@ -0,0 +1,431 @@
+package bandwidth
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DeliveryStatus is delivery status of a sent message
+type DeliveryStatus string
+
+const (
+	// DeliveryStatusPending is status of a sent message without delivery receipt
+	DeliveryStatusPending DeliveryStatus = "pending"
+	// DeliveryStatusDelivered is status of a message delivered to the recipient
+	DeliveryStatusDelivered DeliveryStatus = "delivered"
+	// DeliveryStatusFailed is status of a message which can't be delivered
+	DeliveryStatusFailed DeliveryStatus = "failed"
+	// DeliveryStatusTimeout is status of a message without delivery receipt for DeliveryTracker.Timeout
+	DeliveryStatusTimeout DeliveryStatus = "timeout"
+)
+
+// IsFinal returns true if the status is final (a timed out message can still get late receipt)
+func (s DeliveryStatus) IsFinal() bool {
+	return s == DeliveryStatusDelivered || s == DeliveryStatusFailed || s == DeliveryStatusTimeout
+}
+
+// MessageDelivery describes delivery of a tracked message
+type MessageDelivery struct {
+	MessageID   string
+	Sender      string
+	Status      DeliveryStatus
+	ErrorCode   string
+	Description string
+	SentTime    time.Time
+	UpdatedTime time.Time
+}
+
+// DeliveryStatusChange describes a change of delivery status of a message
+type DeliveryStatusChange struct {
+	MessageID string
+	Sender    string
+	From      DeliveryStatus
+	To        DeliveryStatus
+	Time      time.Time
+}
+
+// DeliveryStats is aggregate delivery statistics of a sender number
+type DeliveryStats struct {
+	Sender    string
+	Sent      int
+	Pending   int
+	Delivered int
+	Failed    int
+	TimedOut  int
+	// DeliveryRate is ratio of delivered messages to messages with final status (0 if there are no such messages)
+	DeliveryRate float64
+}
+
+type trackedMessage struct {
+	MessageDelivery
+	v2       bool
+	endPoint string
+	done     chan struct{}
+}
+
+// DeliveryTracker keeps delivery statuses of sent messages up to date using delivery receipts (callbacks) and polling
+// v1 messages should be sent with ReceiptRequested (CreateMessage() of the tracker requests all receipts).
+type DeliveryTracker struct {
+	// PollInterval is interval of polling the API while waiting for delivery (0 disables polling)
+	PollInterval time.Duration
+	// Timeout is max time to wait for delivery receipt after sending (0 means no timeout)
+	Timeout time.Duration
+
+	api         *Client
+	mutex       sync.Mutex
+	messages    map[string]*trackedMessage
+	subscribers []chan *DeliveryStatusChange
+}
+
+// NewDeliveryTracker creates new DeliveryTracker instance
+// api can be nil if only callbacks should be used
+// example: tracker := bandwidth.NewDeliveryTracker(api)
+func NewDeliveryTracker(api *Client) *DeliveryTracker {
+	return &DeliveryTracker{PollInterval: 30 * time.Second, Timeout: time.Hour, api: api, messages: map[string]*trackedMessage{}}
+}
+
+// MessageNotTrackedError is returned for a message which is not registered in DeliveryTracker
+type MessageNotTrackedError struct {
+	ID string
+}
+
+func (e *MessageNotTrackedError) Error() string {
+	return fmt.Sprintf("Message %s is not tracked", e.ID)
+}
+
+func (t *DeliveryTracker) track(id, sender string, v2 bool, endPoint string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	message, ok := t.messages[id]
+	if !ok {
+		message = &trackedMessage{MessageDelivery: MessageDelivery{MessageID: id, Status: DeliveryStatusPending, SentTime: time.Now()}, done: make(chan struct{})}
+		message.UpdatedTime = message.SentTime
+		t.messages[id] = message
+	}
+	message.Sender, message.v2, message.endPoint = sender, v2, endPoint
+}
+
+// Track registers a message sent by v1 API (receipts of messages which are not tracked are ignored)
+func (t *DeliveryTracker) Track(id, sender string) {
+	t.track(id, sender, false, "")
+}
+
+// TrackV2 registers a message sent by v2 API
+// Optional argument is messaging endpoint used to poll the message (MessagingV2EndPoint by default)
+func (t *DeliveryTracker) TrackV2(id, sender string, other ...string) {
+	t.track(id, sender, true, messagingEndPoint(other))
+}
+
+// CreateMessage sends a message via v1 API (with ReceiptRequested "all" if it is not set) and tracks its delivery
+// It returns ID of created message or error
+func (t *DeliveryTracker) CreateMessage(data *CreateMessageData) (string, error) {
+	message := *data
+	if message.ReceiptRequested == "" {
+		message.ReceiptRequested = "all"
+	}
+	id, err := t.api.CreateMessage(&message)
+	if err != nil {
+		return "", err
+	}
+	t.Track(id, message.From)
+	return id, nil
+}
+
+// CreateMessageV2 sends a message via v2 API and tracks its delivery
+// It returns result of CreateMessageV2() or error
+func (t *DeliveryTracker) CreateMessageV2(data *CreateMessageDataV2, other ...string) (*CreateMessageResultV2, error) {
+	message, err := t.api.CreateMessageV2(data, other...)
+	if err != nil {
+		return nil, err
+	}
+	t.TrackV2(message.ID, data.From, other...)
+	return message, nil
+}
+
+// Delivery returns last known delivery of the message
+func (t *DeliveryTracker) Delivery(id string) (*MessageDelivery, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	message, ok := t.messages[id]
+	if !ok {
+		return nil, false
+	}
+	delivery := message.MessageDelivery
+	return &delivery, true
+}
+
+// Forget removes the message from the tracker (and from statistics)
+func (t *DeliveryTracker) Forget(id string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	delete(t.messages, id)
+}
+
+// Subscribe returns a channel of status changes of all tracked messages and function to cancel the subscription
+// Changes are dropped if the channel is full.
+func (t *DeliveryTracker) Subscribe() (<-chan *DeliveryStatusChange, func()) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	ch := make(chan *DeliveryStatusChange, 64)
+	t.subscribers = append(t.subscribers, ch)
+	return ch, func() {
+		t.mutex.Lock()
+		defer t.mutex.Unlock()
+		for i, c := range t.subscribers {
+			if c == ch {
+				t.subscribers = append(t.subscribers[:i], t.subscribers[i+1:]...)
+				close(ch)
+				return
+			}
+		}
+	}
+}
+
+func (t *DeliveryTracker) setStatus(id string, status DeliveryStatus, errorCode, description string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	message, ok := t.messages[id]
+	if !ok || message.Status == status || (message.Status.IsFinal() && message.Status != DeliveryStatusTimeout) {
+		return
+	}
+	change := &DeliveryStatusChange{MessageID: id, Sender: message.Sender, From: message.Status, To: status, Time: time.Now()}
+	message.Status, message.ErrorCode, message.Description, message.UpdatedTime = status, errorCode, description, change.Time
+	if status.IsFinal() && change.From == DeliveryStatusPending {
+		close(message.done)
+	}
+	for _, ch := range t.subscribers {
+		select {
+		case ch <- change:
+		default:
+		}
+	}
+}
+
+func deliveryStatusFromV1(state string) DeliveryStatus {
+	switch state {
+	case "delivered":
+		return DeliveryStatusDelivered
+	case "not-delivered", "error":
+		return DeliveryStatusFailed
+	}
+	return ""
+}
+
+// HandleCallback updates delivery status using v1 message callback (delivery receipt)
+func (t *DeliveryTracker) HandleCallback(event *CallbackEvent) {
+	if event.MessageID == "" || event.Direction == "in" {
+		return
+	}
+	if status := deliveryStatusFromV1(event.DeliveryState); status != "" {
+		t.setStatus(event.MessageID, status, event.DeliveryCode, event.DeliveryDescription)
+	}
+}
+
+// HandleCallbacksV2 updates delivery statuses using v2 message callbacks (message-delivered and message-failed)
+func (t *DeliveryTracker) HandleCallbacksV2(callbacks []*MessageCallbackV2) {
+	for _, callback := range callbacks {
+		if callback.Message == nil {
+			continue
+		}
+		errorCode := ""
+		if callback.ErrorCode != 0 {
+			errorCode = strconv.Itoa(callback.ErrorCode)
+		}
+		switch callback.Type {
+		case MessageCallbackV2Delivered:
+			t.setStatus(callback.Message.ID, DeliveryStatusDelivered, errorCode, callback.Description)
+		case MessageCallbackV2Failed:
+			t.setStatus(callback.Message.ID, DeliveryStatusFailed, errorCode, callback.Description)
+		}
+	}
+}
+
+// ServeHTTP allows to use DeliveryTracker as handler of v1 and v2 message callbacks
+// example: http.Handle("/callbacks/messages", tracker)
+func (t *DeliveryTracker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	// v2 callbacks are sent as JSON array
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		callbacks, err := ParseMessageCallbacksV2(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		t.HandleCallbacksV2(callbacks)
+	} else {
+		event, err := ParseCallbackEvent(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		t.HandleCallback(event)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func deliveryStatusFromV2(status string) DeliveryStatus {
+	switch status {
+	case "DELIVERED":
+		return DeliveryStatusDelivered
+	case "FAILED", "UNDELIVERED":
+		return DeliveryStatusFailed
+	}
+	return ""
+}
+
+// Poll requests current delivery status of the tracked message from the server (via GetMessage() or GetMessagesV2())
+// It returns current delivery status of the message or error
+func (t *DeliveryTracker) Poll(id string) (DeliveryStatus, error) {
+	if t.api == nil {
+		return "", fmt.Errorf("DeliveryTracker has no Client to poll message %s", id)
+	}
+	t.mutex.Lock()
+	message, ok := t.messages[id]
+	if !ok {
+		t.mutex.Unlock()
+		return "", &MessageNotTrackedError{ID: id}
+	}
+	v2, endPoint := message.v2, message.endPoint
+	t.mutex.Unlock()
+	if v2 {
+		page, err := t.api.GetMessagesV2(&GetMessagesQueryV2{MessageID: id}, endPoint)
+		if err != nil {
+			return "", err
+		}
+		for _, m := range page.Messages {
+			if status := deliveryStatusFromV2(m.MessageStatus); status != "" {
+				errorCode := ""
+				if m.ErrorCode != 0 {
+					errorCode = strconv.Itoa(m.ErrorCode)
+				}
+				t.setStatus(id, status, errorCode, "")
+			}
+		}
+	} else {
+		m, err := t.api.GetMessage(id)
+		if err != nil {
+			return "", err
+		}
+		if status := deliveryStatusFromV1(m.DeliveryState); status != "" {
+			t.setStatus(id, status, m.DeliveryCode, m.DeliveryDescription)
+		}
+	}
+	delivery, ok := t.Delivery(id)
+	if !ok {
+		// the message has been forgotten while polling
+		return "", &MessageNotTrackedError{ID: id}
+	}
+	return delivery.Status, nil
+}
+
+// CheckTimeouts marks pending messages sent earlier than Timeout ago as timed out
+// It returns count of timed out messages
+func (t *DeliveryTracker) CheckTimeouts() int {
+	if t.Timeout <= 0 {
+		return 0
+	}
+	deadline := time.Now().Add(-t.Timeout)
+	t.mutex.Lock()
+	ids := []string{}
+	for id, message := range t.messages {
+		if message.Status == DeliveryStatusPending && !message.SentTime.After(deadline) {
+			ids = append(ids, id)
+		}
+	}
+	t.mutex.Unlock()
+	for _, id := range ids {
+		t.setStatus(id, DeliveryStatusTimeout, "", "No delivery receipt")
+	}
+	return len(ids)
+}
+
+// Wait blocks until the tracked message gets a final delivery status (delivered, failed or timeout) or ctx is done
+// Transient errors of polling are ignored (the message is polled again after PollInterval).
+// It returns delivery of the message or error
+// example: delivery, err := tracker.Wait(ctx, messageID)
+func (t *DeliveryTracker) Wait(ctx context.Context, id string) (*MessageDelivery, error) {
+	t.mutex.Lock()
+	message, ok := t.messages[id]
+	if !ok {
+		t.mutex.Unlock()
+		return nil, &MessageNotTrackedError{ID: id}
+	}
+	done := message.done
+	var timeout <-chan time.Time
+	if t.Timeout > 0 {
+		timer := time.NewTimer(message.SentTime.Add(t.Timeout).Sub(time.Now()))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	t.mutex.Unlock()
+	var poll <-chan time.Time
+	if t.api != nil && t.PollInterval > 0 {
+		ticker := time.NewTicker(t.PollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+	for {
+		select {
+		case <-done:
+			delivery, ok := t.Delivery(id)
+			if !ok {
+				return nil, &MessageNotTrackedError{ID: id}
+			}
+			return delivery, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timeout:
+			t.setStatus(id, DeliveryStatusTimeout, "", "No delivery receipt")
+		case <-poll:
+			if _, err := t.Poll(id); err != nil && !IsTransientError(err) {
+				return nil, err
+			}
+		}
+	}
+}
+
+// Stats returns delivery statistics of tracked messages per sender number (ordered by sender)
+func (t *DeliveryTracker) Stats() []*DeliveryStats {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	bySender := map[string]*DeliveryStats{}
+	for _, message := range t.messages {
+		stats, ok := bySender[message.Sender]
+		if !ok {
+			stats = &DeliveryStats{Sender: message.Sender}
+			bySender[message.Sender] = stats
+		}
+		stats.Sent++
+		switch message.Status {
+		case DeliveryStatusDelivered:
+			stats.Delivered++
+		case DeliveryStatusFailed:
+			stats.Failed++
+		case DeliveryStatusTimeout:
+			stats.TimedOut++
+		default:
+			stats.Pending++
+		}
+	}
+	list := make([]*DeliveryStats, 0, len(bySender))
+	for _, stats := range bySender {
+		if finished := stats.Delivered + stats.Failed + stats.TimedOut; finished > 0 {
+			stats.DeliveryRate = float64(stats.Delivered) / float64(finished)
+		}
+		list = append(list, stats)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Sender < list[j].Sender })
+	return list
+}
@@ -0,0 +1,221 @@
+package bandwidth
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDeliveryTrackerHandleCallback(t *testing.T) {
+	tracker := NewDeliveryTracker(nil)
+	tracker.Track("m1", "+19195550001")
+	changes, cancel := tracker.Subscribe()
+	defer cancel()
+	tracker.HandleCallback(&CallbackEvent{EventType: CallbackEventSMS, MessageID: "m1", Direction: "out", DeliveryState: "waiting"})
+	delivery, _ := tracker.Delivery("m1")
+	expect(t, delivery.Status, DeliveryStatusPending)
+	tracker.HandleCallback(&CallbackEvent{EventType: CallbackEventSMS, MessageID: "m1", Direction: "out", DeliveryState: "not-delivered", DeliveryCode: "470", DeliveryDescription: "Rejected"})
+	delivery, _ = tracker.Delivery("m1")
+	expect(t, delivery.Status, DeliveryStatusFailed)
+	expect(t, delivery.ErrorCode, "470")
+	expect(t, delivery.Description, "Rejected")
+	change := <-changes
+	expect(t, change.From, DeliveryStatusPending)
+	expect(t, change.To, DeliveryStatusFailed)
+	expect(t, change.Sender, "+19195550001")
+	// final status is not changed by later receipts
+	tracker.HandleCallback(&CallbackEvent{EventType: CallbackEventSMS, MessageID: "m1", Direction: "out", DeliveryState: "delivered"})
+	delivery, _ = tracker.Delivery("m1")
+	expect(t, delivery.Status, DeliveryStatusFailed)
+	_, ok := tracker.Delivery("m2")
+	expect(t, ok, false)
+}
+
+func TestDeliveryTrackerHandleCallbacksV2(t *testing.T) {
+	tracker := NewDeliveryTracker(nil)
+	tracker.TrackV2("m1", "+19195550001")
+	tracker.TrackV2("m2", "+19195550001")
+	tracker.HandleCallbacksV2([]*MessageCallbackV2{
+		&MessageCallbackV2{Type: MessageCallbackV2Delivered, Message: &CreateMessageResultV2{ID: "m1"}},
+		&MessageCallbackV2{Type: MessageCallbackV2Failed, ErrorCode: 4432, Description: "forbidden", Message: &CreateMessageResultV2{ID: "m2"}},
+	})
+	delivery, _ := tracker.Delivery("m1")
+	expect(t, delivery.Status, DeliveryStatusDelivered)
+	delivery, _ = tracker.Delivery("m2")
+	expect(t, delivery.Status, DeliveryStatusFailed)
+	expect(t, delivery.ErrorCode, "4432")
+}
+
+func TestDeliveryTrackerWithUntrackedMessages(t *testing.T) {
+	tracker := NewDeliveryTracker(nil)
+	tracker.HandleCallback(&CallbackEvent{EventType: CallbackEventSMS, MessageID: "m1", Direction: "out", DeliveryState: "delivered"})
+	tracker.HandleCallbacksV2([]*MessageCallbackV2{
+		&MessageCallbackV2{Type: MessageCallbackV2Delivered},
+		&MessageCallbackV2{Type: MessageCallbackV2Failed, Message: &CreateMessageResultV2{ID: "m2"}},
+	})
+	_, ok := tracker.Delivery("m1")
+	expect(t, ok, false)
+	_, ok = tracker.Delivery("m2")
+	expect(t, ok, false)
+	expect(t, len(tracker.Stats()), 0)
+	_, err := tracker.Wait(context.Background(), "m1")
+	expect(t, err.Error(), "Message m1 is not tracked")
+	_, err = NewDeliveryTracker(getAPI()).Poll("m1")
+	expect(t, err.Error(), "Message m1 is not tracked")
+}
+
+func TestDeliveryTrackerServeHTTP(t *testing.T) {
+	tracker := NewDeliveryTracker(nil)
+	tracker.Track("m1", "+19195550001")
+	tracker.TrackV2("m2", "+19195550001")
+	w := httptest.NewRecorder()
+	tracker.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{"eventType":"sms","messageId":"m1","direction":"out","deliveryState":"delivered"}`))))
+	expect(t, w.Code, http.StatusOK)
+	w = httptest.NewRecorder()
+	tracker.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(` [{"type":"message-failed","message":{"id":"m2"}}]`))))
+	expect(t, w.Code, http.StatusOK)
+	w = httptest.NewRecorder()
+	tracker.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`[{"type":"message-failed"}]`))))
+	expect(t, w.Code, http.StatusOK)
+	w = httptest.NewRecorder()
+	tracker.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`[{"type":`))))
+	expect(t, w.Code, http.StatusBadRequest)
+	delivery, _ := tracker.Delivery("m1")
+	expect(t, delivery.Status, DeliveryStatusDelivered)
+	delivery, _ = tracker.Delivery("m2")
+	expect(t, delivery.Status, DeliveryStatusFailed)
+}
+
+func TestDeliveryTrackerWait(t *testing.T) {
+	tracker := NewDeliveryTracker(nil)
+	tracker.Track("m1", "+19195550001")
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		tracker.HandleCallback(&CallbackEvent{EventType: CallbackEventSMS, MessageID: "m1", Direction: "out", DeliveryState: "delivered"})
+	}()
+	delivery, err := tracker.Wait(context.Background(), "m1")
+	expectNil(t, err)
+	expect(t, delivery.Status, DeliveryStatusDelivered)
+}
+
+func TestDeliveryTrackerWaitWithTimeout(t *testing.T) {
+	tracker := NewDeliveryTracker(nil)
+	tracker.Timeout = 10 * time.Millisecond
+	tracker.Track("m1", "+19195550001")
+	delivery, err := tracker.Wait(context.Background(), "m1")
+	expectNil(t, err)
+	expect(t, delivery.Status, DeliveryStatusTimeout)
+	// a late receipt updates the status
+	tracker.HandleCallback(&CallbackEvent{EventType: CallbackEventSMS, MessageID: "m1", Direction: "out", DeliveryState: "delivered"})
+	delivery, _ = tracker.Delivery("m1")
+	expect(t, delivery.Status, DeliveryStatusDelivered)
+}
+
+func TestDeliveryTrackerWaitFail(t *testing.T) {
+	tracker := NewDeliveryTracker(nil)
+	tracker.Timeout = 0
+	tracker.Track("m1", "+19195550001")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := tracker.Wait(ctx, "m1")
+	expect(t, err, context.DeadlineExceeded)
+}
+
+func TestDeliveryTrackerWaitWithPolling(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{
+		RequestHandler{
+			PathAndQuery:     "/v1/users/userId/messages",
+			Method:           http.MethodPost,
+			EstimatedContent: `{"from":"+19195550001","to":"+19195551111","text":"hi","receiptRequested":"all"}`,
+			HeadersToSend:    map[string]string{"Location": "/v1/users/userId/messages/m1"},
+			StatusCodeToSend: http.StatusCreated},
+		RequestHandler{
+			PathAndQuery:  "/v1/users/userId/messages/m1",
+			Method:        http.MethodGet,
+			ContentToSend: `{"id":"m1","deliveryState":"delivered","deliveryCode":"0"}`}})
+	defer server.Close()
+	tracker := NewDeliveryTracker(api)
+	tracker.PollInterval = 10 * time.Millisecond
+	id, err := tracker.CreateMessage(&CreateMessageData{From: "+19195550001", To: "+19195551111", Text: "hi"})
+	expectNil(t, err)
+	delivery, err := tracker.Wait(context.Background(), id)
+	expectNil(t, err)
+	expect(t, delivery.Status, DeliveryStatusDelivered)
+	expect(t, delivery.Sender, "+19195550001")
+}
+
+func TestDeliveryTrackerWaitWithTransientPollErrors(t *testing.T) {
+	var log func() []string
+	server, api, log := startMockServerWithLog(t, func(w http.ResponseWriter, r *http.Request, body string) {
+		if len(log()) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"m1","deliveryState":"delivered"}`))
+	})
+	defer server.Close()
+	tracker := NewDeliveryTracker(api)
+	tracker.PollInterval = time.Millisecond
+	tracker.Track("m1", "+19195550001")
+	delivery, err := tracker.Wait(context.Background(), "m1")
+	expectNil(t, err)
+	expect(t, delivery.Status, DeliveryStatusDelivered)
+	expect(t, len(log()) >= 3, true)
+}
+
+func TestDeliveryTrackerPollV2(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{
+		RequestHandler{
+			PathAndQuery:  "/api/v2/users/userId/messages",
+			Method:        http.MethodPost,
+			ContentToSend: `{"id":"m1"}`},
+		RequestHandler{
+			PathAndQuery:  "/api/v2/users/userId/messages?messageId=m1",
+			Method:        http.MethodGet,
+			ContentToSend: `{"messages":[{"messageId":"m1","messageStatus":"FAILED","errorCode":9902}]}`}})
+	defer server.Close()
+	tracker := NewDeliveryTracker(api)
+	message, err := tracker.CreateMessageV2(&CreateMessageDataV2{From: "+19195550001", To: "+19195551111"}, api.APIEndPoint)
+	expectNil(t, err)
+	status, err := tracker.Poll(message.ID)
+	expectNil(t, err)
+	expect(t, status, DeliveryStatusFailed)
+	delivery, _ := tracker.Delivery("m1")
+	expect(t, delivery.ErrorCode, "9902")
+}
+
+func TestDeliveryTrackerPollFail(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery:     "/v1/users/userId/messages/m1",
+		Method:           http.MethodGet,
+		StatusCodeToSend: http.StatusNotFound}})
+	defer server.Close()
+	tracker := NewDeliveryTracker(api)
+	tracker.Track("m1", "+19195550001")
+	shouldFail(t, func() (interface{}, error) { return tracker.Poll("m1") })
+	shouldFail(t, func() (interface{}, error) { return NewDeliveryTracker(nil).Poll("m1") })
+}
+
+func TestDeliveryTrackerStats(t *testing.T) {
+	tracker := NewDeliveryTracker(nil)
+	tracker.Timeout = time.Millisecond
+	for _, id := range []string{"a1", "a2", "a3", "a4"} {
+		tracker.Track(id, "+1")
+	}
+	tracker.Track("b1", "+2")
+	tracker.HandleCallback(&CallbackEvent{MessageID: "a1", DeliveryState: "delivered"})
+	tracker.HandleCallback(&CallbackEvent{MessageID: "a2", DeliveryState: "delivered"})
+	tracker.HandleCallback(&CallbackEvent{MessageID: "a3", DeliveryState: "not-delivered"})
+	time.Sleep(5 * time.Millisecond)
+	expect(t, tracker.CheckTimeouts(), 2)
+	stats := tracker.Stats()
+	expect(t, len(stats), 2)
+	expect(t, *stats[0], DeliveryStats{Sender: "+1", Sent: 4, Delivered: 2, Failed: 1, TimedOut: 1, DeliveryRate: 0.5})
+	expect(t, *stats[1], DeliveryStats{Sender: "+2", Sent: 1, TimedOut: 1})
+	tracker.Forget("b1")
+	expect(t, len(tracker.Stats()), 1)
+}
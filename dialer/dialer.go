@@ -0,0 +1,366 @@
+// Package dialer places outbound call campaigns with concurrency, rate and time-of-day limits
+package dialer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Bandwidth/go-bandwidth"
+	"github.com/Bandwidth/go-bandwidth/xml"
+)
+
+// Template describes calls of a campaign
+type Template struct {
+	// Call is data of created calls (To is replaced by campaign numbers)
+	Call bandwidth.CreateCallData
+	// Audio is played after a call is answered
+	Audio *bandwidth.PlayAudioData
+	// BXML is returned by ServeHTTP for answered calls (Call.CallbackURL should point to the campaign handler)
+	BXML *xml.Response
+}
+
+// Campaign calls a list of numbers
+type Campaign struct {
+	API      *bandwidth.Client
+	Numbers  []string
+	Template Template
+	// Concurrency is max count of simultaneous calls
+	// A call holds its slot until it ends (an answered call is not replaced by a new call while it is active).
+	Concurrency int
+	// CallsPerSecond limits rate of creating calls (0 means unlimited)
+	CallsPerSecond float64
+	// Window limits time of day when calls are placed (nil means any time)
+	Window *Window
+	// MaxAttempts is max count of calls to a number
+	MaxAttempts int
+	// RetryDelay is delay before next call to a number which has not answered
+	RetryDelay time.Duration
+	// RetryOn is list of outcomes which should be retried
+	RetryOn []Outcome
+	// PollInterval is interval of checking states of calls without callbacks (0 disables polling)
+	// Transient errors of polling are ignored, a call which can't be polled is hung up.
+	PollInterval time.Duration
+
+	mutex   sync.Mutex
+	waiters map[string]chan Outcome
+	// creating is count of calls being created, early keeps outcomes of callbacks received before CreateCall() has returned
+	creating int
+	early    map[string][]Outcome
+}
+
+// NewCampaign creates new Campaign instance
+// example: campaign := dialer.NewCampaign(api, numbers, dialer.Template{Call: bandwidth.CreateCallData{From: "+19195551212"}})
+func NewCampaign(api *bandwidth.Client, numbers []string, template Template) *Campaign {
+	return &Campaign{
+		API:          api,
+		Numbers:      numbers,
+		Template:     template,
+		Concurrency:  10,
+		MaxAttempts:  3,
+		RetryDelay:   30 * time.Minute,
+		RetryOn:      []Outcome{OutcomeNoAnswer, OutcomeBusy},
+		PollInterval: 10 * time.Second,
+	}
+}
+
+type job struct {
+	result    *Result
+	notBefore time.Time
+}
+
+type attempt struct {
+	job     *job
+	callID  string
+	outcome Outcome
+	err     error
+}
+
+func (c *Campaign) shouldRetry(outcome Outcome) bool {
+	for _, o := range c.RetryOn {
+		if o == outcome {
+			return true
+		}
+	}
+	return false
+}
+
+// Run calls all numbers of the campaign and waits for outcomes of all calls
+// If ctx is done, calls in progress are hung up.
+// It returns Report (partial if ctx is done) and error
+func (c *Campaign) Run(ctx context.Context) (*Report, error) {
+	if c.Concurrency <= 0 {
+		return nil, errors.New("Concurrency should be positive")
+	}
+	if c.Window != nil && c.Window.Start == c.Window.End {
+		return nil, errors.New("Window should have different Start and End")
+	}
+	c.mutex.Lock()
+	c.waiters = map[string]chan Outcome{}
+	c.early = map[string][]Outcome{}
+	c.mutex.Unlock()
+	report := &Report{}
+	pending := make([]*job, 0, len(c.Numbers))
+	for _, number := range c.Numbers {
+		result := &Result{Number: number}
+		report.Results = append(report.Results, result)
+		pending = append(pending, &job{result: result})
+	}
+	var gap time.Duration
+	if c.CallsPerSecond > 0 {
+		gap = time.Duration(float64(time.Second) / c.CallsPerSecond)
+	}
+	var lastStart time.Time
+	done := make(chan *attempt, c.Concurrency)
+	inFlight := 0
+	for len(pending) > 0 || inFlight > 0 {
+		now := time.Now()
+		wake := time.Time{}
+		if inFlight < c.Concurrency && len(pending) > 0 {
+			index := 0
+			for i, j := range pending {
+				if j.notBefore.Before(pending[index].notBefore) {
+					index = i
+				}
+			}
+			next := pending[index]
+			wake = next.notBefore
+			if !lastStart.IsZero() && lastStart.Add(gap).After(wake) {
+				wake = lastStart.Add(gap)
+			}
+			if wake.Before(now) {
+				wake = now
+			}
+			wake = c.Window.NextOpen(wake)
+			if !wake.After(now) {
+				pending = append(pending[:index], pending[index+1:]...)
+				lastStart = now
+				inFlight++
+				next.result.Attempts++
+				next.result.LastAttemptTime = now
+				go func() { done <- c.call(ctx, next) }()
+				continue
+			}
+		}
+		timer := time.NewTimer(time.Hour)
+		if !wake.IsZero() {
+			timer.Reset(wake.Sub(now))
+		}
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			// attempts in progress finish after hanging up their calls
+			for ; inFlight > 0; inFlight-- {
+				c.complete(<-done)
+			}
+			return report, ctx.Err()
+		case <-timer.C:
+		case a := <-done:
+			timer.Stop()
+			inFlight--
+			result := c.complete(a)
+			if result.Attempts < c.MaxAttempts && c.shouldRetry(a.outcome) {
+				pending = append(pending, &job{result: result, notBefore: time.Now().Add(c.RetryDelay)})
+			}
+		}
+	}
+	return report, nil
+}
+
+// complete stores the attempt to its result
+func (c *Campaign) complete(a *attempt) *Result {
+	result := a.job.result
+	result.Outcome = a.outcome
+	result.LastError = ""
+	if a.err != nil {
+		result.LastError = a.err.Error()
+	}
+	if a.callID != "" {
+		result.CallIDs = append(result.CallIDs, a.callID)
+	}
+	return result
+}
+
+func (c *Campaign) call(ctx context.Context, j *job) *attempt {
+	data := c.Template.Call
+	data.To = j.result.Number
+	// a call gets at most two outcomes: answered and outcome of its end
+	waiter := make(chan Outcome, 2)
+	c.mutex.Lock()
+	c.creating++
+	c.mutex.Unlock()
+	callID, err := c.API.CreateCall(&data)
+	c.mutex.Lock()
+	c.creating--
+	if err == nil {
+		// callbacks of the new call can be received before CreateCall() returns
+		ended := false
+		for _, outcome := range c.early[callID] {
+			sendOutcome(waiter, outcome)
+			ended = ended || outcome != OutcomeAnswered
+		}
+		if !ended {
+			c.waiters[callID] = waiter
+		}
+	}
+	if c.creating == 0 {
+		c.early = map[string][]Outcome{}
+	}
+	c.mutex.Unlock()
+	if err != nil {
+		return &attempt{job: j, outcome: OutcomeFailed, err: err}
+	}
+	defer func() {
+		c.mutex.Lock()
+		delete(c.waiters, callID)
+		c.mutex.Unlock()
+	}()
+	var poll <-chan time.Time
+	if c.PollInterval > 0 {
+		ticker := time.NewTicker(c.PollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+	// the slot of an answered call is freed when the call ends
+	answered := false
+	finish := func(outcome Outcome, err error) *attempt {
+		if answered {
+			outcome = OutcomeAnswered
+		}
+		return &attempt{job: j, callID: callID, outcome: outcome, err: err}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			c.API.HangUpCall(callID)
+			return finish(OutcomeFailed, ctx.Err())
+		case outcome := <-waiter:
+			if outcome != OutcomeAnswered {
+				return finish(outcome, nil)
+			}
+			answered = true
+		case <-poll:
+			outcome, err := c.pollOutcome(callID)
+			if err != nil {
+				if bandwidth.IsTransientError(err) {
+					continue
+				}
+				// the call may be still active
+				c.API.HangUpCall(callID)
+				return finish(OutcomeFailed, err)
+			}
+			if outcome != "" {
+				return finish(outcome, nil)
+			}
+		}
+	}
+}
+
+// sendOutcome passes the outcome to the waiter of a call (repeated callbacks which don't fit to the waiter are dropped)
+func sendOutcome(waiter chan Outcome, outcome Outcome) {
+	select {
+	case waiter <- outcome:
+	default:
+	}
+}
+
+func (c *Campaign) pollOutcome(callID string) (Outcome, error) {
+	call, err := c.API.GetCall(callID)
+	if err != nil {
+		return "", err
+	}
+	if !bandwidth.CallState(call.State).IsFinal() {
+		return "", nil
+	}
+	if call.ActiveTime != "" {
+		return OutcomeAnswered, nil
+	}
+	events, err := c.API.GetCallEvents(callID)
+	if err != nil {
+		return "", err
+	}
+	outcome := OutcomeNoAnswer
+	for _, event := range events {
+		switch event.Name {
+		case bandwidth.CallbackEventAnswer:
+			return OutcomeAnswered, nil
+		case bandwidth.CallbackEventReject:
+			outcome = OutcomeBusy
+		}
+	}
+	return outcome, nil
+}
+
+func outcomeFromEvent(event *bandwidth.CallbackEvent) Outcome {
+	switch event.EventType {
+	case bandwidth.CallbackEventAnswer:
+		return OutcomeAnswered
+	case bandwidth.CallbackEventTimeout:
+		return OutcomeNoAnswer
+	case bandwidth.CallbackEventReject:
+		return OutcomeBusy
+	case bandwidth.CallbackEventHangup:
+		switch event.Cause {
+		case "USER_BUSY", "CALL_REJECTED":
+			return OutcomeBusy
+		case "NO_ANSWER", "NO_USER_RESPONSE", "ORIGINATOR_CANCEL":
+			return OutcomeNoAnswer
+		}
+		return OutcomeFailed
+	}
+	return ""
+}
+
+// HandleCallback registers outcome of a campaign call and plays Template.Audio to answered calls
+// It returns error object
+func (c *Campaign) HandleCallback(event *bandwidth.CallbackEvent) error {
+	outcome := outcomeFromEvent(event)
+	if outcome == "" {
+		return nil
+	}
+	c.mutex.Lock()
+	waiter, ok := c.waiters[event.CallID]
+	if ok && outcome != OutcomeAnswered {
+		// the call has ended
+		delete(c.waiters, event.CallID)
+	}
+	if !ok && c.creating > 0 {
+		// it may be a callback of a call which is being created
+		c.early[event.CallID] = append(c.early[event.CallID], outcome)
+		ok = true
+	}
+	c.mutex.Unlock()
+	if !ok {
+		return nil
+	}
+	if waiter != nil {
+		sendOutcome(waiter, outcome)
+	}
+	if outcome == OutcomeAnswered && c.Template.Audio != nil {
+		return c.API.PlayAudioToCall(event.CallID, c.Template.Audio)
+	}
+	return nil
+}
+
+// ServeHTTP allows to use Campaign as handler of call callbacks
+// It responds with Template.BXML to answer callbacks
+func (c *Campaign) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	event, err := bandwidth.ParseCallbackEvent(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := c.HandleCallback(event); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if event.EventType == bandwidth.CallbackEventAnswer && c.Template.BXML != nil {
+		w.Header().Set("Content-Type", "application/xml")
+		fmt.Fprint(w, c.Template.BXML.ToXML())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
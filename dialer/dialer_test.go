@@ -0,0 +1,239 @@
+package dialer
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Bandwidth/go-bandwidth"
+	"github.com/Bandwidth/go-bandwidth/xml"
+)
+
+func TestCampaignRun(t *testing.T) {
+	var campaign *Campaign
+	server, api := startMockServer(t, func(callID, to string) {
+		time.Sleep(time.Millisecond)
+		switch to {
+		case "+19195550001":
+			campaign.HandleCallback(&bandwidth.CallbackEvent{EventType: bandwidth.CallbackEventAnswer, CallID: callID})
+			campaign.HandleCallback(&bandwidth.CallbackEvent{EventType: bandwidth.CallbackEventHangup, CallID: callID, Cause: "NORMAL_CLEARING"})
+		case "+19195550002":
+			campaign.HandleCallback(&bandwidth.CallbackEvent{EventType: bandwidth.CallbackEventTimeout, CallID: callID})
+		case "+19195550003":
+			campaign.HandleCallback(&bandwidth.CallbackEvent{EventType: bandwidth.CallbackEventHangup, CallID: callID, Cause: "USER_BUSY"})
+		}
+	}, nil)
+	defer server.Close()
+	campaign = NewCampaign(api, []string{"+19195550001", "+19195550002", "+19195550003"}, Template{Call: bandwidth.CreateCallData{From: "+19195551212"}})
+	campaign.Concurrency = 2
+	campaign.RetryDelay = 0
+	campaign.RetryOn = []Outcome{OutcomeNoAnswer}
+	report, err := campaign.Run(context.Background())
+	expectNil(t, err)
+	expect(t, len(report.Results), 3)
+	expect(t, report.Results[0].Outcome, OutcomeAnswered)
+	expect(t, report.Results[0].Attempts, 1)
+	expect(t, report.Results[1].Outcome, OutcomeNoAnswer)
+	expect(t, report.Results[1].Attempts, 3)
+	expect(t, len(report.Results[1].CallIDs), 3)
+	expect(t, report.Results[2].Outcome, OutcomeBusy)
+	expect(t, report.Results[2].Attempts, 1)
+	expect(t, report.Summary(), map[Outcome]int{OutcomeAnswered: 1, OutcomeNoAnswer: 1, OutcomeBusy: 1})
+}
+
+func TestCampaignRunWithActiveCalls(t *testing.T) {
+	var campaign *Campaign
+	var mutex sync.Mutex
+	events := []string{}
+	server, api := startMockServer(t, func(callID, to string) {
+		mutex.Lock()
+		events = append(events, "created "+callID)
+		mutex.Unlock()
+		campaign.HandleCallback(&bandwidth.CallbackEvent{EventType: bandwidth.CallbackEventAnswer, CallID: callID})
+		time.Sleep(10 * time.Millisecond)
+		mutex.Lock()
+		events = append(events, "ended "+callID)
+		mutex.Unlock()
+		campaign.HandleCallback(&bandwidth.CallbackEvent{EventType: bandwidth.CallbackEventHangup, CallID: callID})
+	}, nil)
+	defer server.Close()
+	campaign = NewCampaign(api, []string{"+19195550001", "+19195550002"}, Template{})
+	campaign.Concurrency = 1
+	campaign.PollInterval = 0
+	report, err := campaign.Run(context.Background())
+	expectNil(t, err)
+	expect(t, report.Summary(), map[Outcome]int{OutcomeAnswered: 2})
+	// the second call is created after the end of the first answered call
+	expect(t, events, []string{"created c1", "ended c1", "created c2", "ended c2"})
+}
+
+func TestCampaignRunWithPolling(t *testing.T) {
+	server, api := startMockServer(t, nil, map[string]string{
+		"GET /v1/users/userId/calls/c1":        `{"id": "c1", "state": "completed", "activeTime": "2013-02-08T13:15:52.347Z"}`,
+		"GET /v1/users/userId/calls/c2":        `{"id": "c2", "state": "rejected"}`,
+		"GET /v1/users/userId/calls/c2/events": `[{"name": "create"}, {"name": "reject"}]`,
+	})
+	defer server.Close()
+	campaign := NewCampaign(api, []string{"+19195550001", "+19195550002"}, Template{})
+	campaign.Concurrency = 1
+	campaign.PollInterval = time.Millisecond
+	campaign.RetryOn = nil
+	report, err := campaign.Run(context.Background())
+	expectNil(t, err)
+	expect(t, report.Results[0].Outcome, OutcomeAnswered)
+	expect(t, report.Results[1].Outcome, OutcomeBusy)
+}
+
+func TestCampaignRunWithFailedCalls(t *testing.T) {
+	api, _ := bandwidth.New("userId", "apiToken", "apiSecret")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+	api.APIEndPoint = server.URL
+	campaign := NewCampaign(api, []string{"+19195550001"}, Template{})
+	campaign.RetryOn = []Outcome{OutcomeFailed}
+	campaign.RetryDelay = 0
+	campaign.MaxAttempts = 2
+	report, err := campaign.Run(context.Background())
+	expectNil(t, err)
+	expect(t, report.Results[0].Outcome, OutcomeFailed)
+	expect(t, report.Results[0].Attempts, 2)
+	expect(t, report.Results[0].LastError, "Http code 400")
+}
+
+func TestCampaignRunWithEarlyCallback(t *testing.T) {
+	api, _ := bandwidth.New("userId", "apiToken", "apiSecret")
+	var campaign *Campaign
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the callbacks are handled before the response of call creation
+		campaign.HandleCallback(&bandwidth.CallbackEvent{EventType: bandwidth.CallbackEventAnswer, CallID: "c1"})
+		campaign.HandleCallback(&bandwidth.CallbackEvent{EventType: bandwidth.CallbackEventHangup, CallID: "c1", Cause: "NORMAL_CLEARING"})
+		w.Header().Set("Location", "/v1/users/userId/calls/c1")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+	api.APIEndPoint = server.URL
+	campaign = NewCampaign(api, []string{"+19195550001"}, Template{})
+	campaign.PollInterval = 0
+	report, err := campaign.Run(context.Background())
+	expectNil(t, err)
+	expect(t, report.Results[0].Outcome, OutcomeAnswered)
+	expect(t, len(campaign.early), 0)
+}
+
+func TestCampaignRunWithRetriedError(t *testing.T) {
+	api, _ := bandwidth.New("userId", "apiToken", "apiSecret")
+	var mutex sync.Mutex
+	requests := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		count := len(requests)
+		mutex.Unlock()
+		switch {
+		case r.Method == http.MethodPost && count == 1:
+			w.WriteHeader(http.StatusBadRequest)
+		case r.Method == http.MethodPost:
+			w.Header().Set("Location", "/v1/users/userId/calls/c1")
+			w.WriteHeader(http.StatusCreated)
+		case count < 5:
+			// transient errors of polling
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id": "c1", "state": "completed", "activeTime": "2013-02-08T13:15:52.347Z"}`))
+		}
+	}))
+	defer server.Close()
+	api.APIEndPoint = server.URL
+	campaign := NewCampaign(api, []string{"+19195550001"}, Template{})
+	campaign.RetryOn = []Outcome{OutcomeFailed}
+	campaign.RetryDelay = 0
+	campaign.PollInterval = time.Millisecond
+	report, err := campaign.Run(context.Background())
+	expectNil(t, err)
+	expect(t, report.Results[0].Outcome, OutcomeAnswered)
+	expect(t, report.Results[0].Attempts, 2)
+	expect(t, report.Results[0].LastError, "")
+}
+
+func TestCampaignRunWithCallRate(t *testing.T) {
+	var campaign *Campaign
+	server, api := startMockServer(t, func(callID, to string) {
+		campaign.HandleCallback(&bandwidth.CallbackEvent{EventType: bandwidth.CallbackEventAnswer, CallID: callID})
+		campaign.HandleCallback(&bandwidth.CallbackEvent{EventType: bandwidth.CallbackEventHangup, CallID: callID})
+	}, nil)
+	defer server.Close()
+	campaign = NewCampaign(api, []string{"+19195550001", "+19195550002", "+19195550003"}, Template{})
+	campaign.CallsPerSecond = 50
+	start := time.Now()
+	_, err := campaign.Run(context.Background())
+	expectNil(t, err)
+	if time.Since(start) < 40*time.Millisecond {
+		t.Error("Calls per second limit is ignored")
+	}
+}
+
+func TestCampaignRunWithCanceledContext(t *testing.T) {
+	var mutex sync.Mutex
+	list := []string{}
+	hangups := func() []string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]string{}, list...)
+	}
+	api, _ := bandwidth.New("userId", "apiToken", "apiSecret")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/users/userId/calls" {
+			w.Header().Set("Location", "/v1/users/userId/calls/c1")
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		if strings.Contains(string(body), `"state":"completed"`) {
+			mutex.Lock()
+			list = append(list, strings.TrimPrefix(r.URL.Path, "/v1/users/userId/calls/"))
+			mutex.Unlock()
+		}
+	}))
+	defer server.Close()
+	api.APIEndPoint = server.URL
+	campaign := NewCampaign(api, []string{"+19195550001"}, Template{})
+	campaign.PollInterval = 0
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	report, err := campaign.Run(ctx)
+	expect(t, err, context.DeadlineExceeded)
+	expect(t, report.Results[0].Attempts, 1)
+	expect(t, report.Results[0].CallIDs, []string{"c1"})
+	expect(t, hangups(), []string{"c1"})
+	campaign.Concurrency = 0
+	_, err = campaign.Run(context.Background())
+	expect(t, err.Error(), "Concurrency should be positive")
+	campaign.Concurrency = 1
+	campaign.Window = &Window{Start: 9 * time.Hour, End: 9 * time.Hour}
+	_, err = campaign.Run(context.Background())
+	expect(t, err.Error(), "Window should have different Start and End")
+}
+
+func TestCampaignServeHTTP(t *testing.T) {
+	server, api := startMockServer(t, nil, nil)
+	defer server.Close()
+	campaign := NewCampaign(api, nil, Template{BXML: &xml.Response{Verbs: []interface{}{xml.SpeakSentence{Sentence: "Hello"}}}})
+	w := httptest.NewRecorder()
+	campaign.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{"eventType": "answer", "callId": "c1"}`))))
+	expect(t, w.Body.String(), "<Response><SpeakSentence>Hello</SpeakSentence></Response>")
+	w = httptest.NewRecorder()
+	campaign.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{"eventType": "hangup", "callId": "c1"}`))))
+	expect(t, w.Code, http.StatusOK)
+	w = httptest.NewRecorder()
+	campaign.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{}`))))
+	expect(t, w.Code, http.StatusBadRequest)
+}
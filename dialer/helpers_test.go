@@ -0,0 +1,60 @@
+package dialer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/Bandwidth/go-bandwidth"
+)
+
+func expect(t *testing.T, value interface{}, expected interface{}) {
+	if !reflect.DeepEqual(value, expected) {
+		t.Errorf("Expected %v  - Got %v (%T)", expected, value, value)
+	}
+}
+
+func expectNil(t *testing.T, value interface{}) {
+	if value != nil {
+		t.Errorf("Expected nil  - Got %v", value)
+	}
+}
+
+// startMockServer starts fake API which creates calls c1, c2, ... and passes number of each created call to onCall
+func startMockServer(t *testing.T, onCall func(callID, to string), handlers map[string]string) (*httptest.Server, *bandwidth.Client) {
+	api, _ := bandwidth.New("userId", "apiToken", "apiSecret")
+	var mutex sync.Mutex
+	count := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Path == "/v1/users/userId/calls" {
+			data := &bandwidth.CreateCallData{}
+			body, _ := ioutil.ReadAll(r.Body)
+			json.Unmarshal(body, data)
+			mutex.Lock()
+			count++
+			callID := fmt.Sprintf("c%d", count)
+			mutex.Unlock()
+			w.Header().Set("Location", "/v1/users/userId/calls/"+callID)
+			w.WriteHeader(http.StatusCreated)
+			if onCall != nil {
+				go onCall(callID, data.To)
+			}
+			return
+		}
+		content, ok := handlers[r.Method+" "+r.URL.Path]
+		if !ok {
+			t.Logf("Unhandled request %s %s", r.Method, r.URL.String())
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, content)
+	}))
+	api.APIEndPoint = server.URL
+	return server, api
+}
@@ -0,0 +1,75 @@
+package dialer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Outcome is result of a call
+type Outcome string
+
+const (
+	// OutcomeAnswered means the call has been answered
+	OutcomeAnswered Outcome = "answered"
+	// OutcomeNoAnswer means nobody has answered the call
+	OutcomeNoAnswer Outcome = "no-answer"
+	// OutcomeBusy means the call has been rejected or the line was busy
+	OutcomeBusy Outcome = "busy"
+	// OutcomeFailed means the call couldn't be placed
+	OutcomeFailed Outcome = "failed"
+)
+
+// Result is outcome of calling one number
+type Result struct {
+	Number          string    `json:"number"`
+	Outcome         Outcome   `json:"outcome"`
+	Attempts        int       `json:"attempts"`
+	CallIDs         []string  `json:"callIds"`
+	LastError       string    `json:"lastError,omitempty"`
+	LastAttemptTime time.Time `json:"lastAttemptTime"`
+}
+
+// Report is result of a campaign
+type Report struct {
+	Results []*Result `json:"results"`
+}
+
+// Summary returns count of numbers per outcome
+func (r *Report) Summary() map[Outcome]int {
+	summary := map[Outcome]int{}
+	for _, result := range r.Results {
+		summary[result.Outcome]++
+	}
+	return summary
+}
+
+// WriteCSV writes the report in CSV format (one row per number)
+func (r *Report) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"number", "outcome", "attempts", "callIds", "lastError", "lastAttemptTime"})
+	for _, result := range r.Results {
+		lastAttemptTime := ""
+		if !result.LastAttemptTime.IsZero() {
+			lastAttemptTime = result.LastAttemptTime.Format(time.RFC3339)
+		}
+		writer.Write([]string{
+			result.Number,
+			string(result.Outcome),
+			strconv.Itoa(result.Attempts),
+			strings.Join(result.CallIDs, " "),
+			result.LastError,
+			lastAttemptTime,
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteJSON writes the report in JSON format
+func (r *Report) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(r)
+}
@@ -0,0 +1,28 @@
+package dialer
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestReportWriteCSV(t *testing.T) {
+	report := &Report{Results: []*Result{
+		&Result{Number: "+19195550001", Outcome: OutcomeAnswered, Attempts: 1, CallIDs: []string{"c1"}, LastAttemptTime: time.Date(2017, 1, 2, 10, 0, 0, 0, time.UTC)},
+		&Result{Number: "+19195550002", Outcome: OutcomeFailed, Attempts: 2, LastError: "error"},
+	}}
+	buffer := &bytes.Buffer{}
+	expectNil(t, report.WriteCSV(buffer))
+	expect(t, buffer.String(), "number,outcome,attempts,callIds,lastError,lastAttemptTime\n"+
+		"+19195550001,answered,1,c1,,2017-01-02T10:00:00Z\n"+
+		"+19195550002,failed,2,,error,\n")
+}
+
+func TestReportWriteJSON(t *testing.T) {
+	report := &Report{Results: []*Result{
+		&Result{Number: "+19195550001", Outcome: OutcomeAnswered, Attempts: 1, CallIDs: []string{"c1"}, LastAttemptTime: time.Date(2017, 1, 2, 10, 0, 0, 0, time.UTC)},
+	}}
+	buffer := &bytes.Buffer{}
+	expectNil(t, report.WriteJSON(buffer))
+	expect(t, buffer.String(), `{"results":[{"number":"+19195550001","outcome":"answered","attempts":1,"callIds":["c1"],"lastAttemptTime":"2017-01-02T10:00:00Z"}]}`+"\n")
+}
@@ -0,0 +1,44 @@
+package dialer
+
+import "time"
+
+// Window is a daily time interval when calls are allowed (e.g. 9:00-18:00)
+// End before Start means the window spans midnight, equal Start and End are not allowed (such window never opens)
+type Window struct {
+	// Start and End are offsets from midnight
+	Start    time.Duration
+	End      time.Duration
+	Location *time.Location
+}
+
+func (w *Window) offset(t time.Time) (time.Time, time.Duration) {
+	if w.Location != nil {
+		t = t.In(w.Location)
+	}
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return midnight, t.Sub(midnight)
+}
+
+// Contains returns true if calls are allowed at time t
+func (w *Window) Contains(t time.Time) bool {
+	if w == nil {
+		return true
+	}
+	_, offset := w.offset(t)
+	if w.Start <= w.End {
+		return offset >= w.Start && offset < w.End
+	}
+	return offset >= w.Start || offset < w.End
+}
+
+// NextOpen returns t if the window is open at t or the nearest time when it opens
+func (w *Window) NextOpen(t time.Time) time.Time {
+	if w.Contains(t) {
+		return t
+	}
+	midnight, offset := w.offset(t)
+	if offset < w.Start {
+		return midnight.Add(w.Start)
+	}
+	return midnight.AddDate(0, 0, 1).Add(w.Start)
+}
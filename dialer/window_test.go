@@ -0,0 +1,33 @@
+package dialer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWindow(t *testing.T) {
+	window := &Window{Start: 9 * time.Hour, End: 18 * time.Hour, Location: time.UTC}
+	day := time.Date(2017, 1, 2, 0, 0, 0, 0, time.UTC)
+	expect(t, window.Contains(day.Add(10*time.Hour)), true)
+	expect(t, window.Contains(day.Add(8*time.Hour)), false)
+	expect(t, window.Contains(day.Add(18*time.Hour)), false)
+	expect(t, window.NextOpen(day.Add(10*time.Hour)), day.Add(10*time.Hour))
+	expect(t, window.NextOpen(day.Add(8*time.Hour)), day.Add(9*time.Hour))
+	expect(t, window.NextOpen(day.Add(19*time.Hour)), day.Add(33*time.Hour))
+}
+
+func TestWindowOverMidnight(t *testing.T) {
+	window := &Window{Start: 22 * time.Hour, End: 2 * time.Hour, Location: time.UTC}
+	day := time.Date(2017, 1, 2, 0, 0, 0, 0, time.UTC)
+	expect(t, window.Contains(day.Add(23*time.Hour)), true)
+	expect(t, window.Contains(day.Add(time.Hour)), true)
+	expect(t, window.Contains(day.Add(12*time.Hour)), false)
+	expect(t, window.NextOpen(day.Add(12*time.Hour)), day.Add(22*time.Hour))
+}
+
+func TestNilWindow(t *testing.T) {
+	var window *Window
+	now := time.Now()
+	expect(t, window.Contains(now), true)
+	expect(t, window.NextOpen(now), now)
+}
@@ -0,0 +1,195 @@
+package bandwidth
+
+import (
+	"fmt"
+	"strings"
+)
+
+// E164Number is a phone number in E.164 format (e.g. "+19195551212")
+type E164Number string
+
+// InvalidPhoneNumberError is returned for malformed phone numbers
+type InvalidPhoneNumberError struct {
+	Number string
+	Reason string
+}
+
+func (e *InvalidPhoneNumberError) Error() string {
+	return fmt.Sprintf("Invalid phone number %q: %s", e.Number, e.Reason)
+}
+
+var tollFreeAreaCodes = map[string]bool{
+	"800": true, "833": true, "844": true, "855": true, "866": true, "877": true, "888": true,
+}
+
+// ParseE164Number parses a number in international ("+1 (919) 555-1212", "00 44 20 7946 0000", "011 44 ...")
+// or national NANP ("(919) 555-1212", "1-919-555-1212") format and normalizes it to E.164
+// NANP numbers are validated by area code and exchange rules
+// It returns E164Number or error (InvalidPhoneNumberError)
+// example: number, err := bandwidth.ParseE164Number("(919) 555-1212")
+func ParseE164Number(s string) (E164Number, error) {
+	text := strings.TrimSpace(s)
+	international := false
+	switch {
+	case strings.HasPrefix(text, "+"):
+		international, text = true, text[1:]
+	case strings.HasPrefix(text, "00"):
+		international, text = true, text[2:]
+	case strings.HasPrefix(text, "011"):
+		international, text = true, text[3:]
+	}
+	digits := make([]byte, 0, len(text))
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c >= '0' && c <= '9':
+			digits = append(digits, c)
+		case c == ' ' || c == '-' || c == '.' || c == '(' || c == ')':
+		default:
+			return "", &InvalidPhoneNumberError{Number: s, Reason: fmt.Sprintf("unexpected character %q", c)}
+		}
+	}
+	if !international {
+		switch {
+		case len(digits) == 10:
+			digits = append([]byte{'1'}, digits...)
+		case len(digits) == 11 && digits[0] == '1':
+		default:
+			return "", &InvalidPhoneNumberError{Number: s, Reason: "national number should have 10 digits"}
+		}
+	}
+	if len(digits) < 8 || len(digits) > 15 {
+		return "", &InvalidPhoneNumberError{Number: s, Reason: "number should have from 8 to 15 digits"}
+	}
+	if digits[0] == '0' {
+		return "", &InvalidPhoneNumberError{Number: s, Reason: "country code can't start with 0"}
+	}
+	number := E164Number("+" + string(digits))
+	if digits[0] == '1' {
+		if err := validateNANP(string(digits[1:])); err != "" {
+			return "", &InvalidPhoneNumberError{Number: s, Reason: err}
+		}
+	}
+	return number, nil
+}
+
+// validateNANP checks 10 digits of NANP number
+// It returns description of the problem or empty string for valid number
+func validateNANP(digits string) string {
+	if len(digits) != 10 {
+		return "NANP number should have 10 digits after country code"
+	}
+	if !isNXX(digits[:3]) {
+		return "invalid area code " + digits[:3]
+	}
+	if !isNXX(digits[3:6]) {
+		return "invalid exchange code " + digits[3:6]
+	}
+	return ""
+}
+
+// isNXX returns true for a NANP code which starts with 2-9 and is not a N11 service code
+func isNXX(code string) bool {
+	return code[0] >= '2' && code[0] <= '9' && code[1:] != "11"
+}
+
+// MustParseE164Number is like ParseE164Number but panics on invalid number
+// example: number := bandwidth.MustParseE164Number("+19195551212")
+func MustParseE164Number(s string) E164Number {
+	number, err := ParseE164Number(s)
+	if err != nil {
+		panic(err)
+	}
+	return number
+}
+
+// String returns the number in E.164 format
+func (n E164Number) String() string {
+	return string(n)
+}
+
+// IsNANP returns true for numbers of North American Numbering Plan (country code 1)
+func (n E164Number) IsNANP() bool {
+	return len(n) == 12 && strings.HasPrefix(string(n), "+1")
+}
+
+// AreaCode returns area code of NANP number (empty string for other numbers)
+func (n E164Number) AreaCode() string {
+	if !n.IsNANP() {
+		return ""
+	}
+	return string(n[2:5])
+}
+
+// IsTollFree returns true for NANP toll-free numbers (800, 833, 844, 855, 866, 877, 888)
+func (n E164Number) IsTollFree() bool {
+	return tollFreeAreaCodes[n.AreaCode()]
+}
+
+// National returns the number formatted for display in national format ("(919) 555-1212")
+// Numbers out of NANP are returned in E.164 format
+func (n E164Number) National() string {
+	if !n.IsNANP() {
+		return string(n)
+	}
+	return fmt.Sprintf("(%s) %s-%s", n[2:5], n[5:8], n[8:])
+}
+
+// International returns the number formatted for display in international format ("+1 919-555-1212")
+// Numbers out of NANP are returned in E.164 format
+func (n E164Number) International() string {
+	if !n.IsNANP() {
+		return string(n)
+	}
+	return fmt.Sprintf("+1 %s-%s-%s", n[2:5], n[5:8], n[8:])
+}
+
+// checkNumbers validates numbers before sending of request if StrictNumbers is set
+// Empty values and SIP URIs are skipped. Numbers should be in E.164 format.
+func (api *Client) checkNumbers(numbers ...string) error {
+	if !api.StrictNumbers {
+		return nil
+	}
+	for _, number := range numbers {
+		if number == "" || strings.HasPrefix(number, "sip:") {
+			continue
+		}
+		parsed, err := ParseE164Number(number)
+		if err != nil {
+			return err
+		}
+		if string(parsed) != number {
+			return &InvalidPhoneNumberError{Number: number, Reason: "number should be in E.164 format (" + string(parsed) + ")"}
+		}
+	}
+	return nil
+}
+
+// checkIDOrNumber validates idOrNumber if it is a number
+func (api *Client) checkIDOrNumber(idOrNumber string) error {
+	if strings.HasPrefix(idOrNumber, "+") {
+		return api.checkNumbers(idOrNumber)
+	}
+	return nil
+}
+
+// checkAreaCode validates area code of query of available numbers
+func (api *Client) checkAreaCode(query *GetAvailableNumberQuery) error {
+	if !api.StrictNumbers || query == nil || query.AreaCode == "" {
+		return nil
+	}
+	code := query.AreaCode
+	if len(code) != 3 || strings.Trim(code, "0123456789") != "" || !isNXX(code) {
+		return &InvalidPhoneNumberError{Number: code, Reason: "invalid area code"}
+	}
+	return nil
+}
+
+// checkRecipients validates recipients of a message (a number or list of numbers)
+func (api *Client) checkRecipients(to interface{}) error {
+	recipients, err := recipientList(to)
+	if err != nil {
+		return err
+	}
+	return api.checkNumbers(recipients...)
+}
@@ -0,0 +1,142 @@
+package bandwidth
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestParseE164Number(t *testing.T) {
+	valid := map[string]string{
+		"+19195551212":        "+19195551212",
+		"+1 (919) 555-1212":   "+19195551212",
+		"(919) 555-1212":      "+19195551212",
+		"919.555.1212":        "+19195551212",
+		"1-919-555-1212":      "+19195551212",
+		"00 44 20 7946 0000":  "+442079460000",
+		"011 44 20 7946 0000": "+442079460000",
+	}
+	for text, expected := range valid {
+		number, err := ParseE164Number(text)
+		expectNil(t, err)
+		expect(t, number, E164Number(expected))
+	}
+	invalid := []string{"", "12345", "+1919555121a", "(119) 555-1212", "(911) 555-1212", "(919) 155-1212",
+		"(919) 411-1212", "+1919555121", "+0123456789", "+1234567890123456"}
+	for _, text := range invalid {
+		_, err := ParseE164Number(text)
+		if _, ok := err.(*InvalidPhoneNumberError); !ok {
+			t.Errorf("Expected InvalidPhoneNumberError for %q, got %v", text, err)
+		}
+	}
+}
+
+func TestMustParseE164Number(t *testing.T) {
+	expect(t, MustParseE164Number("919-555-1212"), E164Number("+19195551212"))
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic")
+		}
+	}()
+	MustParseE164Number("123")
+}
+
+func TestE164NumberFormatting(t *testing.T) {
+	number := E164Number("+19195551212")
+	expect(t, number.String(), "+19195551212")
+	expect(t, number.IsNANP(), true)
+	expect(t, number.AreaCode(), "919")
+	expect(t, number.IsTollFree(), false)
+	expect(t, number.National(), "(919) 555-1212")
+	expect(t, number.International(), "+1 919-555-1212")
+	expect(t, E164Number("+18885551212").IsTollFree(), true)
+	foreign := E164Number("+442079460000")
+	expect(t, foreign.IsNANP(), false)
+	expect(t, foreign.AreaCode(), "")
+	expect(t, foreign.IsTollFree(), false)
+	expect(t, foreign.National(), "+442079460000")
+	expect(t, foreign.International(), "+442079460000")
+}
+
+func TestStrictNumbers(t *testing.T) {
+	api := getAPI()
+	expectNil(t, api.checkNumbers("919-555-1212"))
+	api.StrictNumbers = true
+	expectNil(t, api.checkNumbers("+19195551212", "", "sip:user@domain.com"))
+	_, ok := api.checkNumbers("919-555-1212").(*InvalidPhoneNumberError)
+	expect(t, ok, true)
+	_, ok = api.checkNumbers("+1911").(*InvalidPhoneNumberError)
+	expect(t, ok, true)
+	expectNil(t, api.checkIDOrNumber("n-123"))
+	expectNil(t, api.checkAreaCode(&GetAvailableNumberQuery{AreaCode: "919"}))
+	expectNil(t, api.checkAreaCode(nil))
+	_, ok = api.checkAreaCode(&GetAvailableNumberQuery{AreaCode: "9a9"}).(*InvalidPhoneNumberError)
+	expect(t, ok, true)
+	expectNil(t, api.checkRecipients([]interface{}{"+19195551212"}))
+	_, ok = api.checkRecipients([]string{"+19195551212", "5551212"}).(*InvalidPhoneNumberError)
+	expect(t, ok, true)
+}
+
+func TestStrictNumbersRejectsBeforeRequest(t *testing.T) {
+	server, api, log := startMockServerWithLog(t, func(w http.ResponseWriter, r *http.Request, body string) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	defer server.Close()
+	api.StrictNumbers = true
+	shouldFail(t, func() (interface{}, error) {
+		return api.CreateCall(&CreateCallData{From: "+19195551212", To: "555-1212"})
+	})
+	shouldFail(t, func() (interface{}, error) { return api.UpdateCall("id", &UpdateCallData{TransferTo: "919"}) })
+	shouldFail(t, func() (interface{}, error) {
+		return api.UpdateCall("id", &UpdateCallData{TransferTo: "+19195551212", TransferCallerID: "+1"})
+	})
+	shouldFail(t, func() (interface{}, error) {
+		return api.CreateMessage(&CreateMessageData{From: "+19195551212", To: "1"})
+	})
+	shouldFail(t, func() (interface{}, error) {
+		return api.CreateMessages(&CreateMessageData{From: "1", To: "+19195551212"})
+	})
+	shouldFail(t, func() (interface{}, error) {
+		return api.CreateMessageV2(&CreateMessageDataV2{From: "+19195551212", To: NewRecipients("1")}, api.APIEndPoint)
+	})
+	shouldFail(t, func() (interface{}, error) {
+		return api.CreateMessageV2(&CreateMessageDataV2{From: "1"}, api.APIEndPoint)
+	})
+	shouldFail(t, func() (interface{}, error) { return api.GetPhoneNumber("+1") })
+	shouldFail(t, func() (interface{}, error) { return nil, api.UpdatePhoneNumber("+1", &UpdatePhoneNumberData{}) })
+	shouldFail(t, func() (interface{}, error) {
+		return nil, api.UpdatePhoneNumber("n-1", &UpdatePhoneNumberData{FallbackNumber: "1"})
+	})
+	shouldFail(t, func() (interface{}, error) { return api.CreatePhoneNumber(&CreatePhoneNumberData{Number: "1"}) })
+	shouldFail(t, func() (interface{}, error) {
+		return api.GetAvailableNumbers(AvailableNumberTypeLocal, &GetAvailableNumberQuery{AreaCode: "111"})
+	})
+	shouldFail(t, func() (interface{}, error) {
+		return api.GetAndOrderAvailableNumbers(AvailableNumberTypeLocal, &GetAvailableNumberQuery{AreaCode: "111"})
+	})
+	expect(t, len(log()), 0)
+}
+
+func TestStrictNumbersAllowsValidNumbers(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery:     "/v1/users/userId/calls",
+		Method:           http.MethodPost,
+		EstimatedContent: `{"from":"+19195551212","to":"+19195551213"}`,
+		HeadersToSend:    map[string]string{"Location": "/v1/users/userId/calls/123"},
+		StatusCodeToSend: http.StatusCreated}})
+	defer server.Close()
+	api.StrictNumbers = true
+	id, err := api.CreateCall(&CreateCallData{From: "+19195551212", To: "+19195551213"})
+	expectNil(t, err)
+	expect(t, id, "123")
+}
+
+func TestStrictNumbersAllowsTransferCallerIDText(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery:     "/v1/users/userId/calls/123",
+		Method:           http.MethodPost,
+		EstimatedContent: `{"transferCallerId":"private","transferTo":"+19195551213"}`}})
+	defer server.Close()
+	api.StrictNumbers = true
+	_, err := api.UpdateCall("123", &UpdateCallData{TransferTo: "+19195551213", TransferCallerID: "private"})
+	expectNil(t, err)
+}
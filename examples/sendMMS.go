@@ -1,21 +1,9 @@
 package examples
 
 import "github.com/bandwidthcom/go-bandwidth"
+import "context"
 import "os"
 import "fmt"
-import "math/rand"
-
-
-const letterBytes = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
-
-func randomString(n int) string {
-    b := make([]byte, n)
-    for i := range b {
-        b[i] = letterBytes[rand.Intn(len(letterBytes))]
-    }
-    return string(b)
-}
-
 
 //run: sendMMS <from-number> <to-number> <text> <path-to-jpg-file-to-attach>
 func main4() {
@@ -24,20 +12,10 @@ func main4() {
 	toNumber := os.Args[2]
 	text := os.Args[3]
 	jpgPath := os.Args[4]
-	mediaName := randomString(64)
-	err := api.UploadMediaFile(mediaName, jpgPath, "image/jpeg")
-	if err != nil {
-		fmt.Printf("Error on uploading file: %s", err.Error())
-		return
-	}
-	id, err := api.CreateMessage(&bandwidth.CreateMessageData{
-		From: fromNumber,
-		To: toNumber,
-		Text: text,
-		Media: []string{fmt.Sprintf("https://api.catapult.inetwork.com/v1/users/%s/media/%s", os.Getenv("CATAPULT_USER_ID"), mediaName)}})
+	result, err := api.SendMMS(context.Background(), fromNumber, toNumber, text, bandwidth.MMSFile(jpgPath, "image/jpeg"))
 	if err != nil {
 		fmt.Printf("Error: %s", err.Error())
 		return
 	}
-	fmt.Printf("Message ID is %s", id)
+	fmt.Printf("Message ID is %s", result.MessageID)
 }
@@ -8,6 +8,8 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -57,45 +59,50 @@ type RequestHandler struct {
 func startMockServer(t *testing.T, handlers []RequestHandler) (*httptest.Server, *Client) {
 	api := getAPI()
 	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		for _, handler := range handlers {
-			if handler.Method == "" {
-				handler.Method = http.MethodGet
-			}
-			if handler.StatusCodeToSend == 0 {
-				handler.StatusCodeToSend = http.StatusOK
-			}
-			if handler.Method == r.Method && handler.PathAndQuery == r.URL.String() {
-				if handler.EstimatedContent != "" {
-					expect(t, readText(t, r.Body), handler.EstimatedContent)
-				}
-				if handler.EstimatedHeaders != nil {
-					for key, value := range handler.EstimatedHeaders {
-						expect(t, r.Header.Get(key), value)
-					}
-				}
-				header := w.Header()
-				if handler.HeadersToSend != nil {
-					for key, value := range handler.HeadersToSend {
-						header.Set(key, value)
-					}
-				}
-				if handler.ContentToSend != "" && header.Get("Content-Type") == "" {
-					header.Set("Content-Type", "application/json")
-				}
-				w.WriteHeader(handler.StatusCodeToSend)
-				if handler.ContentToSend != "" {
-					fmt.Fprintln(w, handler.ContentToSend)
-				}
-				return
-			}
+		if !serveRequestHandlers(t, handlers, w, r, readText(t, r.Body)) {
+			t.Logf("Unhandled request %s %s", r.Method, r.URL.String())
+			w.WriteHeader(http.StatusNotFound)
 		}
-		t.Logf("Unhandled request %s %s", r.Method, r.URL.String())
-		w.WriteHeader(http.StatusNotFound)
 	}))
 	api.APIEndPoint = mockServer.URL
 	return mockServer, api
 }
 
+// serveRequestHandlers responds using first handler which matches method, path and query of the request
+// It returns false if there is no such handler.
+func serveRequestHandlers(t *testing.T, handlers []RequestHandler, w http.ResponseWriter, r *http.Request, body string) bool {
+	for _, handler := range handlers {
+		if handler.Method == "" {
+			handler.Method = http.MethodGet
+		}
+		if handler.StatusCodeToSend == 0 {
+			handler.StatusCodeToSend = http.StatusOK
+		}
+		if handler.Method != r.Method || handler.PathAndQuery != r.URL.String() {
+			continue
+		}
+		if handler.EstimatedContent != "" {
+			expect(t, body, handler.EstimatedContent)
+		}
+		for key, value := range handler.EstimatedHeaders {
+			expect(t, r.Header.Get(key), value)
+		}
+		header := w.Header()
+		for key, value := range handler.HeadersToSend {
+			header.Set(key, value)
+		}
+		if handler.ContentToSend != "" && header.Get("Content-Type") == "" {
+			header.Set("Content-Type", "application/json")
+		}
+		w.WriteHeader(handler.StatusCodeToSend)
+		if handler.ContentToSend != "" {
+			fmt.Fprintln(w, handler.ContentToSend)
+		}
+		return true
+	}
+	return false
+}
+
 func readText(t *testing.T, r io.Reader) string {
 	text, err := ioutil.ReadAll(r)
 	if err != nil {
@@ -104,3 +111,34 @@ func readText(t *testing.T, r io.Reader) string {
 	}
 	return string(text)
 }
+
+// startMockServerWithLog starts server which responds using handler and returns log of received requests ("METHOD path body")
+// Use it for responses which depend on earlier requests (static responses are served by startMockServerWithHandlersAndLog).
+func startMockServerWithLog(t *testing.T, handler func(w http.ResponseWriter, r *http.Request, body string)) (*httptest.Server, *Client, func() []string) {
+	api := getAPI()
+	var mutex sync.Mutex
+	log := []string{}
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := readText(t, r.Body)
+		mutex.Lock()
+		log = append(log, strings.TrimSpace(fmt.Sprintf("%s %s %s", r.Method, r.URL.Path, body)))
+		mutex.Unlock()
+		handler(w, r, body)
+	}))
+	api.APIEndPoint = mockServer.URL
+	return mockServer, api, func() []string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]string{}, log...)
+	}
+}
+
+// startMockServerWithHandlersAndLog starts server which responds using handlers (like startMockServer) and returns log of received requests
+// Requests without a handler get status otherwise (they are checked by the log).
+func startMockServerWithHandlersAndLog(t *testing.T, handlers []RequestHandler, otherwise int) (*httptest.Server, *Client, func() []string) {
+	return startMockServerWithLog(t, func(w http.ResponseWriter, r *http.Request, body string) {
+		if !serveRequestHandlers(t, handlers, w, r, body) {
+			w.WriteHeader(otherwise)
+		}
+	})
+}
@@ -0,0 +1,114 @@
+package ivr
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Bandwidth/go-bandwidth"
+	"github.com/Bandwidth/go-bandwidth/xml"
+)
+
+// BXMLRunner executes menu flow by returning BXML responses to callbacks
+type BXMLRunner struct {
+	Flow *Flow
+	// GatherURL is URL of this handler which receives results of gathers
+	GatherURL string
+}
+
+// NewBXMLRunner creates new BXMLRunner instance
+// example: http.Handle("/ivr", ivr.NewBXMLRunner(flow, "https://example.com/ivr"))
+func NewBXMLRunner(flow *Flow, gatherURL string) *BXMLRunner {
+	return &BXMLRunner{Flow: flow, GatherURL: gatherURL}
+}
+
+// HandleCallback moves the menu flow of the call forward using data of a callback
+// It returns BXML response (nil if nothing should be done) or error
+func (r *BXMLRunner) HandleCallback(event *bandwidth.CallbackEvent) (*xml.Response, error) {
+	var step *Step
+	var err error
+	switch event.EventType {
+	case bandwidth.CallbackEventIncomingCall, bandwidth.CallbackEventAnswer:
+		step, err = r.Flow.Start(event.CallID)
+	case bandwidth.CallbackEventGather:
+		if event.Reason == gatherReasonHungUp {
+			return nil, r.Flow.End(event.CallID)
+		}
+		step, err = r.Flow.Input(event.CallID, event.Digits)
+	case bandwidth.CallbackEventHangup:
+		return nil, r.Flow.End(event.CallID)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.Response(step), nil
+}
+
+// ServeHTTP allows to use BXMLRunner as handler of BXML requests
+func (r *BXMLRunner) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	event, err := bandwidth.ParseCallbackEvent(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	response, err := r.HandleCallback(event)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if response == nil {
+		response = &xml.Response{}
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	fmt.Fprint(w, response.ToXML())
+}
+
+// Response builds BXML for a step of the menu flow
+func (r *BXMLRunner) Response(step *Step) *xml.Response {
+	response := &xml.Response{Verbs: []interface{}{}}
+	if step.Prompt != nil {
+		response.Verbs = append(response.Verbs, promptVerb(step.Prompt))
+	}
+	if step.Action != nil {
+		if step.Action.Transfer != "" {
+			response.Verbs = append(response.Verbs, xml.Transfer{TransferTo: step.Action.Transfer, TransferCallerID: step.Action.TransferCallerID})
+		} else {
+			response.Verbs = append(response.Verbs, xml.Hangup{})
+		}
+		return response
+	}
+	gather := xml.Gather{
+		RequestURL:        r.GatherURL,
+		MaxDigits:         step.Menu.maxDigits(),
+		InterDigitTimeout: step.Menu.timeout(),
+		Tag:               step.Tag,
+	}
+	if step.Menu.TerminatingDigits != "" {
+		gather.TerminatingDigits = step.Menu.TerminatingDigits
+	}
+	if step.Menu.Prompt != nil {
+		switch verb := promptVerb(step.Menu.Prompt).(type) {
+		case xml.PlayAudio:
+			gather.PlayAudio = &verb
+		case xml.SpeakSentence:
+			gather.SpeakSentence = &verb
+		}
+	}
+	response.Verbs = append(response.Verbs, gather)
+	return response
+}
+
+func promptVerb(prompt *Prompt) interface{} {
+	if prompt.FileURL != "" {
+		return xml.PlayAudio{URL: prompt.FileURL}
+	}
+	verb := xml.SpeakSentence{Sentence: prompt.Sentence, Voice: prompt.Voice}
+	if prompt.Gender != "" {
+		verb.Gender = prompt.Gender
+	}
+	if prompt.Locale != "" {
+		verb.Locale = prompt.Locale
+	}
+	return verb
+}
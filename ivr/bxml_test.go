@@ -0,0 +1,52 @@
+package ivr
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Bandwidth/go-bandwidth"
+)
+
+func TestBXMLRunner(t *testing.T) {
+	runner := NewBXMLRunner(NewFlow(loadTestMenu(t), NewMemoryStore()), "http://example.com/ivr")
+	response, err := runner.HandleCallback(&bandwidth.CallbackEvent{EventType: bandwidth.CallbackEventIncomingCall, CallID: "123"})
+	expectNil(t, err)
+	expect(t, response.ToXML(), `<Response><Gather requestUrl="http://example.com/ivr" maxDigits="1" interDigitTimeout="5" tag="ivr:"><SpeakSentence>Press 1 for sales, 2 for support</SpeakSentence></Gather></Response>`)
+	response, _ = runner.HandleCallback(&bandwidth.CallbackEvent{EventType: bandwidth.CallbackEventGather, CallID: "123", Digits: "2"})
+	expect(t, response.ToXML(), `<Response><Gather requestUrl="http://example.com/ivr" maxDigits="1" interDigitTimeout="5" tag="ivr:2"><PlayAudio>http://example.com/support.mp3</PlayAudio></Gather></Response>`)
+	response, _ = runner.HandleCallback(&bandwidth.CallbackEvent{EventType: bandwidth.CallbackEventGather, CallID: "123", Digits: "*"})
+	response, _ = runner.HandleCallback(&bandwidth.CallbackEvent{EventType: bandwidth.CallbackEventGather, CallID: "123", Digits: "1"})
+	expect(t, response.ToXML(), `<Response><SpeakSentence>Connecting to sales</SpeakSentence><Transfer transferTo="+19195551212"></Transfer></Response>`)
+	response, err = runner.HandleCallback(&bandwidth.CallbackEvent{EventType: bandwidth.CallbackEventHangup, CallID: "123"})
+	expectNil(t, err)
+	expect(t, response == nil, true)
+}
+
+func TestBXMLRunnerWithHungUpGather(t *testing.T) {
+	runner := NewBXMLRunner(NewFlow(loadTestMenu(t), NewMemoryStore()), "http://example.com/ivr")
+	runner.HandleCallback(&bandwidth.CallbackEvent{EventType: bandwidth.CallbackEventIncomingCall, CallID: "123"})
+	response, err := runner.HandleCallback(&bandwidth.CallbackEvent{EventType: bandwidth.CallbackEventGather, CallID: "123", Reason: "hung-up"})
+	expectNil(t, err)
+	expect(t, response == nil, true)
+	_, err = runner.Flow.Current("123")
+	expect(t, err, ErrNoSession)
+}
+
+func TestBXMLRunnerServeHTTP(t *testing.T) {
+	runner := NewBXMLRunner(NewFlow(loadTestMenu(t), NewMemoryStore()), "http://example.com/ivr")
+	w := httptest.NewRecorder()
+	runner.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ivr?eventType=answer&callId=123", nil))
+	expect(t, w.Code, http.StatusOK)
+	expect(t, w.Header().Get("Content-Type"), "application/xml")
+	w = httptest.NewRecorder()
+	runner.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ivr?eventType=gather&callId=123&digits=9", nil))
+	expect(t, w.Body.String(), `<Response><SpeakSentence>Invalid choice</SpeakSentence><Gather requestUrl="http://example.com/ivr" maxDigits="1" interDigitTimeout="5" tag="ivr:"><SpeakSentence>Press 1 for sales, 2 for support</SpeakSentence></Gather></Response>`)
+	w = httptest.NewRecorder()
+	runner.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/ivr", bytes.NewReader([]byte(`{"eventType": "gather", "callId": "456", "digits": "1"}`))))
+	expect(t, w.Code, http.StatusInternalServerError)
+	w = httptest.NewRecorder()
+	runner.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/ivr", bytes.NewReader([]byte(`{}`))))
+	expect(t, w.Code, http.StatusBadRequest)
+}
@@ -0,0 +1,151 @@
+package ivr
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNoSession is returned when input is received for a call without started menu flow
+var ErrNoSession = errors.New("No IVR session for the call")
+
+// tagPrefix starts tags of menus (see Step.Tag)
+const tagPrefix = "ivr:"
+
+// Step is the next thing to do with the call
+type Step struct {
+	// Prompt should be played before anything else (e.g. invalid input message)
+	Prompt *Prompt
+	// Menu is a menu to gather digits for (nil if Action is set)
+	Menu *Menu
+	// Tag identifies the menu within the tree
+	Tag string
+	// Action is a leaf action (transfer or hangup) which finishes the flow
+	Action *Action
+}
+
+// Flow executes a menu tree for calls
+type Flow struct {
+	Root  *Menu
+	Store Store
+}
+
+// NewFlow creates new Flow instance
+// example: flow := ivr.NewFlow(menu, ivr.NewMemoryStore())
+func NewFlow(root *Menu, store Store) *Flow {
+	return &Flow{Root: root, Store: store}
+}
+
+func (f *Flow) menuAt(path []string) (*Menu, error) {
+	menu := f.Root
+	for _, digits := range path {
+		action, ok := menu.Options[digits]
+		if !ok || action.Menu == nil {
+			return nil, fmt.Errorf("Invalid menu path %v", path)
+		}
+		menu = action.Menu
+	}
+	return menu, nil
+}
+
+func pathTag(path []string) string {
+	tag := tagPrefix
+	for i, digits := range path {
+		if i > 0 {
+			tag += "/"
+		}
+		tag += digits
+	}
+	return tag
+}
+
+// Start begins the menu flow for the call
+// It returns first Step (root menu) or error
+func (f *Flow) Start(callID string) (*Step, error) {
+	if err := f.Store.Save(&Session{CallID: callID}); err != nil {
+		return nil, err
+	}
+	return &Step{Menu: f.Root, Tag: pathTag(nil)}, nil
+}
+
+// Current returns a step for current menu of the call (e.g. to repeat it)
+// If a leaf action waits for end of its prompt (see Session.Action) the step contains only this action.
+// It returns Step or error
+func (f *Flow) Current(callID string) (*Step, error) {
+	session, err := f.Store.Get(callID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, ErrNoSession
+	}
+	if session.Action != nil {
+		return &Step{Action: session.Action}, nil
+	}
+	menu, err := f.menuAt(session.Path)
+	if err != nil {
+		return nil, err
+	}
+	return &Step{Menu: menu, Tag: pathTag(session.Path)}, nil
+}
+
+// Input handles digits entered by the caller (empty string means timeout)
+// It returns next Step or error
+func (f *Flow) Input(callID string, digits string) (*Step, error) {
+	session, err := f.Store.Get(callID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, ErrNoSession
+	}
+	menu, err := f.menuAt(session.Path)
+	if err != nil {
+		return nil, err
+	}
+	action, ok := menu.Options[digits]
+	if digits == "" || !ok {
+		session.Retries++
+		if session.Retries > menu.maxRetries() {
+			return f.finish(callID, menu.onFailure())
+		}
+		if err := f.Store.Save(session); err != nil {
+			return nil, err
+		}
+		prompt := menu.InvalidPrompt
+		if digits == "" && menu.TimeoutPrompt != nil {
+			prompt = menu.TimeoutPrompt
+		}
+		return &Step{Prompt: prompt, Menu: menu, Tag: pathTag(session.Path)}, nil
+	}
+	if action.IsLeaf() {
+		return f.finish(callID, action)
+	}
+	session.Retries = 0
+	if action.Back {
+		if len(session.Path) > 0 {
+			session.Path = session.Path[:len(session.Path)-1]
+		}
+	} else {
+		session.Path = append(session.Path, digits)
+	}
+	if err := f.Store.Save(session); err != nil {
+		return nil, err
+	}
+	menu, err = f.menuAt(session.Path)
+	if err != nil {
+		return nil, err
+	}
+	return &Step{Prompt: action.Prompt, Menu: menu, Tag: pathTag(session.Path)}, nil
+}
+
+func (f *Flow) finish(callID string, action *Action) (*Step, error) {
+	if err := f.Store.Delete(callID); err != nil {
+		return nil, err
+	}
+	return &Step{Prompt: action.Prompt, Action: action}, nil
+}
+
+// End removes state of the call (e.g. after hang up)
+func (f *Flow) End(callID string) error {
+	return f.Store.Delete(callID)
+}
@@ -0,0 +1,45 @@
+package ivr
+
+import "testing"
+
+func TestFlow(t *testing.T) {
+	menu := loadTestMenu(t)
+	flow := NewFlow(menu, NewMemoryStore())
+	step, err := flow.Start("123")
+	expectNil(t, err)
+	expect(t, step.Menu, menu)
+	expect(t, step.Tag, "ivr:")
+	step, _ = flow.Input("123", "2")
+	expect(t, step.Menu, menu.Options["2"].Menu)
+	expect(t, step.Tag, "ivr:2")
+	step, _ = flow.Current("123")
+	expect(t, step.Tag, "ivr:2")
+	step, _ = flow.Input("123", "*")
+	expect(t, step.Menu, menu)
+	step, _ = flow.Input("123", "1")
+	expect(t, step.Action.Transfer, "+19195551212")
+	expect(t, step.Prompt.Sentence, "Connecting to sales")
+	_, err = flow.Input("123", "1")
+	expect(t, err, ErrNoSession)
+}
+
+func TestFlowWithInvalidInput(t *testing.T) {
+	menu := loadTestMenu(t)
+	flow := NewFlow(menu, NewMemoryStore())
+	flow.Start("123")
+	step, _ := flow.Input("123", "9")
+	expect(t, step.Menu, menu)
+	expect(t, step.Prompt.Sentence, "Invalid choice")
+	step, _ = flow.Input("123", "")
+	expect(t, step.Action.Hangup, true)
+	_, err := flow.Current("123")
+	expect(t, err, ErrNoSession)
+}
+
+func TestFlowEnd(t *testing.T) {
+	flow := NewFlow(loadTestMenu(t), NewMemoryStore())
+	flow.Start("123")
+	expectNil(t, flow.End("123"))
+	_, err := flow.Input("123", "1")
+	expect(t, err, ErrNoSession)
+}
@@ -0,0 +1,75 @@
+package ivr
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Bandwidth/go-bandwidth"
+)
+
+func expect(t *testing.T, value interface{}, expected interface{}) {
+	if !reflect.DeepEqual(value, expected) {
+		t.Errorf("Expected %v  - Got %v (%T)", expected, value, value)
+	}
+}
+
+func expectNil(t *testing.T, value interface{}) {
+	if value != nil {
+		t.Errorf("Expected nil  - Got %v", value)
+	}
+}
+
+const testMenu = `{
+	"name": "main",
+	"prompt": {"sentence": "Press 1 for sales, 2 for support"},
+	"maxRetries": 1,
+	"invalidPrompt": {"sentence": "Invalid choice"},
+	"options": {
+		"1": {"prompt": {"sentence": "Connecting to sales"}, "transfer": "+19195551212"},
+		"2": {"menu": {
+			"prompt": {"fileUrl": "http://example.com/support.mp3"},
+			"options": {"1": {"hangup": true}, "*": {"back": true}}
+		}}
+	}
+}`
+
+func loadTestMenu(t *testing.T) *Menu {
+	menu, err := LoadMenu(strings.NewReader(testMenu))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return menu
+}
+
+// mockServer records received requests as "METHOD path body"
+type mockServer struct {
+	*httptest.Server
+	mutex    sync.Mutex
+	requests []string
+}
+
+func (s *mockServer) Requests() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string{}, s.requests...)
+}
+
+func startMockServer(t *testing.T) (*mockServer, *bandwidth.Client) {
+	api, _ := bandwidth.New("userId", "apiToken", "apiSecret")
+	server := &mockServer{}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		server.mutex.Lock()
+		server.requests = append(server.requests, r.Method+" "+r.URL.Path+" "+string(body))
+		server.mutex.Unlock()
+		w.Header().Set("Location", r.URL.Path+"/1")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	api.APIEndPoint = server.URL
+	return server, api
+}
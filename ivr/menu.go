@@ -0,0 +1,198 @@
+// Package ivr implements voice menus (IVR) on top of Catapult gathers and BXML
+package ivr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/Bandwidth/go-bandwidth"
+)
+
+const (
+	// DefaultMaxDigits is default count of digits gathered by a menu
+	DefaultMaxDigits = 1
+	// DefaultTimeout is default time (in seconds) to wait for a digit
+	DefaultTimeout = 5
+	// DefaultMaxRetries is default count of repeats of a menu after invalid input or timeout
+	DefaultMaxRetries = 2
+)
+
+// Prompt is a sentence or an audio file played to the caller
+type Prompt struct {
+	Sentence string `yaml:"sentence,omitempty" json:"sentence,omitempty"`
+	FileURL  string `yaml:"fileUrl,omitempty" json:"fileUrl,omitempty"`
+	Gender   string `yaml:"gender,omitempty" json:"gender,omitempty"`
+	Locale   string `yaml:"locale,omitempty" json:"locale,omitempty"`
+	Voice    string `yaml:"voice,omitempty" json:"voice,omitempty"`
+}
+
+// PlayAudioData converts the prompt to data of PlayAudioToCall()
+func (p *Prompt) PlayAudioData() *bandwidth.PlayAudioData {
+	return &bandwidth.PlayAudioData{FileURL: p.FileURL, Sentence: p.Sentence, Gender: p.Gender, Locale: p.Locale, Voice: p.Voice}
+}
+
+// Action is executed when the caller selects a menu option
+// Exactly one of Menu, Back, Transfer or Hangup should be set
+type Action struct {
+	// Prompt is played before the action
+	Prompt *Prompt `yaml:"prompt,omitempty" json:"prompt,omitempty"`
+	// Menu is a sub-menu to open
+	Menu *Menu `yaml:"menu,omitempty" json:"menu,omitempty"`
+	// Back returns to the parent menu
+	Back bool `yaml:"back,omitempty" json:"back,omitempty"`
+	// Transfer is a phone number to transfer the call to
+	Transfer         string `yaml:"transfer,omitempty" json:"transfer,omitempty"`
+	TransferCallerID string `yaml:"transferCallerId,omitempty" json:"transferCallerId,omitempty"`
+	// Hangup completes the call
+	Hangup bool `yaml:"hangup,omitempty" json:"hangup,omitempty"`
+}
+
+// IsLeaf returns true if the action finishes the menu flow
+func (a *Action) IsLeaf() bool {
+	return a.Menu == nil && !a.Back
+}
+
+// Menu is a node of IVR menu tree
+type Menu struct {
+	Name              string             `yaml:"name,omitempty" json:"name,omitempty"`
+	Prompt            *Prompt            `yaml:"prompt,omitempty" json:"prompt,omitempty"`
+	Options           map[string]*Action `yaml:"options,omitempty" json:"options,omitempty"`
+	MaxDigits         int                `yaml:"maxDigits,omitempty" json:"maxDigits,omitempty"`
+	Timeout           int                `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	TerminatingDigits string             `yaml:"terminatingDigits,omitempty" json:"terminatingDigits,omitempty"`
+	MaxRetries        int                `yaml:"maxRetries,omitempty" json:"maxRetries,omitempty"`
+	InvalidPrompt     *Prompt            `yaml:"invalidPrompt,omitempty" json:"invalidPrompt,omitempty"`
+	TimeoutPrompt     *Prompt            `yaml:"timeoutPrompt,omitempty" json:"timeoutPrompt,omitempty"`
+	// OnFailure is executed after MaxRetries invalid inputs (hangup by default)
+	OnFailure *Action `yaml:"onFailure,omitempty" json:"onFailure,omitempty"`
+}
+
+func (m *Menu) maxDigits() int {
+	if m.MaxDigits > 0 {
+		return m.MaxDigits
+	}
+	return DefaultMaxDigits
+}
+
+func (m *Menu) timeout() int {
+	if m.Timeout > 0 {
+		return m.Timeout
+	}
+	return DefaultTimeout
+}
+
+func (m *Menu) maxRetries() int {
+	if m.MaxRetries > 0 {
+		return m.MaxRetries
+	}
+	return DefaultMaxRetries
+}
+
+func (m *Menu) onFailure() *Action {
+	if m.OnFailure != nil {
+		return m.OnFailure
+	}
+	return &Action{Hangup: true}
+}
+
+// GatherData builds data of CreateGather() for the menu
+func (m *Menu) GatherData(tag string) *bandwidth.CreateGatherData {
+	data := &bandwidth.CreateGatherData{
+		MaxDigits:         m.maxDigits(),
+		InterDigitTimeout: m.timeout(),
+		TerminatingDigits: m.TerminatingDigits,
+		Tag:               tag,
+	}
+	if m.Prompt != nil {
+		data.Prompt = &bandwidth.GatherPromptData{
+			FileURL:   m.Prompt.FileURL,
+			Sentence:  m.Prompt.Sentence,
+			Gender:    m.Prompt.Gender,
+			Locale:    m.Prompt.Locale,
+			Voice:     m.Prompt.Voice,
+			Bargeable: true,
+		}
+	}
+	return data
+}
+
+// Validate checks the menu tree
+// It returns error object
+func (m *Menu) Validate() error {
+	return m.validate(m.Name)
+}
+
+func (m *Menu) validate(path string) error {
+	if len(m.Options) == 0 {
+		return fmt.Errorf("Menu %s has no options", path)
+	}
+	for digits, action := range m.Options {
+		if action == nil {
+			return fmt.Errorf("Option %s of menu %s has no action", digits, path)
+		}
+		if err := action.validate(fmt.Sprintf("%s/%s", path, digits)); err != nil {
+			return err
+		}
+	}
+	if m.OnFailure != nil {
+		if !m.OnFailure.IsLeaf() {
+			return fmt.Errorf("OnFailure of menu %s should transfer or hang up the call", path)
+		}
+		return m.OnFailure.validate(path + "/onFailure")
+	}
+	return nil
+}
+
+func (a *Action) validate(path string) error {
+	count := 0
+	if a.Menu != nil {
+		count++
+	}
+	if a.Back {
+		count++
+	}
+	if a.Transfer != "" {
+		count++
+	}
+	if a.Hangup {
+		count++
+	}
+	if count != 1 {
+		return fmt.Errorf("Action %s should have exactly one of menu, back, transfer or hangup", path)
+	}
+	if a.Menu != nil {
+		return a.Menu.validate(path)
+	}
+	return nil
+}
+
+// LoadMenu reads a menu tree in JSON format
+// It returns validated Menu instance or error
+func LoadMenu(r io.Reader) (*Menu, error) {
+	return LoadMenuWith(r, json.Unmarshal)
+}
+
+// LoadMenuWith reads a menu tree using the unmarshal function (e.g. to read menus in YAML format)
+// Fields of menu types have both json and yaml tags.
+// It returns validated Menu instance or error
+// example: menu, err := ivr.LoadMenuWith(file, yaml.Unmarshal)
+func LoadMenuWith(r io.Reader, unmarshal func(data []byte, v interface{}) error) (*Menu, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	menu := &Menu{}
+	if err := unmarshal(data, menu); err != nil {
+		return nil, err
+	}
+	if menu.Options == nil && menu.Prompt == nil {
+		return nil, errors.New("Empty menu")
+	}
+	if err := menu.Validate(); err != nil {
+		return nil, err
+	}
+	return menu, nil
+}
@@ -0,0 +1,64 @@
+package ivr
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLoadMenu(t *testing.T) {
+	menu := loadTestMenu(t)
+	expect(t, menu.Name, "main")
+	expect(t, menu.Prompt.Sentence, "Press 1 for sales, 2 for support")
+	expect(t, len(menu.Options), 2)
+	expect(t, menu.Options["1"].Transfer, "+19195551212")
+	expect(t, menu.Options["1"].IsLeaf(), true)
+	expect(t, menu.Options["2"].Menu.Options["1"].Hangup, true)
+	expect(t, menu.Options["2"].Menu.Options["*"].Back, true)
+	expect(t, menu.Options["2"].IsLeaf(), false)
+}
+
+func TestLoadMenuFail(t *testing.T) {
+	for _, text := range []string{
+		"",
+		`{"invalid": [`,
+		`{"prompt": {"sentence": "test"}}`,
+		`{"options": {"1": {}}}`,
+		`{"options": {"1": {"hangup": true, "transfer": "123"}}}`,
+		`{"options": {"1": {"hangup": true}}, "onFailure": {"back": true}}`,
+	} {
+		if _, err := LoadMenu(strings.NewReader(text)); err == nil {
+			t.Errorf("Should fail for %q", text)
+		}
+	}
+}
+
+func TestLoadMenuWith(t *testing.T) {
+	formats := []string{}
+	menu, err := LoadMenuWith(strings.NewReader(testMenu), func(data []byte, v interface{}) error {
+		formats = append(formats, "custom")
+		return json.Unmarshal(data, v)
+	})
+	expectNil(t, err)
+	expect(t, formats, []string{"custom"})
+	expect(t, menu.Options["1"].Transfer, "+19195551212")
+	_, err = LoadMenuWith(strings.NewReader(testMenu), func(data []byte, v interface{}) error { return errors.New("invalid format") })
+	expect(t, err.Error(), "invalid format")
+}
+
+func TestGatherData(t *testing.T) {
+	menu := loadTestMenu(t)
+	data := menu.GatherData("ivr:")
+	expect(t, data.MaxDigits, DefaultMaxDigits)
+	expect(t, data.InterDigitTimeout, DefaultTimeout)
+	expect(t, data.Tag, "ivr:")
+	expect(t, data.Prompt.Sentence, "Press 1 for sales, 2 for support")
+	expect(t, data.Prompt.Bargeable, true)
+	menu = &Menu{MaxDigits: 4, Timeout: 10, TerminatingDigits: "#"}
+	data = menu.GatherData("")
+	expect(t, data.MaxDigits, 4)
+	expect(t, data.InterDigitTimeout, 10)
+	expect(t, data.TerminatingDigits, "#")
+	expect(t, data.Prompt == nil, true)
+}
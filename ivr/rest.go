@@ -0,0 +1,149 @@
+package ivr
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Bandwidth/go-bandwidth"
+)
+
+const (
+	// actionTag is tag of a prompt played before a leaf action (the action is kept in Session.Action)
+	actionTag = "ivr-action"
+	menuTag   = "ivr-menu"
+)
+
+// gatherReasonHungUp is reason of a gather finished by hang up of the caller
+const gatherReasonHungUp = "hung-up"
+
+// RESTRunner executes menu flow via REST API (gathers and call callbacks)
+type RESTRunner struct {
+	API  *bandwidth.Client
+	Flow *Flow
+}
+
+// NewRESTRunner creates new RESTRunner instance
+// example: runner := ivr.NewRESTRunner(api, ivr.NewFlow(menu, ivr.NewMemoryStore()))
+func NewRESTRunner(api *bandwidth.Client, flow *Flow) *RESTRunner {
+	return &RESTRunner{API: api, Flow: flow}
+}
+
+// Start begins the menu flow for an answered call
+// It returns error object
+func (r *RESTRunner) Start(callID string) error {
+	step, err := r.Flow.Start(callID)
+	if err != nil {
+		return err
+	}
+	return r.execute(callID, step)
+}
+
+// HandleCallback moves the menu flow of the call forward using data of a call callback
+// It returns error object
+func (r *RESTRunner) HandleCallback(event *bandwidth.CallbackEvent) error {
+	switch event.EventType {
+	case bandwidth.CallbackEventAnswer:
+		return r.Start(event.CallID)
+	case bandwidth.CallbackEventGather:
+		if event.State != "completed" || !strings.HasPrefix(event.Tag, tagPrefix) {
+			return nil
+		}
+		if event.Reason == gatherReasonHungUp {
+			return r.Flow.End(event.CallID)
+		}
+		current, err := r.Flow.Current(event.CallID)
+		if err != nil {
+			return err
+		}
+		if current.Tag != event.Tag {
+			// a late gather of a previous menu
+			return nil
+		}
+		step, err := r.Flow.Input(event.CallID, event.Digits)
+		if err != nil {
+			return err
+		}
+		return r.execute(event.CallID, step)
+	case bandwidth.CallbackEventSpeak, bandwidth.CallbackEventPlayback:
+		if event.Status != "done" {
+			return nil
+		}
+		if event.Tag != menuTag && event.Tag != actionTag {
+			return nil
+		}
+		step, err := r.Flow.Current(event.CallID)
+		if err == ErrNoSession {
+			// the caller has hung up while the prompt was played
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if event.Tag == menuTag && step.Menu != nil {
+			return r.gather(event.CallID, step)
+		}
+		if event.Tag == actionTag && step.Action != nil {
+			if err := r.Flow.End(event.CallID); err != nil {
+				return err
+			}
+			return r.executeAction(event.CallID, step.Action)
+		}
+	case bandwidth.CallbackEventHangup:
+		return r.Flow.End(event.CallID)
+	}
+	return nil
+}
+
+// ServeHTTP allows to use RESTRunner as handler of call callbacks
+// example: http.Handle("/callbacks/ivr", runner)
+func (r *RESTRunner) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	event, err := bandwidth.ParseCallbackEvent(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := r.HandleCallback(event); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (r *RESTRunner) execute(callID string, step *Step) error {
+	if step.Action != nil {
+		if step.Prompt != nil {
+			// the leaf action is executed after the prompt has been played (see HandleCallback)
+			if err := r.Flow.Store.Save(&Session{CallID: callID, Action: step.Action}); err != nil {
+				return err
+			}
+			data := step.Prompt.PlayAudioData()
+			data.Tag = actionTag
+			return r.API.PlayAudioToCall(callID, data)
+		}
+		return r.executeAction(callID, step.Action)
+	}
+	if step.Prompt != nil {
+		// the gather is created after the prompt has been played (see HandleCallback) to not overlap the prompt of the menu
+		data := step.Prompt.PlayAudioData()
+		data.Tag = menuTag
+		return r.API.PlayAudioToCall(callID, data)
+	}
+	return r.gather(callID, step)
+}
+
+func (r *RESTRunner) gather(callID string, step *Step) error {
+	_, err := r.API.CreateGather(callID, step.Menu.GatherData(step.Tag))
+	return err
+}
+
+func (r *RESTRunner) executeAction(callID string, action *Action) error {
+	if action.Transfer == "" {
+		return r.API.HangUpCall(callID)
+	}
+	_, err := r.API.UpdateCall(callID, &bandwidth.UpdateCallData{
+		State:            "transferring",
+		TransferTo:       action.Transfer,
+		TransferCallerID: action.TransferCallerID,
+	})
+	return err
+}
@@ -0,0 +1,91 @@
+package ivr
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Bandwidth/go-bandwidth"
+)
+
+func TestRESTRunner(t *testing.T) {
+	server, api := startMockServer(t)
+	defer server.Close()
+	runner := NewRESTRunner(api, NewFlow(loadTestMenu(t), NewMemoryStore()))
+	expectNil(t, runner.HandleCallback(&bandwidth.CallbackEvent{EventType: bandwidth.CallbackEventAnswer, CallID: "123"}))
+	expectNil(t, runner.HandleCallback(&bandwidth.CallbackEvent{EventType: bandwidth.CallbackEventGather, CallID: "123", State: "completed", Digits: "5", Tag: "ivr:"}))
+	expectNil(t, runner.HandleCallback(&bandwidth.CallbackEvent{EventType: bandwidth.CallbackEventSpeak, CallID: "123", Status: "started", Tag: "ivr-menu"}))
+	expectNil(t, runner.HandleCallback(&bandwidth.CallbackEvent{EventType: bandwidth.CallbackEventSpeak, CallID: "123", Status: "done", Tag: "ivr-menu"}))
+	expectNil(t, runner.HandleCallback(&bandwidth.CallbackEvent{EventType: bandwidth.CallbackEventGather, CallID: "123", State: "completed", Digits: "1", Tag: "other"}))
+	expectNil(t, runner.HandleCallback(&bandwidth.CallbackEvent{EventType: bandwidth.CallbackEventGather, CallID: "123", State: "completed", Digits: "1", Tag: "ivr:"}))
+	expectNil(t, runner.HandleCallback(&bandwidth.CallbackEvent{EventType: bandwidth.CallbackEventSpeak, CallID: "123", Status: "done", Tag: "ivr-action"}))
+	expect(t, server.Requests(), []string{
+		`POST /v1/users/userId/calls/123/gather {"maxDigits":"1","interDigitTimeout":"5","tag":"ivr:","prompt":{"sentence":"Press 1 for sales, 2 for support","bargeable":true}}`,
+		`POST /v1/users/userId/calls/123/audio {"sentence":"Invalid choice","tag":"ivr-menu"}`,
+		`POST /v1/users/userId/calls/123/gather {"maxDigits":"1","interDigitTimeout":"5","tag":"ivr:","prompt":{"sentence":"Press 1 for sales, 2 for support","bargeable":true}}`,
+		`POST /v1/users/userId/calls/123/audio {"sentence":"Connecting to sales","tag":"ivr-action"}`,
+		`POST /v1/users/userId/calls/123 {"transferTo":"+19195551212","state":"transferring"}`,
+	})
+	_, err := runner.Flow.Current("123")
+	expect(t, err, ErrNoSession)
+}
+
+func TestRESTRunnerWithLateGather(t *testing.T) {
+	server, api := startMockServer(t)
+	defer server.Close()
+	runner := NewRESTRunner(api, NewFlow(loadTestMenu(t), NewMemoryStore()))
+	expectNil(t, runner.Start("123"))
+	expectNil(t, runner.HandleCallback(&bandwidth.CallbackEvent{EventType: bandwidth.CallbackEventGather, CallID: "123", State: "completed", Digits: "2", Tag: "ivr:"}))
+	// a gather of the root menu finished after the sub-menu has been opened is ignored
+	expectNil(t, runner.HandleCallback(&bandwidth.CallbackEvent{EventType: bandwidth.CallbackEventGather, CallID: "123", State: "completed", Digits: "1", Tag: "ivr:"}))
+	expect(t, len(server.Requests()), 2)
+	step, _ := runner.Flow.Current("123")
+	expect(t, step.Tag, "ivr:2")
+	// a gather finished while the prompt of a leaf action is played is ignored too
+	expectNil(t, runner.HandleCallback(&bandwidth.CallbackEvent{EventType: bandwidth.CallbackEventGather, CallID: "123", State: "completed", Digits: "*", Tag: "ivr:2"}))
+	expectNil(t, runner.HandleCallback(&bandwidth.CallbackEvent{EventType: bandwidth.CallbackEventGather, CallID: "123", State: "completed", Digits: "1", Tag: "ivr:"}))
+	expectNil(t, runner.HandleCallback(&bandwidth.CallbackEvent{EventType: bandwidth.CallbackEventGather, CallID: "123", State: "completed", Digits: "2", Tag: "ivr:"}))
+	step, _ = runner.Flow.Current("123")
+	expect(t, step.Action.Transfer, "+19195551212")
+	expect(t, len(server.Requests()), 4)
+}
+
+func TestRESTRunnerWithHangup(t *testing.T) {
+	server, api := startMockServer(t)
+	defer server.Close()
+	runner := NewRESTRunner(api, NewFlow(loadTestMenu(t), NewMemoryStore()))
+	expectNil(t, runner.Start("123"))
+	w := httptest.NewRecorder()
+	runner.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{"eventType": "gather", "callId": "123", "state": "completed", "digits": "2", "tag": "ivr:"}`))))
+	expect(t, w.Code, http.StatusOK)
+	expectNil(t, runner.HandleCallback(&bandwidth.CallbackEvent{EventType: bandwidth.CallbackEventGather, CallID: "123", State: "completed", Digits: "1", Tag: "ivr:2"}))
+	requests := server.Requests()
+	expect(t, requests[1], `POST /v1/users/userId/calls/123/gather {"maxDigits":"1","interDigitTimeout":"5","tag":"ivr:2","prompt":{"fileUrl":"http://example.com/support.mp3","bargeable":true}}`)
+	expect(t, requests[2], `POST /v1/users/userId/calls/123 {"state":"completed"}`)
+}
+
+func TestRESTRunnerWithHungUpGather(t *testing.T) {
+	server, api := startMockServer(t)
+	defer server.Close()
+	runner := NewRESTRunner(api, NewFlow(loadTestMenu(t), NewMemoryStore()))
+	expectNil(t, runner.Start("123"))
+	expectNil(t, runner.HandleCallback(&bandwidth.CallbackEvent{EventType: bandwidth.CallbackEventGather, CallID: "123", State: "completed", Reason: "hung-up", Tag: "ivr:"}))
+	expect(t, len(server.Requests()), 1)
+	_, err := runner.Flow.Current("123")
+	expect(t, err, ErrNoSession)
+	// a prompt finished after hang up is ignored
+	expectNil(t, runner.HandleCallback(&bandwidth.CallbackEvent{EventType: bandwidth.CallbackEventSpeak, CallID: "123", Status: "done", Tag: "ivr-menu"}))
+	expect(t, len(server.Requests()), 1)
+}
+
+func TestRESTRunnerFail(t *testing.T) {
+	server, api := startMockServer(t)
+	defer server.Close()
+	runner := NewRESTRunner(api, NewFlow(loadTestMenu(t), NewMemoryStore()))
+	err := runner.HandleCallback(&bandwidth.CallbackEvent{EventType: bandwidth.CallbackEventGather, CallID: "123", State: "completed", Digits: "1", Tag: "ivr:"})
+	expect(t, err, ErrNoSession)
+	w := httptest.NewRecorder()
+	runner.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{"eventType": "gather", "callId": "123", "state": "completed", "digits": "1", "tag": "ivr:"}`))))
+	expect(t, w.Code, http.StatusInternalServerError)
+}
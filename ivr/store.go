@@ -0,0 +1,64 @@
+package ivr
+
+import "sync"
+
+// Session is state of menu flow of one call
+type Session struct {
+	CallID string
+	// Path is list of selected options from root menu to current menu
+	Path []string
+	// Retries is count of invalid inputs in current menu
+	Retries int
+	// Action is a leaf action which waits for end of its prompt (it is set by RESTRunner)
+	Action *Action
+}
+
+// Store keeps sessions of calls
+type Store interface {
+	// Get returns session of the call (nil if it is missing)
+	Get(callID string) (*Session, error)
+	Save(session *Session) error
+	Delete(callID string) error
+}
+
+// MemoryStore keeps sessions in memory
+type MemoryStore struct {
+	mutex    sync.Mutex
+	sessions map[string]*Session
+}
+
+// NewMemoryStore creates new MemoryStore instance
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sessions: map[string]*Session{}}
+}
+
+// Get returns session of the call
+func (s *MemoryStore) Get(callID string) (*Session, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	session, ok := s.sessions[callID]
+	if !ok {
+		return nil, nil
+	}
+	result := *session
+	result.Path = append([]string{}, session.Path...)
+	return &result, nil
+}
+
+// Save stores session of the call
+func (s *MemoryStore) Save(session *Session) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	result := *session
+	result.Path = append([]string{}, session.Path...)
+	s.sessions[session.CallID] = &result
+	return nil
+}
+
+// Delete removes session of the call
+func (s *MemoryStore) Delete(callID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.sessions, callID)
+	return nil
+}
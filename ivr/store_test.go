@@ -0,0 +1,19 @@
+package ivr
+
+import "testing"
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	session, err := store.Get("123")
+	expectNil(t, err)
+	expect(t, session == nil, true)
+	expectNil(t, store.Save(&Session{CallID: "123", Path: []string{"1"}}))
+	session, _ = store.Get("123")
+	expect(t, session.Path, []string{"1"})
+	session.Path[0] = "2"
+	session, _ = store.Get("123")
+	expect(t, session.Path, []string{"1"})
+	expectNil(t, store.Delete("123"))
+	session, _ = store.Get("123")
+	expect(t, session == nil, true)
+}
@@ -1,12 +1,18 @@
 package bandwidth
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"mime"
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 )
 
 const mediaPath = "media"
@@ -18,6 +24,27 @@ type MediaFile struct {
 	MediaName     string `json:"mediaName"`
 }
 
+// MediaUploadOptions is optional parameters of UploadMedia()
+type MediaUploadOptions struct {
+	// ContentType is content type of the file (it is detected by name or content if it is empty)
+	ContentType string
+	// Progress is called after each sent chunk with count of sent bytes and total size (-1 if size is unknown)
+	Progress func(sent, total int64)
+}
+
+// MediaDownload is a downloaded media file
+type MediaDownload struct {
+	// Body is content of the file (it should be closed by caller)
+	Body          io.ReadCloser
+	ContentType   string
+	ContentLength int64
+	Header        http.Header
+	// Partial is true if Body contains a part of the file (see DownloadMediaFileRange())
+	Partial bool
+	// TotalSize is size of whole file (-1 if it is unknown)
+	TotalSize int64
+}
+
 // GetMediaFiles returns  a list of your media files
 // It returns list of MediaFile instances or error
 func (api *Client) GetMediaFiles() ([]*MediaFile, error) {
@@ -40,49 +67,219 @@ func (api *Client) DeleteMediaFile(name string) error {
 // example: api.UploadMediaFile("file.jpg", "/path/ti/file.jpg", "image/jpeg")
 // api.UploadMediaFile("file.bin", readCloserInstance) // using io.ReadCloser instance
 func (api *Client) UploadMediaFile(name string, file interface{}, contentType ...string) error {
-	request, err := api.createRequest(http.MethodPut, fmt.Sprintf("%s/%s", api.concatUserPath(mediaPath), url.QueryEscape(name)), "v1")
-	if err != nil {
-		return err
-	}
+	options := &MediaUploadOptions{ContentType: "application/octet-stream"}
 	if len(contentType) > 0 {
-		request.Header.Set("Content-Type", contentType[0])
-	} else {
-		request.Header.Set("Content-Type", "application/octet-stream")
+		options.ContentType = contentType[0]
 	}
-	switch file.(type) {
+	switch v := file.(type) {
 	case string:
-		request.Body, err = os.Open(file.(string))
-		if err != nil {
-			return err
-		}
-	default:
-		request.Body = file.(io.ReadCloser)
+		return api.UploadMediaFromFile(context.Background(), name, v, options)
+	case io.ReadCloser:
+		defer v.Close()
+		return api.UploadMedia(context.Background(), name, v, readerSize(v), options)
+	case io.Reader:
+		return api.UploadMedia(context.Background(), name, v, readerSize(v), options)
 	}
-	client := &http.Client{}
-	response, err := client.Do(request)
+	return fmt.Errorf("Unsupported media content %T (a file path or io.Reader is expected)", file)
+}
+
+// UploadMediaFromFile uploads a local file as media file
+// It returns error object
+// example: err := api.UploadMediaFromFile(ctx, "file.jpg", "/path/to/file.jpg", nil)
+func (api *Client) UploadMediaFromFile(ctx context.Context, name, path string, options *MediaUploadOptions) error {
+	file, err := os.Open(path)
 	if err != nil {
 		return err
 	}
-	_, _, err = api.checkResponse(response, nil)
-	return err
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	o := MediaUploadOptions{}
+	if options != nil {
+		o = *options
+	}
+	if o.ContentType == "" && filepath.Ext(name) == "" {
+		// the media name has no extension: content type is detected by name of the local file
+		o.ContentType = mime.TypeByExtension(filepath.Ext(path))
+	}
+	return api.UploadMedia(ctx, name, file, info.Size(), &o)
+}
+
+// UploadMedia uploads content as media file
+// size is size of the content (-1 if it is unknown). Content-Length is sent if the size is known.
+// Requests are retried according to Client.RetryPolicy if content implements io.Seeker.
+// It returns error object
+// example: err := api.UploadMedia(ctx, "file.jpg", bytes.NewReader(data), int64(len(data)), &bandwidth.MediaUploadOptions{ContentType: "image/jpeg"})
+func (api *Client) UploadMedia(ctx context.Context, name string, content io.Reader, size int64, options *MediaUploadOptions) error {
+	return api.uploadMedia(ctx, api.APIEndPoint, "v1", api.mediaV1Path(name), name, content, size, options)
 }
 
 // DownloadMediaFile download media ffile
 // It returns error io.ReadCloser, cotent type of downloaded file or error
 // example: stream, contentType,  err := api.DownloadMediaFile("file.jpg")
 func (api *Client) DownloadMediaFile(name string) (io.ReadCloser, string, error) {
-	request, err := api.createRequest(http.MethodGet, fmt.Sprintf("%s/%s", api.concatUserPath(mediaPath), url.QueryEscape(name)), "v1")
+	download, err := api.DownloadMedia(context.Background(), name)
 	if err != nil {
 		return nil, "", err
 	}
-	client := &http.Client{}
-	response, err := client.Do(request)
+	return download.Body, download.ContentType, nil
+}
+
+// DownloadMedia downloads a media file
+// Requests are retried according to Client.RetryPolicy.
+// It returns MediaDownload instance (its Body should be closed by caller) or error
+// example: download, err := api.DownloadMedia(ctx, "file.jpg")
+func (api *Client) DownloadMedia(ctx context.Context, name string) (*MediaDownload, error) {
+	return api.downloadMedia(ctx, api.APIEndPoint, "v1", api.mediaV1Path(name), nil)
+}
+
+func (api *Client) mediaV1Path(name string) string {
+	return fmt.Sprintf("%s/%s", api.concatUserPath(mediaPath), url.QueryEscape(name))
+}
+
+// readerSize returns size of content of known reader types (-1 for others)
+func readerSize(r io.Reader) int64 {
+	switch v := r.(type) {
+	case *bytes.Reader:
+		return int64(v.Len())
+	case *bytes.Buffer:
+		return int64(v.Len())
+	case *strings.Reader:
+		return int64(v.Len())
+	case *os.File:
+		if info, err := v.Stat(); err == nil && info.Mode().IsRegular() {
+			if offset, err := v.Seek(0, io.SeekCurrent); err == nil {
+				return info.Size() - offset
+			}
+		}
+	}
+	return -1
+}
+
+// sniffContentType detects content type of media by name or first 512 bytes of content
+// It returns content type and reader of whole content
+func sniffContentType(name string, content io.Reader) (string, io.Reader, error) {
+	if contentType := mime.TypeByExtension(filepath.Ext(name)); contentType != "" {
+		return contentType, content, nil
+	}
+	head := make([]byte, 512)
+	if seeker, ok := content.(io.ReadSeeker); ok {
+		offset, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return "", nil, err
+		}
+		n, err := io.ReadFull(seeker, head)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return "", nil, err
+		}
+		if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+			return "", nil, err
+		}
+		return http.DetectContentType(head[:n]), content, nil
+	}
+	n, err := io.ReadFull(content, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", nil, err
+	}
+	return http.DetectContentType(head[:n]), io.MultiReader(bytes.NewReader(head[:n]), content), nil
+}
+
+type progressReader struct {
+	reader   io.Reader
+	sent     int64
+	total    int64
+	progress func(sent, total int64)
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.sent += int64(n)
+		r.progress(r.sent, r.total)
+	}
+	return n, err
+}
+
+func (api *Client) uploadMedia(ctx context.Context, endPoint, version, path, name string, content io.Reader, size int64, options *MediaUploadOptions) error {
+	if content == nil {
+		return fmt.Errorf("No content of media file %s", name)
+	}
+	o := MediaUploadOptions{}
+	if options != nil {
+		o = *options
+	}
+	if o.ContentType == "" {
+		var err error
+		if o.ContentType, content, err = sniffContentType(name, content); err != nil {
+			return err
+		}
+	}
+	seeker, canRetry := content.(io.Seeker)
+	var start int64
+	if canRetry {
+		var err error
+		if start, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			canRetry = false
+		}
+	}
+	response, err := api.doWithRetry(ctx, func(attempt int) (*http.Request, bool, error) {
+		if attempt > 1 {
+			if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+				return nil, false, err
+			}
+		}
+		request, err := api.createRequestWithEndPoint(endPoint, http.MethodPut, path, version)
+		if err != nil {
+			return nil, false, err
+		}
+		request.Header.Set("Content-Type", o.ContentType)
+		body := content
+		if o.Progress != nil {
+			body = &progressReader{reader: content, total: size, progress: o.Progress}
+		}
+		request.Body = ioutil.NopCloser(body)
+		if size >= 0 {
+			request.ContentLength = size
+			if size == 0 {
+				request.Body = http.NoBody
+			}
+		}
+		return request, canRetry, nil
+	})
 	if err != nil {
-		return nil, "", err
+		return err
+	}
+	_, _, err = api.checkResponse(response, nil)
+	return err
+}
+
+func (api *Client) downloadMedia(ctx context.Context, endPoint, version, path string, header http.Header) (*MediaDownload, error) {
+	response, err := api.doWithRetry(ctx, func(attempt int) (*http.Request, bool, error) {
+		request, err := api.createRequestWithEndPoint(endPoint, http.MethodGet, path, version)
+		if err != nil {
+			return nil, false, err
+		}
+		request.Header.Del("Accept")
+		for key, values := range header {
+			request.Header[key] = values
+		}
+		return request, true, nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	if response.StatusCode >= 400 {
-		text, _ := ioutil.ReadAll(response.Body)
-		return nil, "", fmt.Errorf("Http code %d: %s", response.StatusCode, text)
+	download := &MediaDownload{Body: response.Body, ContentType: response.Header.Get("Content-Type"), ContentLength: response.ContentLength, Header: response.Header, TotalSize: response.ContentLength}
+	if response.StatusCode == http.StatusPartialContent {
+		download.Partial, download.TotalSize = true, -1
+		// Content-Range: bytes first-last/total
+		contentRange := response.Header.Get("Content-Range")
+		if i := strings.LastIndex(contentRange, "/"); i >= 0 {
+			if total, err := strconv.ParseInt(contentRange[i+1:], 10, 64); err == nil {
+				download.TotalSize = total
+			}
+		}
 	}
-	return response.Body, response.Header.Get("Content-Type"), nil
+	return download, nil
 }
@@ -0,0 +1,101 @@
+package media
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Bandwidth/go-bandwidth"
+)
+
+func expect(t *testing.T, value interface{}, expected interface{}) {
+	if !reflect.DeepEqual(value, expected) {
+		t.Errorf("Expected %v  - Got %v (%T)", expected, value, value)
+	}
+}
+
+func expectNil(t *testing.T, value interface{}) {
+	if value != nil {
+		t.Errorf("Expected nil  - Got %v", value)
+	}
+}
+
+// mediaServer is fake media API which keeps files in memory
+type mediaServer struct {
+	mutex sync.Mutex
+	files map[string][]byte
+	// failed contains names of files which can't be uploaded
+	failed map[string]bool
+	log    []string
+}
+
+func (s *mediaServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	const prefix = "/v1/users/userId/media"
+	if r.URL.Path == prefix && r.Method == http.MethodGet {
+		list := []*bandwidth.MediaFile{}
+		for name, content := range s.files {
+			list = append(list, &bandwidth.MediaFile{MediaName: name, ContentLength: int64(len(content))})
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].MediaName < list[j].MediaName })
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(list)
+		return
+	}
+	if !strings.HasPrefix(r.URL.Path, prefix+"/") {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	name, _ := url.QueryUnescape(strings.TrimPrefix(r.URL.EscapedPath(), prefix+"/"))
+	s.log = append(s.log, r.Method+" "+name)
+	switch r.Method {
+	case http.MethodPut:
+		if s.failed[name] {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.files[name], _ = ioutil.ReadAll(r.Body)
+	case http.MethodGet:
+		content, ok := s.files[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(content)
+	case http.MethodDelete:
+		delete(s.files, name)
+	}
+}
+
+// changes returns logged uploads and deletions
+func (s *mediaServer) changes() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	list := []string{}
+	for _, line := range s.log {
+		if !strings.HasPrefix(line, http.MethodGet) {
+			list = append(list, line)
+		}
+	}
+	s.log = nil
+	return list
+}
+
+func startMediaServer(files map[string]string) (*httptest.Server, *bandwidth.Client, *mediaServer) {
+	api, _ := bandwidth.New("userId", "apiToken", "apiSecret")
+	media := &mediaServer{files: map[string][]byte{}, failed: map[string]bool{}}
+	for name, content := range files {
+		media.files[name] = []byte(content)
+	}
+	server := httptest.NewServer(media)
+	api.APIEndPoint = server.URL
+	return server, api, media
+}
@@ -0,0 +1,81 @@
+package media
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// Action is an operation of synchronization plan
+type Action string
+
+const (
+	// ActionUpload means the local file is uploaded
+	ActionUpload Action = "upload"
+	// ActionDelete means the remote media file is removed
+	ActionDelete Action = "delete"
+	// ActionNone means nothing is changed
+	ActionNone Action = "none"
+)
+
+// Reasons of actions
+const (
+	ReasonNew            = "new"
+	ReasonSizeChanged    = "size changed"
+	ReasonContentChanged = "content changed"
+	ReasonUnchanged      = "unchanged"
+	ReasonOrphan         = "orphan"
+)
+
+// PlanItem describes synchronization of one file
+type PlanItem struct {
+	Name   string `json:"name"`
+	Action Action `json:"action"`
+	Reason string `json:"reason"`
+	Size   int64  `json:"size"`
+	// Error is error of executed action (empty for dry run and successful actions)
+	Error string `json:"error,omitempty"`
+}
+
+// Plan is result of synchronization (or list of planned changes for dry run)
+type Plan struct {
+	DryRun bool        `json:"dryRun"`
+	Items  []*PlanItem `json:"items"`
+}
+
+// Summary returns count of files per action
+func (p *Plan) Summary() map[Action]int {
+	summary := map[Action]int{}
+	for _, item := range p.Items {
+		summary[item.Action]++
+	}
+	return summary
+}
+
+// Changes returns items which upload or delete files
+func (p *Plan) Changes() []*PlanItem {
+	list := []*PlanItem{}
+	for _, item := range p.Items {
+		if item.Action != ActionNone {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// WriteCSV writes the plan in CSV format (one row per file)
+func (p *Plan) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"name", "action", "reason", "size", "error"})
+	for _, item := range p.Items {
+		writer.Write([]string{item.Name, string(item.Action), item.Reason, strconv.FormatInt(item.Size, 10), item.Error})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteJSON writes the plan in JSON format
+func (p *Plan) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(p)
+}
@@ -0,0 +1,39 @@
+package media
+
+import (
+	"bytes"
+	"testing"
+)
+
+func testPlan() *Plan {
+	return &Plan{Items: []*PlanItem{
+		&PlanItem{Name: "a.wav", Action: ActionUpload, Reason: ReasonNew, Size: 10},
+		&PlanItem{Name: "b.wav", Action: ActionNone, Reason: ReasonUnchanged, Size: 20},
+		&PlanItem{Name: "c.wav", Action: ActionDelete, Reason: ReasonOrphan, Size: 30, Error: "error"},
+	}}
+}
+
+func TestPlanSummary(t *testing.T) {
+	plan := testPlan()
+	expect(t, plan.Summary(), map[Action]int{ActionUpload: 1, ActionNone: 1, ActionDelete: 1})
+	changes := plan.Changes()
+	expect(t, len(changes), 2)
+	expect(t, changes[0].Name, "a.wav")
+	expect(t, changes[1].Name, "c.wav")
+}
+
+func TestPlanWriteCSV(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	expectNil(t, testPlan().WriteCSV(buffer))
+	expect(t, buffer.String(), "name,action,reason,size,error\n"+
+		"a.wav,upload,new,10,\n"+
+		"b.wav,none,unchanged,20,\n"+
+		"c.wav,delete,orphan,30,error\n")
+}
+
+func TestPlanWriteJSON(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	plan := &Plan{DryRun: true, Items: testPlan().Items[:1]}
+	expectNil(t, plan.WriteJSON(buffer))
+	expect(t, buffer.String(), `{"dryRun":true,"items":[{"name":"a.wav","action":"upload","reason":"new","size":10}]}`+"\n")
+}
@@ -0,0 +1,247 @@
+// Package media synchronizes media files of Bandwidth API with a local directory
+package media
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Bandwidth/go-bandwidth"
+)
+
+// DefaultManifestName is name of media file which keeps hashes of synchronized files
+const DefaultManifestName = ".media-sync-manifest.json"
+
+// Manifest keeps SHA-256 hashes of synchronized files (it is stored as a media file)
+// Prefixes contains hashes by names of media files for each Prefix of Syncer, so syncers with different prefixes can share the manifest.
+type Manifest struct {
+	Prefixes    map[string]map[string]string `json:"prefixes"`
+	UpdatedTime time.Time                    `json:"updatedTime"`
+}
+
+// Files returns hashes of files synchronized with the prefix
+func (m *Manifest) Files(prefix string) map[string]string {
+	if files, ok := m.Prefixes[prefix]; ok {
+		return files
+	}
+	return map[string]string{}
+}
+
+// Syncer uploads new and changed files of a local directory to media files
+// Files are compared by name, size and hash stored in the manifest. Hidden files (names starting with ".") are ignored.
+// Media files are listed by GetMediaFiles() at once (v1 media API has no paging).
+type Syncer struct {
+	API *bandwidth.Client
+	// Prefix is prepended to names of media files (only media files with this prefix are synchronized)
+	Prefix string
+	// ManifestName is name of media file with hashes of synchronized files (DefaultManifestName by default)
+	ManifestName string
+	// DeleteOrphans removes media files which don't exist in the local directory
+	// Only files uploaded by the syncer with the same Prefix (listed in the manifest) are removed,
+	// so recordings and other media files of the account are kept.
+	DeleteOrphans bool
+	// DryRun only builds the plan without changing media files
+	DryRun bool
+}
+
+// NewSyncer creates new Syncer instance
+func NewSyncer(api *bandwidth.Client) *Syncer {
+	return &Syncer{API: api, ManifestName: DefaultManifestName}
+}
+
+// Sync uploads new and changed files of the directory with default options
+// It returns executed plan or error
+// example: plan, err := media.Sync(ctx, api, "./prompts")
+func Sync(ctx context.Context, api *bandwidth.Client, dir string) (*Plan, error) {
+	return NewSyncer(api).Sync(ctx, dir)
+}
+
+type localFile struct {
+	path string
+	size int64
+	hash string
+}
+
+func fileHash(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+func (s *Syncer) manifestName() string {
+	if s.ManifestName != "" {
+		return s.ManifestName
+	}
+	return DefaultManifestName
+}
+
+// localFiles returns files of the directory by names of media files
+func (s *Syncer) localFiles(dir string) (map[string]*localFile, error) {
+	files := map[string]*localFile{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(info.Name(), ".") && path != dir {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		relative, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		hash, err := fileHash(path)
+		if err != nil {
+			return err
+		}
+		files[s.Prefix+filepath.ToSlash(relative)] = &localFile{path: path, size: info.Size(), hash: hash}
+		return nil
+	})
+	return files, err
+}
+
+func (s *Syncer) loadManifest(ctx context.Context) (*Manifest, error) {
+	manifest := &Manifest{}
+	download, err := s.API.DownloadMedia(ctx, s.manifestName())
+	if err != nil {
+		return nil, err
+	}
+	defer download.Body.Close()
+	if err := json.NewDecoder(download.Body).Decode(manifest); err != nil || manifest.Prefixes == nil {
+		// broken manifest: all files are uploaded again
+		return &Manifest{Prefixes: map[string]map[string]string{}}, nil
+	}
+	return manifest, nil
+}
+
+// Plan compares the directory with media files
+// It returns plan of synchronization (nothing is changed) or error
+func (s *Syncer) Plan(ctx context.Context, dir string) (*Plan, error) {
+	plan, _, _, err := s.plan(ctx, dir)
+	return plan, err
+}
+
+// plan returns the plan, local files and previous manifest
+func (s *Syncer) plan(ctx context.Context, dir string) (*Plan, map[string]*localFile, *Manifest, error) {
+	local, err := s.localFiles(dir)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	remoteFiles, err := s.API.GetMediaFiles()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	remote := map[string]int64{}
+	manifest := &Manifest{Prefixes: map[string]map[string]string{}}
+	for _, file := range remoteFiles {
+		if file.MediaName == s.manifestName() {
+			if manifest, err = s.loadManifest(ctx); err != nil {
+				return nil, nil, nil, err
+			}
+			continue
+		}
+		if strings.HasPrefix(file.MediaName, s.Prefix) {
+			remote[file.MediaName] = file.ContentLength
+		}
+	}
+	plan := &Plan{DryRun: s.DryRun, Items: []*PlanItem{}}
+	hashes := manifest.Files(s.Prefix)
+	for name, file := range local {
+		item := &PlanItem{Name: name, Action: ActionUpload, Size: file.size}
+		size, ok := remote[name]
+		switch {
+		case !ok:
+			item.Reason = ReasonNew
+		case size != file.size:
+			item.Reason = ReasonSizeChanged
+		case hashes[name] != file.hash:
+			item.Reason = ReasonContentChanged
+		default:
+			item.Action, item.Reason = ActionNone, ReasonUnchanged
+		}
+		plan.Items = append(plan.Items, item)
+	}
+	for name, size := range remote {
+		if _, ok := local[name]; ok {
+			continue
+		}
+		item := &PlanItem{Name: name, Action: ActionNone, Reason: ReasonOrphan, Size: size}
+		if _, synced := hashes[name]; s.DeleteOrphans && synced {
+			item.Action = ActionDelete
+		}
+		plan.Items = append(plan.Items, item)
+	}
+	sort.Slice(plan.Items, func(i, j int) bool { return plan.Items[i].Name < plan.Items[j].Name })
+	return plan, local, manifest, nil
+}
+
+// Sync compares the directory with media files and executes the plan (unless DryRun is set)
+// The manifest is updated after changes. Errors of uploads and deletions are stored in the plan items.
+// It returns the plan and error (if some actions have failed)
+func (s *Syncer) Sync(ctx context.Context, dir string) (*Plan, error) {
+	plan, local, previous, err := s.plan(ctx, dir)
+	if err != nil || s.DryRun {
+		return plan, err
+	}
+	// hashes of files synchronized with other prefixes are kept
+	manifest := &Manifest{Prefixes: map[string]map[string]string{}}
+	for prefix, files := range previous.Prefixes {
+		manifest.Prefixes[prefix] = files
+	}
+	hashes := map[string]string{}
+	manifest.Prefixes[s.Prefix] = hashes
+	failed := 0
+	for _, item := range plan.Items {
+		if err := ctx.Err(); err != nil {
+			return plan, err
+		}
+		var err error
+		switch item.Action {
+		case ActionUpload:
+			err = s.API.UploadMediaFromFile(ctx, item.Name, local[item.Name].path, nil)
+		case ActionDelete:
+			err = s.API.DeleteMediaFile(item.Name)
+		}
+		if err != nil {
+			item.Error = err.Error()
+			failed++
+		} else if file, ok := local[item.Name]; ok {
+			hashes[item.Name] = file.hash
+		}
+	}
+	if len(plan.Changes()) > 0 {
+		manifest.UpdatedTime = time.Now().UTC()
+		data, err := json.Marshal(manifest)
+		if err != nil {
+			return plan, err
+		}
+		if err := s.API.UploadMedia(ctx, s.manifestName(), bytes.NewReader(data), int64(len(data)), &bandwidth.MediaUploadOptions{ContentType: "application/json"}); err != nil {
+			return plan, err
+		}
+	}
+	if failed > 0 {
+		return plan, fmt.Errorf("%d of %d media changes have failed", failed, len(plan.Changes()))
+	}
+	return plan, nil
+}
@@ -0,0 +1,176 @@
+package media
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func createDir(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "media")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, dir, files)
+	return dir
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func reasons(plan *Plan) map[string]string {
+	result := map[string]string{}
+	for _, item := range plan.Items {
+		result[item.Name] = string(item.Action) + ":" + item.Reason
+	}
+	return result
+}
+
+func TestSync(t *testing.T) {
+	dir := createDir(t, map[string]string{"hold.wav": "music", "prompts/welcome.wav": "hello", ".hidden": "secret", ".git/config": "config"})
+	defer os.RemoveAll(dir)
+	server, api, media := startMediaServer(map[string]string{"old.wav": "old"})
+	defer server.Close()
+	plan, err := Sync(context.Background(), api, dir)
+	expectNil(t, err)
+	expect(t, plan.DryRun, false)
+	expect(t, reasons(plan), map[string]string{"hold.wav": "upload:new", "old.wav": "none:orphan", "prompts/welcome.wav": "upload:new"})
+	expect(t, media.changes(), []string{"PUT hold.wav", "PUT prompts/welcome.wav", "PUT " + DefaultManifestName})
+	expect(t, string(media.files["prompts/welcome.wav"]), "hello")
+	manifest := &Manifest{}
+	expectNil(t, json.Unmarshal(media.files[DefaultManifestName], manifest))
+	expect(t, len(manifest.Files("")), 2)
+	expect(t, manifest.Files("")["hold.wav"], "80f189984e5ca70287d13342f6daa0db45cba3c131c4e46dc81360f3a4c4f690")
+
+	plan, err = Sync(context.Background(), api, dir)
+	expectNil(t, err)
+	expect(t, reasons(plan), map[string]string{"hold.wav": "none:unchanged", "old.wav": "none:orphan", "prompts/welcome.wav": "none:unchanged"})
+	expect(t, media.changes(), []string{})
+}
+
+func TestSyncChangedFiles(t *testing.T) {
+	dir := createDir(t, map[string]string{"hold.wav": "music", "welcome.wav": "hello"})
+	defer os.RemoveAll(dir)
+	server, api, media := startMediaServer(nil)
+	defer server.Close()
+	_, err := Sync(context.Background(), api, dir)
+	expectNil(t, err)
+	media.changes()
+	writeFiles(t, dir, map[string]string{"hold.wav": "MUSIC", "welcome.wav": "hello!"})
+	plan, err := Sync(context.Background(), api, dir)
+	expectNil(t, err)
+	expect(t, reasons(plan), map[string]string{"hold.wav": "upload:content changed", "welcome.wav": "upload:size changed"})
+	expect(t, media.changes(), []string{"PUT hold.wav", "PUT welcome.wav", "PUT " + DefaultManifestName})
+	expect(t, string(media.files["hold.wav"]), "MUSIC")
+}
+
+func TestSyncWithoutManifest(t *testing.T) {
+	dir := createDir(t, map[string]string{"hold.wav": "music"})
+	defer os.RemoveAll(dir)
+	server, api, _ := startMediaServer(map[string]string{"hold.wav": "MUSIC"})
+	defer server.Close()
+	syncer := NewSyncer(api)
+	plan, err := syncer.Plan(context.Background(), dir)
+	expectNil(t, err)
+	expect(t, reasons(plan), map[string]string{"hold.wav": "upload:content changed"})
+}
+
+func TestSyncDryRun(t *testing.T) {
+	dir := createDir(t, map[string]string{"hold.wav": "music"})
+	defer os.RemoveAll(dir)
+	server, api, media := startMediaServer(map[string]string{"old.wav": "old", DefaultManifestName: `{"prefixes":{"":{"old.wav":"hash"}}}`})
+	defer server.Close()
+	syncer := NewSyncer(api)
+	syncer.DryRun = true
+	syncer.DeleteOrphans = true
+	plan, err := syncer.Sync(context.Background(), dir)
+	expectNil(t, err)
+	expect(t, plan.DryRun, true)
+	expect(t, reasons(plan), map[string]string{"hold.wav": "upload:new", "old.wav": "delete:orphan"})
+	expect(t, media.changes(), []string{})
+	expect(t, len(media.files), 2)
+}
+
+func TestSyncDeleteOrphans(t *testing.T) {
+	dir := createDir(t, map[string]string{"hold.wav": "music"})
+	defer os.RemoveAll(dir)
+	server, api, media := startMediaServer(map[string]string{"old.wav": "old", "recording.wav": "audio",
+		DefaultManifestName: `{"prefixes":{"":{"old.wav":"hash"}}}`})
+	defer server.Close()
+	syncer := NewSyncer(api)
+	syncer.DeleteOrphans = true
+	plan, err := syncer.Sync(context.Background(), dir)
+	expectNil(t, err)
+	expect(t, reasons(plan), map[string]string{"hold.wav": "upload:new", "old.wav": "delete:orphan", "recording.wav": "none:orphan"})
+	expect(t, media.changes(), []string{"PUT hold.wav", "DELETE old.wav", "PUT " + DefaultManifestName})
+	_, ok := media.files["old.wav"]
+	expect(t, ok, false)
+	// media files which haven't been uploaded by the syncer are kept
+	_, ok = media.files["recording.wav"]
+	expect(t, ok, true)
+}
+
+func TestSyncWithPrefix(t *testing.T) {
+	dir := createDir(t, map[string]string{"welcome.wav": "hello"})
+	defer os.RemoveAll(dir)
+	server, api, media := startMediaServer(map[string]string{"hold.wav": "music", "prompts/old.wav": "old",
+		DefaultManifestName: `{"prefixes":{"":{"hold.wav":"hash","prompts/welcome.wav":"other"},"prompts/":{"prompts/old.wav":"old"}}}`})
+	defer server.Close()
+	syncer := NewSyncer(api)
+	syncer.Prefix = "prompts/"
+	syncer.DeleteOrphans = true
+	plan, err := syncer.Sync(context.Background(), dir)
+	expectNil(t, err)
+	expect(t, reasons(plan), map[string]string{"prompts/old.wav": "delete:orphan", "prompts/welcome.wav": "upload:new"})
+	manifest := &Manifest{}
+	expectNil(t, json.Unmarshal(media.files[DefaultManifestName], manifest))
+	expect(t, manifest.Files(""), map[string]string{"hold.wav": "hash", "prompts/welcome.wav": "other"})
+	expect(t, manifest.Files("prompts/"), map[string]string{"prompts/welcome.wav": "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"})
+}
+
+func TestSyncWithOldManifest(t *testing.T) {
+	dir := createDir(t, map[string]string{"hold.wav": "music"})
+	defer os.RemoveAll(dir)
+	server, api, _ := startMediaServer(map[string]string{"hold.wav": "music",
+		DefaultManifestName: `{"files":{"hold.wav":"80f189984e5ca70287d13342f6daa0db45cba3c131c4e46dc81360f3a4c4f690"}}`})
+	defer server.Close()
+	plan, err := NewSyncer(api).Plan(context.Background(), dir)
+	expectNil(t, err)
+	expect(t, reasons(plan), map[string]string{"hold.wav": "upload:content changed"})
+}
+
+func TestSyncFail(t *testing.T) {
+	dir := createDir(t, map[string]string{"hold.wav": "music", "welcome.wav": "hello"})
+	defer os.RemoveAll(dir)
+	server, api, media := startMediaServer(nil)
+	defer server.Close()
+	media.failed["hold.wav"] = true
+	plan, err := Sync(context.Background(), api, dir)
+	expect(t, err.Error(), "1 of 2 media changes have failed")
+	expect(t, plan.Items[0].Error != "", true)
+	expect(t, plan.Items[1].Error, "")
+	manifest := &Manifest{}
+	expectNil(t, json.Unmarshal(media.files[DefaultManifestName], manifest))
+	_, ok := manifest.Files("")["hold.wav"]
+	expect(t, ok, false)
+	expect(t, len(manifest.Files("")), 1)
+}
+
+func TestSyncMissingDir(t *testing.T) {
+	server, api, _ := startMediaServer(nil)
+	defer server.Close()
+	_, err := Sync(context.Background(), api, filepath.Join(os.TempDir(), "missing-media-dir"))
+	expect(t, err != nil, true)
+}
@@ -0,0 +1,395 @@
+package bandwidth
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	defaultMediaChunkSize  = 4 * 1024 * 1024
+	defaultMediaMaxResumes = 3
+)
+
+// MediaDownloadOptions is optional parameters of DownloadMediaTo()
+type MediaDownloadOptions struct {
+	// Offset is count of already downloaded bytes (download is resumed from this position, it should be less than size of the file)
+	Offset int64
+	// ChunkSize is size of parts requested with Range header (4 MB by default)
+	ChunkSize int64
+	// Concurrency is count of parts downloaded in parallel (1 by default)
+	Concurrency int
+	// MaxResumes is max count of resumed requests of a part after network failures (3 by default, negative value disables resuming)
+	MaxResumes int
+	// Progress is called after each written block with count of written bytes (including Offset) and total size
+	Progress func(written, total int64)
+}
+
+// ChecksumError is returned when checksum of downloaded content doesn't match checksum provided by the server
+type ChecksumError struct {
+	Name     string
+	Expected string
+	Actual   string
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("Checksum mismatch of %s: expected %s, got %s", e.Name, e.Expected, e.Actual)
+}
+
+// MediaWriteError is returned when downloaded content can't be written to the destination (such errors are not resumed)
+type MediaWriteError struct {
+	Name string
+	Err  error
+}
+
+func (e *MediaWriteError) Error() string {
+	return fmt.Sprintf("Writing of media file %s has failed: %s", e.Name, e.Err)
+}
+
+// DownloadMediaFileRange downloads a part of a media file
+// length is size of the part (0 means till the end of the file)
+// If the server ignores Range header the response body is skipped up to offset (Partial is false in this case).
+// It returns MediaDownload instance (its Body should be closed by caller) or error
+// example: download, err := api.DownloadMediaFileRange(ctx, "recording.wav", 1024, 4096)
+func (api *Client) DownloadMediaFileRange(ctx context.Context, name string, offset, length int64) (*MediaDownload, error) {
+	return api.downloadMediaRange(ctx, api.mediaV1Path(name), offset, length)
+}
+
+// DownloadMediaTo downloads a media file to w using Range requests
+// Parts interrupted by network failures are resumed from the last written byte.
+// Parts are downloaded in parallel if options.Concurrency is greater than 1.
+// If the server doesn't report size of the file, parts are requested one by one until a short part.
+// Errors of w are returned as MediaWriteError without resuming.
+// Content-MD5 of each response is verified. If w implements io.ReaderAt and ETag of the file is MD5 hash of its content
+// whole file is verified after download. ChecksumError is returned on mismatch.
+// It returns size of the file or error
+// example: file, _ := os.Create("recording.wav"); size, err := api.DownloadMediaTo(ctx, "recording.wav", file, &bandwidth.MediaDownloadOptions{Concurrency: 4})
+func (api *Client) DownloadMediaTo(ctx context.Context, name string, w io.WriterAt, options ...*MediaDownloadOptions) (int64, error) {
+	path := api.mediaV1Path(name)
+	return api.downloadTo(ctx, name, func(ctx context.Context, offset, length int64) (*MediaDownload, error) {
+		return api.requestMediaRange(ctx, path, offset, length)
+	}, w, options...)
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// requestMediaRange sends request with Range header (the server can ignore it and send whole file)
+func (api *Client) requestMediaRange(ctx context.Context, path string, offset, length int64) (*MediaDownload, error) {
+	header := http.Header{}
+	if length > 0 {
+		header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+	} else {
+		header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	return api.downloadMedia(ctx, api.APIEndPoint, "v1", path, header)
+}
+
+func (api *Client) downloadMediaRange(ctx context.Context, path string, offset, length int64) (*MediaDownload, error) {
+	download, err := api.requestMediaRange(ctx, path, offset, length)
+	if err != nil {
+		return nil, err
+	}
+	if !download.Partial {
+		// the server has sent whole file
+		if offset > 0 {
+			if _, err := io.CopyN(ioutil.Discard, download.Body, offset); err != nil {
+				download.Body.Close()
+				return nil, err
+			}
+		}
+		if download.ContentLength >= 0 {
+			download.ContentLength -= offset
+		}
+		if length > 0 && (download.ContentLength < 0 || download.ContentLength > length) {
+			download.Body = readCloser{io.LimitReader(download.Body, length), download.Body}
+			download.ContentLength = length
+		}
+	}
+	return download, nil
+}
+
+// rangeFetcher sends request of a part of a file
+type rangeFetcher func(ctx context.Context, offset, length int64) (*MediaDownload, error)
+
+type downloadState struct {
+	name     string
+	w        io.WriterAt
+	total    int64
+	etag     string
+	mutex    sync.Mutex
+	written  int64
+	progress func(written, total int64)
+}
+
+func (s *downloadState) report(n int64) {
+	if s.progress == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.written += n
+	s.progress(s.written, s.total)
+}
+
+// copy writes body of the download to position pos (at most limit bytes if limit >= 0)
+// It returns count of written bytes and error (io.ErrUnexpectedEOF if the body is shorter than expected)
+func (s *downloadState) copy(download *MediaDownload, pos, limit int64) (int64, error) {
+	defer download.Body.Close()
+	expectedMD5 := download.Header.Get("Content-MD5")
+	var hasher hash.Hash
+	if expectedMD5 != "" {
+		hasher = md5.New()
+	}
+	expected := download.ContentLength
+	if limit >= 0 && (expected < 0 || expected > limit) {
+		expected = limit
+	}
+	buffer := make([]byte, 32*1024)
+	var written int64
+	for expected < 0 || written < expected {
+		size := int64(len(buffer))
+		if expected >= 0 && expected-written < size {
+			size = expected - written
+		}
+		n, err := download.Body.Read(buffer[:size])
+		if n > 0 {
+			if _, e := s.w.WriteAt(buffer[:n], pos+written); e != nil {
+				return written, &MediaWriteError{Name: s.name, Err: e}
+			}
+			if hasher != nil {
+				hasher.Write(buffer[:n])
+			}
+			written += int64(n)
+			s.report(int64(n))
+		}
+		if err == io.EOF {
+			if expected >= 0 && written < expected {
+				return written, io.ErrUnexpectedEOF
+			}
+			break
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+	if hasher != nil && written == download.ContentLength {
+		if actual := base64.StdEncoding.EncodeToString(hasher.Sum(nil)); actual != expectedMD5 {
+			return written, &ChecksumError{Name: s.name, Expected: expectedMD5, Actual: actual}
+		}
+	}
+	return written, nil
+}
+
+// part downloads bytes [start, end) of the file resuming interrupted requests
+// download is response which has been already requested for start position (or nil)
+func (s *downloadState) part(ctx context.Context, fetch rangeFetcher, download *MediaDownload, start, end int64, resumes int) error {
+	pos := start
+	for {
+		if download == nil {
+			var err error
+			if download, err = fetch(ctx, pos, end-pos); err != nil {
+				return err
+			}
+			if !download.Partial {
+				download.Body.Close()
+				return fmt.Errorf("Server has ignored Range request of %s", s.name)
+			}
+			if etag := download.Header.Get("ETag"); s.etag != "" && etag != "" && etag != s.etag {
+				download.Body.Close()
+				return fmt.Errorf("Media file %s has been changed during download", s.name)
+			}
+		}
+		n, err := s.copy(download, pos, end-pos)
+		pos += n
+		download = nil
+		if err == nil && pos >= end {
+			return nil
+		}
+		if err == nil {
+			err = io.ErrUnexpectedEOF
+		}
+		switch err.(type) {
+		case *ChecksumError, *MediaWriteError:
+			return err
+		}
+		if ctx.Err() != nil || resumes <= 0 {
+			return err
+		}
+		resumes--
+	}
+}
+
+// sequence downloads parts of the file of unknown size one by one from start until a short or empty part
+// download is response which has been already requested for start position
+// It returns size of the file or error
+func (s *downloadState) sequence(ctx context.Context, fetch rangeFetcher, download *MediaDownload, start, chunkSize int64) (int64, error) {
+	pos := start
+	for {
+		limit := chunkSize
+		if download.ContentLength < 0 {
+			// size of the part is unknown too: the body is read till the end
+			limit = -1
+		}
+		n, err := s.copy(download, pos, limit)
+		pos += n
+		if err != nil {
+			return 0, err
+		}
+		if n < chunkSize {
+			return pos, nil
+		}
+		if download, err = fetch(ctx, pos, chunkSize); err != nil {
+			if isRangeNotSatisfiable(err) {
+				return pos, nil
+			}
+			return 0, err
+		}
+		if !download.Partial {
+			download.Body.Close()
+			return 0, fmt.Errorf("Server has ignored Range request of %s", s.name)
+		}
+	}
+}
+
+// isRangeNotSatisfiable returns true for responses 416 (requested range starts after end of the file)
+func isRangeNotSatisfiable(err error) bool {
+	e, ok := err.(*HTTPError)
+	return ok && e.StatusCode == http.StatusRequestedRangeNotSatisfiable
+}
+
+var md5ETag = regexp.MustCompile(`^"?([0-9a-fA-F]{32})"?$`)
+
+func (api *Client) downloadTo(ctx context.Context, name string, fetch rangeFetcher, w io.WriterAt, options ...*MediaDownloadOptions) (int64, error) {
+	o := MediaDownloadOptions{}
+	if len(options) > 0 && options[0] != nil {
+		o = *options[0]
+	}
+	if o.ChunkSize <= 0 {
+		o.ChunkSize = defaultMediaChunkSize
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 1
+	}
+	if o.MaxResumes == 0 {
+		o.MaxResumes = defaultMediaMaxResumes
+	}
+	first, err := fetch(ctx, o.Offset, o.ChunkSize)
+	if err != nil {
+		if o.Offset == 0 && isRangeNotSatisfiable(err) {
+			// the file is empty
+			return 0, nil
+		}
+		return 0, err
+	}
+	state := &downloadState{name: name, w: w, total: first.TotalSize, etag: first.Header.Get("ETag"), written: o.Offset, progress: o.Progress}
+	switch {
+	case !first.Partial:
+		// the server doesn't support ranges: content is written sequentially and can't be resumed
+		if _, err := io.CopyN(ioutil.Discard, first.Body, o.Offset); err != nil {
+			first.Body.Close()
+			return 0, err
+		}
+		if first.ContentLength >= 0 {
+			first.ContentLength -= o.Offset
+		}
+		n, err := state.copy(first, o.Offset, -1)
+		if err != nil {
+			return 0, err
+		}
+		state.total = o.Offset + n
+	case state.total < 0:
+		// size of the file is unknown: parts are requested one by one
+		total, err := state.sequence(ctx, fetch, first, o.Offset, o.ChunkSize)
+		if err != nil {
+			return 0, err
+		}
+		state.total = total
+	default:
+		firstEnd := o.Offset + o.ChunkSize
+		if firstEnd > state.total {
+			firstEnd = state.total
+		}
+		if err := state.part(ctx, fetch, first, o.Offset, firstEnd, o.MaxResumes); err != nil {
+			return 0, err
+		}
+		if err := state.parts(ctx, fetch, firstEnd, o); err != nil {
+			return 0, err
+		}
+	}
+	if err := state.verify(); err != nil {
+		return 0, err
+	}
+	return state.total, nil
+}
+
+// parts downloads the rest of the file (from start) in parallel
+func (s *downloadState) parts(ctx context.Context, fetch rangeFetcher, start int64, o MediaDownloadOptions) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	starts := make(chan int64)
+	errs := make(chan error, o.Concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < o.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for partStart := range starts {
+				end := partStart + o.ChunkSize
+				if end > s.total {
+					end = s.total
+				}
+				if err := s.part(ctx, fetch, nil, partStart, end, o.MaxResumes); err != nil {
+					errs <- err
+					cancel()
+					return
+				}
+			}
+		}()
+	}
+	func() {
+		defer close(starts)
+		for pos := start; pos < s.total; pos += o.ChunkSize {
+			select {
+			case starts <- pos:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	wg.Wait()
+	close(errs)
+	if err := <-errs; err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// verify checks MD5 hash of whole file if ETag contains it and the file can be read back
+func (s *downloadState) verify() error {
+	match := md5ETag.FindStringSubmatch(strings.TrimSpace(s.etag))
+	reader, ok := s.w.(io.ReaderAt)
+	if match == nil || !ok {
+		return nil
+	}
+	hasher := md5.New()
+	if _, err := io.Copy(hasher, io.NewSectionReader(reader, 0, s.total)); err != nil {
+		return err
+	}
+	expected := strings.ToLower(match[1])
+	if actual := hex.EncodeToString(hasher.Sum(nil)); actual != expected {
+		return &ChecksumError{Name: s.name, Expected: expected, Actual: actual}
+	}
+	return nil
+}
@@ -0,0 +1,296 @@
+package bandwidth
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type memoryFile struct {
+	mutex sync.Mutex
+	data  []byte
+}
+
+func (f *memoryFile) WriteAt(p []byte, off int64) (int, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if end := int(off) + len(p); end > len(f.data) {
+		f.data = append(f.data, make([]byte, end-len(f.data))...)
+	}
+	copy(f.data[off:], p)
+	return len(p), nil
+}
+
+func (f *memoryFile) ReadAt(p []byte, off int64) (int, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if off >= int64(len(f.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+type writerOnly struct {
+	file *memoryFile
+}
+
+func (w writerOnly) WriteAt(p []byte, off int64) (int, error) {
+	return w.file.WriteAt(p, off)
+}
+
+type failingWriter struct{}
+
+func (w failingWriter) WriteAt(p []byte, off int64) (int, error) {
+	return 0, errors.New("disk is full")
+}
+
+type rangeServer struct {
+	mutex    sync.Mutex
+	content  string
+	etag     string
+	noRanges bool
+	// unknownTotal hides size of the file in Content-Range
+	unknownTotal bool
+	// failures contains start positions of requests which are interrupted after half of the part
+	failures map[int]bool
+	ranges   []string
+}
+
+func (s *rangeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	header := r.Header.Get("Range")
+	s.ranges = append(s.ranges, header)
+	s.mutex.Unlock()
+	if s.etag != "" {
+		w.Header().Set("ETag", s.etag)
+	}
+	if s.noRanges || header == "" {
+		w.Header().Set("Content-Length", strconv.Itoa(len(s.content)))
+		io.WriteString(w, s.content)
+		return
+	}
+	bounds := strings.Split(strings.TrimPrefix(header, "bytes="), "-")
+	start, _ := strconv.Atoi(bounds[0])
+	if start >= len(s.content) {
+		w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+	end := len(s.content) - 1
+	if bounds[1] != "" {
+		end, _ = strconv.Atoi(bounds[1])
+	}
+	if end >= len(s.content) {
+		end = len(s.content) - 1
+	}
+	part := s.content[start : end+1]
+	total := strconv.Itoa(len(s.content))
+	if s.unknownTotal {
+		total = "*"
+	}
+	w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%s", start, end, total))
+	w.Header().Set("Content-Length", strconv.Itoa(len(part)))
+	w.WriteHeader(http.StatusPartialContent)
+	s.mutex.Lock()
+	fail := s.failures[start]
+	delete(s.failures, start)
+	s.mutex.Unlock()
+	if fail {
+		io.WriteString(w, part[:len(part)/2])
+		w.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}
+	io.WriteString(w, part)
+}
+
+func startRangeServer(s *rangeServer) (*httptest.Server, *Client) {
+	server := httptest.NewServer(s)
+	api := getAPI()
+	api.APIEndPoint = server.URL
+	return server, api
+}
+
+func TestDownloadMediaFileRange(t *testing.T) {
+	server, api := startRangeServer(&rangeServer{content: "0123456789"})
+	defer server.Close()
+	download, err := api.DownloadMediaFileRange(context.Background(), "file1", 2, 3)
+	expectNil(t, err)
+	expect(t, readText(t, download.Body), "234")
+	expect(t, download.Partial, true)
+	expect(t, download.TotalSize, int64(10))
+	download.Body.Close()
+	download, err = api.DownloadMediaFileRange(context.Background(), "file1", 7, 0)
+	expectNil(t, err)
+	expect(t, readText(t, download.Body), "789")
+	download.Body.Close()
+}
+
+func TestDownloadMediaFileRangeWithoutRangeSupport(t *testing.T) {
+	server, api := startRangeServer(&rangeServer{content: "0123456789", noRanges: true})
+	defer server.Close()
+	download, err := api.DownloadMediaFileRange(context.Background(), "file1", 2, 3)
+	expectNil(t, err)
+	defer download.Body.Close()
+	expect(t, readText(t, download.Body), "234")
+	expect(t, download.Partial, false)
+	expect(t, download.ContentLength, int64(3))
+}
+
+func TestDownloadMediaFileRangeFail(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery:     "/v1/users/userId/media/file1",
+		StatusCodeToSend: http.StatusNotFound}})
+	defer server.Close()
+	shouldFail(t, func() (interface{}, error) { return api.DownloadMediaFileRange(context.Background(), "file1", 0, 1) })
+}
+
+func TestDownloadMediaTo(t *testing.T) {
+	content := strings.Repeat("0123456789", 9) + "abcde"
+	s := &rangeServer{content: content, failures: map[int]bool{20: true, 60: true}}
+	server, api := startRangeServer(s)
+	defer server.Close()
+	file := &memoryFile{}
+	var mutex sync.Mutex
+	var lastProgress int64
+	size, err := api.DownloadMediaTo(context.Background(), "file1", file, &MediaDownloadOptions{ChunkSize: 10, Concurrency: 4,
+		Progress: func(written, total int64) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			expect(t, total, int64(95))
+			lastProgress = written
+		}})
+	expectNil(t, err)
+	expect(t, size, int64(95))
+	expect(t, string(file.data), content)
+	expect(t, lastProgress, int64(95))
+	// 10 parts + 2 resumed requests
+	expect(t, len(s.ranges), 12)
+	expect(t, s.ranges[0], "bytes=0-9")
+}
+
+func TestDownloadMediaToWithOffset(t *testing.T) {
+	s := &rangeServer{content: "0123456789"}
+	server, api := startRangeServer(s)
+	defer server.Close()
+	file := &memoryFile{data: []byte("0123")}
+	size, err := api.DownloadMediaTo(context.Background(), "file1", file, &MediaDownloadOptions{Offset: 4})
+	expectNil(t, err)
+	expect(t, size, int64(10))
+	expect(t, string(file.data), "0123456789")
+	expect(t, s.ranges, []string{fmt.Sprintf("bytes=4-%d", 4+defaultMediaChunkSize-1)})
+	// the server without range support
+	s.noRanges = true
+	file = &memoryFile{data: []byte("012")}
+	size, err = api.DownloadMediaTo(context.Background(), "file1", file, &MediaDownloadOptions{Offset: 3})
+	expectNil(t, err)
+	expect(t, size, int64(10))
+	expect(t, string(file.data), "0123456789")
+}
+
+func TestDownloadMediaToWithChecksum(t *testing.T) {
+	content := "0123456789abcdef"
+	hash := md5.Sum([]byte(content))
+	s := &rangeServer{content: content, etag: `"` + hex.EncodeToString(hash[:]) + `"`}
+	server, api := startRangeServer(s)
+	defer server.Close()
+	_, err := api.DownloadMediaTo(context.Background(), "file1", &memoryFile{}, &MediaDownloadOptions{ChunkSize: 5, Concurrency: 2})
+	expectNil(t, err)
+	s.etag = `"00000000000000000000000000000000"`
+	_, err = api.DownloadMediaTo(context.Background(), "file1", &memoryFile{}, &MediaDownloadOptions{ChunkSize: 5})
+	e, ok := err.(*ChecksumError)
+	expect(t, ok, true)
+	expect(t, e.Actual, hex.EncodeToString(hash[:]))
+	// content can't be verified without io.ReaderAt
+	_, err = api.DownloadMediaTo(context.Background(), "file1", writerOnly{&memoryFile{}}, &MediaDownloadOptions{ChunkSize: 5})
+	expectNil(t, err)
+}
+
+func TestDownloadMediaToWithContentMD5(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery:  "/v1/users/userId/media/file1",
+		HeadersToSend: map[string]string{"Content-MD5": "AAAAAAAAAAAAAAAAAAAAAA==", "Content-Type": "text/plain"},
+		ContentToSend: "123"}})
+	defer server.Close()
+	_, err := api.DownloadMediaTo(context.Background(), "file1", &memoryFile{})
+	_, ok := err.(*ChecksumError)
+	expect(t, ok, true)
+}
+
+func TestDownloadMediaToFail(t *testing.T) {
+	s := &rangeServer{content: strings.Repeat("0123456789", 3), failures: map[int]bool{10: true}}
+	server, api := startRangeServer(s)
+	defer server.Close()
+	_, err := api.DownloadMediaTo(context.Background(), "file1", &memoryFile{}, &MediaDownloadOptions{ChunkSize: 10, MaxResumes: -1})
+	expect(t, err != nil, true)
+	s.etag = `"v1"`
+	s.failures = map[int]bool{}
+	fetch := func(ctx context.Context, offset, length int64) (*MediaDownload, error) {
+		download, err := api.requestMediaRange(ctx, api.mediaV1Path("file1"), offset, length)
+		s.etag = `"v2"`
+		return download, err
+	}
+	_, err = api.downloadTo(context.Background(), "file1", fetch, &memoryFile{}, &MediaDownloadOptions{ChunkSize: 10})
+	expect(t, err.Error(), "Media file file1 has been changed during download")
+}
+
+func TestDownloadMediaToWriteFail(t *testing.T) {
+	s := &rangeServer{content: strings.Repeat("0123456789", 3)}
+	server, api := startRangeServer(s)
+	defer server.Close()
+	_, err := api.DownloadMediaTo(context.Background(), "file1", failingWriter{}, &MediaDownloadOptions{ChunkSize: 10})
+	e, ok := err.(*MediaWriteError)
+	expect(t, ok, true)
+	expect(t, e.Err.Error(), "disk is full")
+	// the part is not requested again
+	expect(t, s.ranges, []string{"bytes=0-9"})
+}
+
+func TestDownloadMediaToWithUnknownSize(t *testing.T) {
+	content := strings.Repeat("0123456789", 2) + "abcde"
+	s := &rangeServer{content: content, unknownTotal: true}
+	server, api := startRangeServer(s)
+	defer server.Close()
+	file := &memoryFile{}
+	size, err := api.DownloadMediaTo(context.Background(), "file1", file, &MediaDownloadOptions{ChunkSize: 10, Concurrency: 2})
+	expectNil(t, err)
+	expect(t, size, int64(25))
+	expect(t, string(file.data), content)
+	expect(t, s.ranges, []string{"bytes=0-9", "bytes=10-19", "bytes=20-29"})
+	// the size is multiple of the chunk size
+	s.content, s.ranges = content[:20], nil
+	file = &memoryFile{}
+	size, err = api.DownloadMediaTo(context.Background(), "file1", file, &MediaDownloadOptions{ChunkSize: 10})
+	expectNil(t, err)
+	expect(t, size, int64(20))
+	expect(t, string(file.data), content[:20])
+	expect(t, s.ranges, []string{"bytes=0-9", "bytes=10-19", "bytes=20-29"})
+	// download with offset
+	file = &memoryFile{data: []byte("0123")}
+	size, err = api.DownloadMediaTo(context.Background(), "file1", file, &MediaDownloadOptions{Offset: 4, ChunkSize: 10})
+	expectNil(t, err)
+	expect(t, size, int64(20))
+	expect(t, string(file.data), content[:20])
+}
+
+func TestDownloadMediaToEmptyFile(t *testing.T) {
+	s := &rangeServer{content: ""}
+	server, api := startRangeServer(s)
+	defer server.Close()
+	file := &memoryFile{}
+	size, err := api.DownloadMediaTo(context.Background(), "file1", file)
+	expectNil(t, err)
+	expect(t, size, int64(0))
+	expect(t, len(file.data), 0)
+}
@@ -0,0 +1,63 @@
+package bandwidth
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// MediaFileV2 struct
+type MediaFileV2 struct {
+	ContentLength int64  `json:"contentLength"`
+	Content       string `json:"content"`
+	MediaName     string `json:"mediaName"`
+}
+
+func (api *Client) mediaV2Path(name string) string {
+	return fmt.Sprintf("%s/%s", api.concatUserPath(mediaPath), url.PathEscape(name))
+}
+
+// GetMediaFilesV2 returns list of media files stored by messaging API v2
+// It returns list of MediaFileV2 instances or error
+// Optional argument is messaging endpoint (MessagingV2EndPoint by default)
+func (api *Client) GetMediaFilesV2(other ...string) ([]*MediaFileV2, error) {
+	result, _, err := api.makeRequestV2ToEndPoint(messagingEndPoint(other), http.MethodGet, api.concatUserPath(mediaPath), &[]*MediaFileV2{})
+	if err != nil {
+		return nil, err
+	}
+	return *(result.(*[]*MediaFileV2)), nil
+}
+
+// DeleteMediaFileV2 removes a media file of messaging API v2
+// It returns error object
+func (api *Client) DeleteMediaFileV2(name string, other ...string) error {
+	_, _, err := api.makeRequestV2ToEndPoint(messagingEndPoint(other), http.MethodDelete, api.mediaV2Path(name))
+	return err
+}
+
+// UploadMediaFileV2 uploads a media file to messaging API v2
+// Content type is detected by name or content if it is empty (see UploadMedia() for size and retries)
+// Use URL of uploaded file (see MediaFileV2URL()) as media of v2 messages.
+// It returns error object
+// example: err := api.UploadMediaFileV2("file.jpg", file, "image/jpeg")
+func (api *Client) UploadMediaFileV2(name string, content io.Reader, contentType string, other ...string) error {
+	return api.uploadMedia(context.Background(), messagingEndPoint(other), "v2", api.mediaV2Path(name), name, content, readerSize(content), &MediaUploadOptions{ContentType: contentType})
+}
+
+// DownloadMediaFileV2 downloads a media file of messaging API v2
+// It returns io.ReadCloser (it should be closed by caller), content type of the file or error
+// example: stream, contentType, err := api.DownloadMediaFileV2("file.jpg")
+func (api *Client) DownloadMediaFileV2(name string, other ...string) (io.ReadCloser, string, error) {
+	download, err := api.downloadMedia(context.Background(), messagingEndPoint(other), "v2", api.mediaV2Path(name), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	return download.Body, download.ContentType, nil
+}
+
+// MediaFileV2URL returns URL of a media file of messaging API v2 (to use it as media of v2 messages)
+func (api *Client) MediaFileV2URL(name string, other ...string) string {
+	return api.prepareURLWithEndPoint(messagingEndPoint(other), api.mediaV2Path(name), "v2")
+}
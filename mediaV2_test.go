@@ -0,0 +1,88 @@
+package bandwidth
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+)
+
+func TestGetMediaFilesV2(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery:  "/api/v2/users/userId/media",
+		Method:        http.MethodGet,
+		ContentToSend: `[{"mediaName": "file1", "contentLength": 10}, {"mediaName": "file2"}]`}})
+	defer server.Close()
+	result, err := api.GetMediaFilesV2(api.APIEndPoint)
+	expectNil(t, err)
+	expect(t, len(result), 2)
+	expect(t, result[0].ContentLength, int64(10))
+}
+
+func TestGetMediaFilesV2Fail(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery:     "/api/v2/users/userId/media",
+		Method:           http.MethodGet,
+		StatusCodeToSend: http.StatusBadRequest}})
+	defer server.Close()
+	shouldFail(t, func() (interface{}, error) { return api.GetMediaFilesV2(api.APIEndPoint) })
+}
+
+func TestDeleteMediaFileV2(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery: "/api/v2/users/userId/media/file%201",
+		Method:       http.MethodDelete}})
+	defer server.Close()
+	expectNil(t, api.DeleteMediaFileV2("file 1", api.APIEndPoint))
+}
+
+func TestUploadMediaFileV2(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery:     "/api/v2/users/userId/media/file1",
+		Method:           http.MethodPut,
+		EstimatedContent: "123",
+		EstimatedHeaders: map[string]string{"Content-Type": "text/plain"}}})
+	defer server.Close()
+	expectNil(t, api.UploadMediaFileV2("file1", bytes.NewReader([]byte("123")), "text/plain", api.APIEndPoint))
+}
+
+func TestUploadMediaFileV2Fail(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery:     "/api/v2/users/userId/media/file1",
+		Method:           http.MethodPut,
+		StatusCodeToSend: http.StatusBadRequest}})
+	defer server.Close()
+	shouldFail(t, func() (interface{}, error) {
+		return nil, api.UploadMediaFileV2("file1", bytes.NewReader([]byte("123")), "", api.APIEndPoint)
+	})
+}
+
+func TestDownloadMediaFileV2(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery:  "/api/v2/users/userId/media/file1",
+		Method:        http.MethodGet,
+		HeadersToSend: map[string]string{"Content-Type": "text/plain"},
+		ContentToSend: "123"}})
+	defer server.Close()
+	r, contentType, err := api.DownloadMediaFileV2("file1", api.APIEndPoint)
+	expectNil(t, err)
+	defer r.Close()
+	expect(t, readText(t, r), "123\n")
+	expect(t, contentType, "text/plain")
+}
+
+func TestDownloadMediaFileV2Fail(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery:     "/api/v2/users/userId/media/file1",
+		Method:           http.MethodGet,
+		StatusCodeToSend: http.StatusNotFound}})
+	defer server.Close()
+	shouldFail(t, func() (interface{}, error) {
+		r, _, err := api.DownloadMediaFileV2("file1", api.APIEndPoint)
+		return r, err
+	})
+}
+
+func TestMediaFileV2URL(t *testing.T) {
+	api := getAPI()
+	expect(t, api.MediaFileV2URL("file 1.jpg"), "https://messaging.bandwidth.com/api/v2/users/userId/media/file%201.jpg")
+}
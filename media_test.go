@@ -4,7 +4,11 @@ import (
 	"net/http"
 	"testing"
 	"bytes"
+	"context"
+	"fmt"
 	"io/ioutil"
+	"strings"
+	"time"
 )
 
 func TestGetMediaFiles(t *testing.T) {
@@ -118,3 +122,100 @@ func TestDownloadMediaFileFail(t *testing.T) {
 		t.Error("Should fail here")
 	}
 }
+
+func TestUploadMedia(t *testing.T) {
+	var contentLength int64
+	var contentType string
+	server, api, log := startMockServerWithLog(t, func(w http.ResponseWriter, r *http.Request, body string) {
+		contentLength, contentType = r.ContentLength, r.Header.Get("Content-Type")
+	})
+	defer server.Close()
+	progress := [][]int64{}
+	content := []byte("\x89PNG\x0D\x0A\x1A\x0A")
+	err := api.UploadMedia(context.Background(), "file1", bytes.NewReader(content), int64(len(content)), &MediaUploadOptions{
+		Progress: func(sent, total int64) { progress = append(progress, []int64{sent, total}) }})
+	expectNil(t, err)
+	expect(t, contentLength, int64(8))
+	expect(t, contentType, "image/png")
+	expect(t, progress[len(progress)-1], []int64{8, 8})
+	expect(t, log(), []string{strings.TrimSpace("PUT /v1/users/userId/media/file1 " + string(content))})
+}
+
+func TestUploadMediaFromFile(t *testing.T) {
+	var contentLength int64
+	var contentType string
+	server, api, log := startMockServerWithLog(t, func(w http.ResponseWriter, r *http.Request, body string) {
+		contentLength, contentType = r.ContentLength, r.Header.Get("Content-Type")
+	})
+	defer server.Close()
+	expectNil(t, api.UploadMediaFromFile(context.Background(), "file1", "test.txt", nil))
+	expect(t, contentLength, int64(4))
+	expect(t, contentType, "text/plain; charset=utf-8")
+	expect(t, log(), []string{"PUT /v1/users/userId/media/file1 1234"})
+	expect(t, api.UploadMediaFromFile(context.Background(), "file1", "unknown.txt", nil) != nil, true)
+}
+
+func TestUploadMediaFileWithUnsupportedContent(t *testing.T) {
+	api := getAPI()
+	err := api.UploadMediaFile("file1", 123)
+	expect(t, err.Error(), "Unsupported media content int (a file path or io.Reader is expected)")
+	expect(t, api.UploadMedia(context.Background(), "file1", nil, 0, nil).Error(), "No content of media file file1")
+}
+
+func TestUploadMediaWithRetry(t *testing.T) {
+	attempts := 0
+	server, api, log := startMockServerWithLog(t, func(w http.ResponseWriter, r *http.Request, body string) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	})
+	defer server.Close()
+	api.RetryPolicy = &RetryPolicy{MaxRetries: 2, Delay: time.Millisecond}
+	expectNil(t, api.UploadMedia(context.Background(), "file1.txt", bytes.NewReader([]byte("123")), 3, nil))
+	expect(t, log(), []string{"PUT /v1/users/userId/media/file1.txt 123", "PUT /v1/users/userId/media/file1.txt 123", "PUT /v1/users/userId/media/file1.txt 123"})
+	// content which can't be read again is not retried
+	attempts = 0
+	err := api.UploadMedia(context.Background(), "file1.txt", ioutil.NopCloser(bytes.NewReader([]byte("123"))), -1, nil)
+	expect(t, err.Error(), "Http code 503")
+	expect(t, attempts, 1)
+}
+
+type countingTransport struct {
+	count int
+}
+
+func (t *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	t.count++
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestDownloadMedia(t *testing.T) {
+	attempts := 0
+	server, api, _ := startMockServerWithLog(t, func(w http.ResponseWriter, r *http.Request, body string) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			fmt.Fprint(w, "Bad gateway")
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Content-Length", "3")
+		fmt.Fprint(w, "123")
+	})
+	defer server.Close()
+	transport := &countingTransport{}
+	api.HTTPClient = &http.Client{Transport: transport}
+	_, err := api.DownloadMedia(context.Background(), "file1")
+	expect(t, err.Error(), "Http code 502: Bad gateway")
+	api.RetryPolicy = &RetryPolicy{MaxRetries: 1}
+	attempts = 0
+	download, err := api.DownloadMedia(context.Background(), "file1")
+	expectNil(t, err)
+	defer download.Body.Close()
+	expect(t, download.ContentType, "text/plain")
+	expect(t, download.ContentLength, int64(3))
+	expect(t, readText(t, download.Body), "123")
+	expect(t, transport.count, 3)
+	expect(t, attempts, 2)
+}
@@ -0,0 +1,72 @@
+package bandwidth
+
+import "fmt"
+
+// OutgoingMessage is a message passed to validators before sending (by CreateMessage(), CreateMessages() and CreateMessageV2())
+type OutgoingMessage struct {
+	From  string
+	To    []string
+	Text  string
+	Media []string
+}
+
+// MessageValidator checks a message before sending
+type MessageValidator interface {
+	// ValidateMessage returns error if the message should not be sent
+	ValidateMessage(message *OutgoingMessage) error
+}
+
+// MessageValidatorFunc allows to use a function as MessageValidator
+type MessageValidatorFunc func(message *OutgoingMessage) error
+
+// ValidateMessage calls f(message)
+func (f MessageValidatorFunc) ValidateMessage(message *OutgoingMessage) error {
+	return f(message)
+}
+
+// recipientList returns list of numbers from "to" field of a message (a number, list of numbers or Recipients)
+// It returns error for other types of recipients
+func recipientList(to interface{}) ([]string, error) {
+	switch v := to.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		if v == "" {
+			return nil, nil
+		}
+		return []string{v}, nil
+	case []string:
+		return v, nil
+	case Recipients:
+		return v.List(), nil
+	case []interface{}:
+		list := []string{}
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("Invalid recipient %v: number should be a string", item)
+			}
+			list = append(list, s)
+		}
+		return list, nil
+	}
+	return nil, fmt.Errorf("Invalid recipients %v: a number or list of numbers is expected", to)
+}
+
+// validateMessage runs MessageValidators of the client
+func (api *Client) validateMessage(from string, to interface{}, text string, media []string) error {
+	if len(api.MessageValidators) == 0 {
+		return nil
+	}
+	recipients, err := recipientList(to)
+	if err != nil {
+		return err
+	}
+	message := &OutgoingMessage{From: from, To: recipients, Text: text, Media: media}
+	for _, validator := range api.MessageValidators {
+		if err := validator.ValidateMessage(message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
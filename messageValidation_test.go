@@ -0,0 +1,63 @@
+package bandwidth
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestRecipientList(t *testing.T) {
+	recipients := func(to interface{}) []string {
+		list, err := recipientList(to)
+		expectNil(t, err)
+		return list
+	}
+	expect(t, len(recipients("")), 0)
+	expect(t, len(recipients(nil)), 0)
+	expect(t, recipients("+19195551212"), []string{"+19195551212"})
+	expect(t, recipients([]string{"+19195551212", "+19195551213"}), []string{"+19195551212", "+19195551213"})
+	expect(t, recipients([]interface{}{"+19195551212"}), []string{"+19195551212"})
+	_, err := recipientList([]interface{}{"+19195551212", 1})
+	expect(t, err.Error(), "Invalid recipient 1: number should be a string")
+	_, err = recipientList(19195551212)
+	expect(t, err.Error(), "Invalid recipients 19195551212: a number or list of numbers is expected")
+}
+
+func TestMessageValidators(t *testing.T) {
+	server, api, log := startMockServerWithLog(t, func(w http.ResponseWriter, r *http.Request, body string) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	defer server.Close()
+	var messages []*OutgoingMessage
+	api.MessageValidators = []MessageValidator{
+		MessageValidatorFunc(func(message *OutgoingMessage) error {
+			messages = append(messages, message)
+			return nil
+		}),
+		MessageValidatorFunc(func(message *OutgoingMessage) error {
+			if message.Text == "bad" {
+				return errors.New("bad text")
+			}
+			return nil
+		}),
+	}
+	shouldFail(t, func() (interface{}, error) {
+		return api.CreateMessage(&CreateMessageData{From: "+19195551212", To: "+19195551213", Text: "bad"})
+	})
+	shouldFail(t, func() (interface{}, error) {
+		return api.CreateMessages(&CreateMessageData{Text: "good"}, &CreateMessageData{Text: "bad"})
+	})
+	shouldFail(t, func() (interface{}, error) {
+		return api.CreateMessageV2(&CreateMessageDataV2{From: "+19195551212", To: NewRecipients("+19195551213", "+19195551214"), Text: "bad", Media: []string{"url"}}, api.APIEndPoint)
+	})
+	expect(t, len(log()), 0)
+	expect(t, messages, []*OutgoingMessage{
+		&OutgoingMessage{From: "+19195551212", To: []string{"+19195551213"}, Text: "bad"},
+		&OutgoingMessage{Text: "good"},
+		&OutgoingMessage{Text: "bad"},
+		&OutgoingMessage{From: "+19195551212", To: []string{"+19195551213", "+19195551214"}, Text: "bad", Media: []string{"url"}},
+	})
+	_, err := api.CreateMessage(&CreateMessageData{From: "+19195551212", To: "+19195551213", Text: "good"})
+	expectNil(t, err)
+	expect(t, len(log()), 1)
+}
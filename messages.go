@@ -80,6 +80,12 @@ func (api *Client) GetMessages(query ...*GetMessagesQuery) ([]*Message, error) {
 // CreateMessage sends a message (SMS/MMS)
 // It returns ID of created message or error
 func (api *Client) CreateMessage(data *CreateMessageData) (string, error) {
+	if err := api.checkNumbers(data.From, data.To); err != nil {
+		return "", err
+	}
+	if err := api.validateMessage(data.From, data.To, data.Text, data.Media); err != nil {
+		return "", err
+	}
 	_, headers, err := api.makeRequest(http.MethodPost, api.concatUserPath(messagesPath), nil, data)
 	if err != nil {
 		return "", err
@@ -90,6 +96,14 @@ func (api *Client) CreateMessage(data *CreateMessageData) (string, error) {
 // CreateMessages sends some messages (SMS/MMS)
 // It statuses of created messages or error
 func (api *Client) CreateMessages(data ...*CreateMessageData) ([]*CreateMessageResult, error) {
+	for _, item := range data {
+		if err := api.checkNumbers(item.From, item.To); err != nil {
+			return nil, err
+		}
+		if err := api.validateMessage(item.From, item.To, item.Text, item.Media); err != nil {
+			return nil, err
+		}
+	}
 	result, _, err := api.makeRequest(http.MethodPost, api.concatUserPath(messagesPath), &[]*CreateMessageResult{}, data)
 	if err != nil {
 		return nil, err
@@ -5,17 +5,39 @@ import (
 	"time"
 )
 
+// MessagingV2EndPoint is default endpoint of messaging API v2
+const MessagingV2EndPoint = "https://messaging.bandwidth.com"
+
+const (
+	// MessagePriorityDefault is default priority of v2 messages
+	MessagePriorityDefault = "default"
+	// MessagePriorityHigh is priority of time-sensitive v2 messages
+	MessagePriorityHigh = "high"
+)
+
+// messagingEndPoint returns optional endpoint passed to v2 messaging functions
+func messagingEndPoint(other []string) string {
+	if len(other) > 0 && other[0] != "" {
+		return other[0]
+	}
+	return MessagingV2EndPoint
+}
+
 // CreateMessageDataV2 struct
+// To is a number or several numbers (see NewRecipients()). A message with several recipients is sent as group message.
 type CreateMessageDataV2 struct {
 	From          string      `json:"from,omitempty"`
-	To            interface{} `json:"to,omitempty"`
+	To            Recipients  `json:"to,omitempty"`
 	Text          string      `json:"text,omitempty"`
 	Media         []string    `json:"media,omitempty"`
 	ApplicationID string      `json:"applicationId,omitempty"`
 	Tag           string      `json:"tag,omitempty"`
+	Priority      string      `json:"priority,omitempty"`
+	Expiration    *time.Time  `json:"expiration,omitempty"`
 }
 
 // CreateMessageResultV2 stores status of sent message
+// To is a number (string) or list of numbers ([]interface{}), use Recipients() to get them as []string
 type CreateMessageResultV2 struct {
 	ID            string      `json:"id"`
 	Time          *time.Time  `json:"time,string"`
@@ -27,21 +49,131 @@ type CreateMessageResultV2 struct {
 	Tag           string      `json:"tag"`
 	Direction     string      `json:"direction"`
 	SegmentCount  int32       `json:"segmentCount"`
+	Priority      string      `json:"priority"`
+	Expiration    *time.Time  `json:"expiration"`
+}
+
+// Recipients returns list of recipients of the message
+func (m *CreateMessageResultV2) Recipients() []string {
+	list, _ := recipientList(m.To)
+	return list
+}
+
+// IsGroup returns true for group messages (with several recipients)
+func (m *CreateMessageResultV2) IsGroup() bool {
+	return len(m.Recipients()) > 1
 }
 
 // CreateMessageV2 sends a message (SMS/MMS)
+// Optional argument is messaging endpoint (MessagingV2EndPoint by default)
+// example: message, err := api.CreateMessageV2(&bandwidth.CreateMessageDataV2{From: "+19195551212", To: bandwidth.NewRecipients("+19195551213", "+19195551214"), Text: "Hi all", ApplicationID: "appId"})
 func (api *Client) CreateMessageV2(data *CreateMessageDataV2, other ...string) (*CreateMessageResultV2, error) {
-    var v2_endpoint = "https://messaging.bandwidth.com"
-    if len(other) > 0 {
-        v2_endpoint = other[0]
-    }
-
-    var currentAPIEndPoint = api.APIEndPoint
-    api.APIEndPoint = v2_endpoint
-	result, _, err := api.makeRequestV2(http.MethodPost, api.concatUserPath(messagesPath), &CreateMessageResultV2{}, data)
-    api.APIEndPoint = currentAPIEndPoint
+	if err := api.checkNumbers(data.From); err != nil {
+		return nil, err
+	}
+	to := NewRecipients(data.To.List()...)
+	if err := to.Validate(); err != nil {
+		return nil, err
+	}
+	if err := api.checkRecipients(to); err != nil {
+		return nil, err
+	}
+	if err := api.validateMessage(data.From, to, data.Text, data.Media); err != nil {
+		return nil, err
+	}
+	message := *data
+	message.To = to
+	result, _, err := api.makeRequestV2ToEndPoint(messagingEndPoint(other), http.MethodPost, api.concatUserPath(messagesPath), &CreateMessageResultV2{}, &message)
 	if err != nil {
 		return nil, err
 	}
 	return result.(*CreateMessageResultV2), nil
 }
+
+// GetMessagesQueryV2 is optional parameters of GetMessagesV2()
+// FromDateTime and ToDateTime are in ISO 8601 format
+type GetMessagesQueryV2 struct {
+	MessageID        string
+	SourceTn         string
+	DestinationTn    string
+	MessageStatus    string
+	MessageDirection string
+	ErrorCode        int
+	CarrierName      string
+	FromDateTime     string
+	ToDateTime       string
+	PageToken        string
+	Limit            int
+}
+
+// MessageV2 struct
+type MessageV2 struct {
+	MessageID        string     `json:"messageId"`
+	AccountID        string     `json:"accountId"`
+	SourceTn         string     `json:"sourceTn"`
+	DestinationTn    string     `json:"destinationTn"`
+	MessageStatus    string     `json:"messageStatus"`
+	MessageDirection string     `json:"messageDirection"`
+	MessageType      string     `json:"messageType"`
+	SegmentCount     int        `json:"segmentCount"`
+	ErrorCode        int        `json:"errorCode"`
+	ReceiveTime      *time.Time `json:"receiveTime"`
+	CarrierName      string     `json:"carrierName"`
+}
+
+// PageInfoV2 contains cursors of pages of v2 list
+type PageInfoV2 struct {
+	PrevPage      string `json:"prevPage"`
+	NextPage      string `json:"nextPage"`
+	PrevPageToken string `json:"prevPageToken"`
+	NextPageToken string `json:"nextPageToken"`
+}
+
+// MessagesPageV2 is a page of messages returned by GetMessagesV2()
+type MessagesPageV2 struct {
+	TotalCount int          `json:"totalCount"`
+	PageInfo   *PageInfoV2  `json:"pageInfo"`
+	Messages   []*MessageV2 `json:"messages"`
+}
+
+// NextPageToken returns token of next page (empty for last page)
+func (p *MessagesPageV2) NextPageToken() string {
+	if p.PageInfo == nil || len(p.Messages) == 0 {
+		return ""
+	}
+	return p.PageInfo.NextPageToken
+}
+
+// GetMessagesV2 searches messages
+// It returns a page of messages or error. Pass NextPageToken() of the page as PageToken to get next page.
+// Optional argument is messaging endpoint (MessagingV2EndPoint by default)
+// example: page, err := api.GetMessagesV2(&bandwidth.GetMessagesQueryV2{SourceTn: "+19195551212", MessageStatus: "FAILED"})
+func (api *Client) GetMessagesV2(query *GetMessagesQueryV2, other ...string) (*MessagesPageV2, error) {
+	result, _, err := api.makeRequestV2ToEndPoint(messagingEndPoint(other), http.MethodGet, api.concatUserPath(messagesPath), &MessagesPageV2{}, query)
+	if err != nil {
+		return nil, err
+	}
+	return result.(*MessagesPageV2), nil
+}
+
+// GetAllMessagesV2 searches messages and requests all pages
+// It returns list of messages or error
+func (api *Client) GetAllMessagesV2(query *GetMessagesQueryV2, other ...string) ([]*MessageV2, error) {
+	options := GetMessagesQueryV2{}
+	if query != nil {
+		options = *query
+	}
+	list := []*MessageV2{}
+	for {
+		page, err := api.GetMessagesV2(&options, other...)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, page.Messages...)
+		token := page.NextPageToken()
+		if token == "" || token == options.PageToken {
+			return list, nil
+		}
+		options.PageToken = token
+	}
+}
@@ -3,6 +3,7 @@ package bandwidth
 import (
 	"net/http"
 	"testing"
+	"time"
 )
 
 func TestCreateMessageV2(t *testing.T) {
@@ -51,3 +52,91 @@ func TestCreateMessageV2Fail(t *testing.T) {
 		return api.CreateMessageV2(&CreateMessageDataV2{From: "fromNumber", To: "toNumber", Text: "text"})
 	})
 }
+
+func TestCreateMessageV2KeepsAPIEndPoint(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery:  "/api/v2/users/userId/messages",
+		Method:        http.MethodPost,
+		ContentToSend: `{"id":"123"}`}})
+	defer server.Close()
+	endPoint := api.APIEndPoint
+	api.APIEndPoint = "http://localhost:1"
+	message, err := api.CreateMessageV2(&CreateMessageDataV2{From: "fromNumber", To: "toNumber", Text: "text"}, endPoint)
+	expectNil(t, err)
+	expect(t, message.ID, "123")
+	expect(t, api.APIEndPoint, "http://localhost:1")
+}
+
+func TestCreateMessageV2Group(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery:     "/api/v2/users/userId/messages",
+		Method:           http.MethodPost,
+		EstimatedContent: `{"from":"+12345678901","to":["+12345678902","+12345678903"],"text":"text","priority":"high","expiration":"2021-01-01T10:00:00Z"}`,
+		ContentToSend:    `{"id":"123","to":["+12345678902","+12345678903"],"priority":"high"}`}})
+	defer server.Close()
+	expiration := time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC)
+	message, err := api.CreateMessageV2(&CreateMessageDataV2{From: "+12345678901", To: NewRecipients("+12345678902", "+12345678903"), Text: "text",
+		Priority: MessagePriorityHigh, Expiration: &expiration}, api.APIEndPoint)
+	expectNil(t, err)
+	expect(t, message.Recipients(), []string{"+12345678902", "+12345678903"})
+	expect(t, message.IsGroup(), true)
+	expect(t, message.Priority, "high")
+}
+
+func TestGetMessagesV2(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery: "/api/v2/users/userId/messages?errorCode=4720&messageDirection=OUTBOUND&sourceTn=%2B12345678901",
+		Method:       http.MethodGet,
+		ContentToSend: `{
+			"totalCount": 1,
+			"pageInfo": {"nextPageToken": "token"},
+			"messages": [{
+				"messageId": "1589228074636lm4k2je7j7jklbn2",
+				"sourceTn": "+12345678901",
+				"destinationTn": "+12345678902",
+				"messageStatus": "FAILED",
+				"messageDirection": "OUTBOUND",
+				"messageType": "sms",
+				"segmentCount": 1,
+				"errorCode": 4720,
+				"receiveTime": "2020-04-07T14:03:07.000Z",
+				"carrierName": "other"
+			}]}`}})
+	defer server.Close()
+	page, err := api.GetMessagesV2(&GetMessagesQueryV2{SourceTn: "+12345678901", MessageDirection: "OUTBOUND", ErrorCode: 4720}, api.APIEndPoint)
+	expectNil(t, err)
+	expect(t, page.TotalCount, 1)
+	expect(t, page.NextPageToken(), "token")
+	expect(t, page.Messages[0].MessageStatus, "FAILED")
+	expect(t, page.Messages[0].ReceiveTime.Year(), 2020)
+}
+
+func TestGetMessagesV2Fail(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery:     "/api/v2/users/userId/messages",
+		Method:           http.MethodGet,
+		StatusCodeToSend: http.StatusBadRequest}})
+	defer server.Close()
+	shouldFail(t, func() (interface{}, error) { return api.GetMessagesV2(nil, api.APIEndPoint) })
+}
+
+func TestGetAllMessagesV2(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{
+		RequestHandler{
+			PathAndQuery:  "/api/v2/users/userId/messages?limit=1",
+			Method:        http.MethodGet,
+			ContentToSend: `{"totalCount":2,"pageInfo":{"nextPageToken":"page2"},"messages":[{"messageId":"1"}]}`},
+		RequestHandler{
+			PathAndQuery:  "/api/v2/users/userId/messages?limit=1&pageToken=page2",
+			Method:        http.MethodGet,
+			ContentToSend: `{"totalCount":2,"pageInfo":{"nextPageToken":"page3"},"messages":[{"messageId":"2"}]}`},
+		RequestHandler{
+			PathAndQuery:  "/api/v2/users/userId/messages?limit=1&pageToken=page3",
+			Method:        http.MethodGet,
+			ContentToSend: `{"totalCount":2,"pageInfo":{},"messages":[]}`}})
+	defer server.Close()
+	list, err := api.GetAllMessagesV2(&GetMessagesQueryV2{Limit: 1}, api.APIEndPoint)
+	expectNil(t, err)
+	expect(t, len(list), 2)
+	expect(t, list[1].MessageID, "2")
+}
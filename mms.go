@@ -0,0 +1,407 @@
+package bandwidth
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMMSMaxSize is default limit of total size of MMS attachments (3.5 MB)
+const DefaultMMSMaxSize = 3500 * 1024
+
+// defaultMMSCleanupTimeout limits waiting for delivery of MMS with tracker without Timeout
+const defaultMMSCleanupTimeout = 24 * time.Hour
+
+// ErrMMSSenderClosed is returned when MMS with DeleteAfterDelivery is sent after MMSSender.Close()
+var ErrMMSSenderClosed = errors.New("MMS sender is closed")
+
+// DefaultMMSContentTypes is default list of content types allowed as MMS attachments
+var DefaultMMSContentTypes = []string{
+	"image/jpeg", "image/png", "image/gif", "image/bmp",
+	"video/mp4", "video/3gpp", "video/mpeg",
+	"audio/mpeg", "audio/mp4", "audio/amr", "audio/3gpp", "audio/wav",
+	"text/plain", "text/vcard", "text/x-vcard", "text/calendar", "application/pdf",
+}
+
+// MMSAttachment is a file attached to MMS
+// Use MMSFile() for local files and MMSReader() for other content.
+type MMSAttachment struct {
+	// Name is original name of the attachment (used to build name of uploaded media file and to detect content type)
+	Name string
+	// Path is path of local file (Reader is ignored if it is set)
+	Path string
+	// Reader is content of the attachment
+	Reader io.Reader
+	// Size is size of content of Reader (it is read to memory to check the size if it is 0)
+	Size int64
+	// ContentType is content type of the attachment (detected by name or content if it is empty)
+	ContentType string
+}
+
+// MMSFile creates an attachment from a local file
+// example: api.SendMMS(ctx, from, to, "Look", bandwidth.MMSFile("/path/to/photo.jpg"))
+func MMSFile(path string, contentType ...string) *MMSAttachment {
+	attachment := &MMSAttachment{Name: filepath.Base(path), Path: path}
+	if len(contentType) > 0 {
+		attachment.ContentType = contentType[0]
+	}
+	return attachment
+}
+
+// MMSReader creates an attachment from io.Reader
+// example: api.SendMMS(ctx, from, to, "Look", bandwidth.MMSReader("photo.jpg", reader, "image/jpeg"))
+func MMSReader(name string, r io.Reader, contentType ...string) *MMSAttachment {
+	attachment := &MMSAttachment{Name: name, Reader: r}
+	if len(contentType) > 0 {
+		attachment.ContentType = contentType[0]
+	}
+	return attachment
+}
+
+// MMSAttachmentError is returned when an attachment can't be sent
+type MMSAttachmentError struct {
+	Name   string
+	Reason string
+}
+
+func (e *MMSAttachmentError) Error() string {
+	return fmt.Sprintf("Invalid MMS attachment %s: %s", e.Name, e.Reason)
+}
+
+// MMSResult is result of sending MMS
+type MMSResult struct {
+	MessageID string
+	// Media is list of names of uploaded media files
+	Media []string
+	// MediaURLs is list of URLs of uploaded media files used as media of the message
+	MediaURLs []string
+	// Cleanup receives result of deleting media files after delivery (nil if DeleteAfterDelivery is not set)
+	// Media files are kept (and an error is received) if the message has no delivery receipt until the tracker's timeout.
+	Cleanup <-chan error
+}
+
+// MMSSender uploads attachments to Bandwidth media and sends MMS with them
+type MMSSender struct {
+	API *Client
+	// UseV2 sends messages via messaging API v2 (v1 is used by default)
+	UseV2 bool
+	// V2EndPoint is optional messaging endpoint of API v2
+	V2EndPoint string
+	// ApplicationID is application of v2 messages
+	ApplicationID string
+	// MaxTotalSize is limit of total size of attachments (0 means no limit)
+	MaxTotalSize int64
+	// ContentTypes is list of allowed content types (empty list allows any type)
+	ContentTypes []string
+	// DeleteAfterDelivery removes uploaded media after the message is delivered or failed (see Tracker)
+	DeleteAfterDelivery bool
+	// Tracker is used to wait for delivery of messages if DeleteAfterDelivery is set (pass delivery callbacks to it to avoid polling)
+	// Messages are forgotten by the tracker when their media are cleaned up.
+	Tracker *DeliveryTracker
+
+	mutex   sync.Mutex
+	closed  bool
+	ctx     context.Context
+	cancel  func()
+	running sync.WaitGroup
+}
+
+// NewMMSSender creates new MMSSender instance with default limits
+func NewMMSSender(api *Client) *MMSSender {
+	return &MMSSender{
+		API:          api,
+		MaxTotalSize: DefaultMMSMaxSize,
+		ContentTypes: DefaultMMSContentTypes,
+		Tracker:      NewDeliveryTracker(api),
+	}
+}
+
+// SendMMS uploads attachments and sends MMS via v1 API with default limits (see MMSSender for other options)
+// It returns MMSResult instance or error
+// example: result, err := api.SendMMS(ctx, "+19195551212", "+19195551213", "Look", bandwidth.MMSFile("/path/to/photo.jpg"))
+func (api *Client) SendMMS(ctx context.Context, from string, to interface{}, text string, attachments ...*MMSAttachment) (*MMSResult, error) {
+	return NewMMSSender(api).Send(ctx, from, to, text, attachments...)
+}
+
+type preparedAttachment struct {
+	name        string
+	contentType string
+	size        int64
+	content     io.Reader
+	closer      io.Closer
+}
+
+func (a *preparedAttachment) close() {
+	if a.closer != nil {
+		a.closer.Close()
+	}
+}
+
+var mediaNameUnsafeChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// newMediaName returns unique name of media file for an attachment
+func newMediaName(name string) (string, error) {
+	random := make([]byte, 12)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	name = strings.Trim(mediaNameUnsafeChars.ReplaceAllString(filepath.Base(name), "_"), "_.")
+	if name == "" {
+		return hex.EncodeToString(random), nil
+	}
+	return hex.EncodeToString(random) + "-" + name, nil
+}
+
+func (s *MMSSender) isAllowedContentType(contentType string) bool {
+	if len(s.ContentTypes) == 0 {
+		return true
+	}
+	for _, t := range s.ContentTypes {
+		if t == contentType {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MMSSender) prepare(attachment *MMSAttachment) (*preparedAttachment, error) {
+	prepared := &preparedAttachment{contentType: attachment.ContentType, size: attachment.Size, content: attachment.Reader}
+	name := attachment.Name
+	if attachment.Path != "" {
+		file, err := os.Open(attachment.Path)
+		if err != nil {
+			return nil, err
+		}
+		info, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		if name == "" {
+			name = filepath.Base(attachment.Path)
+		}
+		prepared.content, prepared.closer, prepared.size = file, file, info.Size()
+	}
+	if prepared.content == nil {
+		return nil, &MMSAttachmentError{Name: name, Reason: "no content"}
+	}
+	if prepared.size <= 0 {
+		// content of unknown size is read to memory (but not more than the limit)
+		reader := prepared.content
+		if s.MaxTotalSize > 0 {
+			reader = io.LimitReader(reader, s.MaxTotalSize+1)
+		}
+		data, err := ioutil.ReadAll(reader)
+		if err != nil {
+			prepared.close()
+			return nil, err
+		}
+		prepared.content, prepared.size = bytes.NewReader(data), int64(len(data))
+	}
+	if prepared.contentType == "" {
+		var err error
+		if prepared.contentType, prepared.content, err = sniffContentType(name, prepared.content); err != nil {
+			prepared.close()
+			return nil, err
+		}
+	}
+	if mediaType, _, err := mime.ParseMediaType(prepared.contentType); err == nil {
+		prepared.contentType = mediaType
+	}
+	if !s.isAllowedContentType(prepared.contentType) {
+		prepared.close()
+		return nil, &MMSAttachmentError{Name: name, Reason: fmt.Sprintf("content type %s is not allowed", prepared.contentType)}
+	}
+	var err error
+	if prepared.name, err = newMediaName(name); err != nil {
+		prepared.close()
+		return nil, err
+	}
+	return prepared, nil
+}
+
+func (s *MMSSender) deleteMedia(names []string) error {
+	var result error
+	for _, name := range names {
+		var err error
+		if s.UseV2 {
+			err = s.API.DeleteMediaFileV2(name, s.V2EndPoint)
+		} else {
+			err = s.API.DeleteMediaFile(name)
+		}
+		if err != nil && result == nil {
+			result = err
+		}
+	}
+	return result
+}
+
+// Send validates and uploads attachments and sends MMS with them
+// Uploaded media files are removed if the message can't be sent.
+// to is a number (v2 API allows list of numbers for group messages)
+// It returns MMSResult instance or error
+func (s *MMSSender) Send(ctx context.Context, from string, to interface{}, text string, attachments ...*MMSAttachment) (*MMSResult, error) {
+	recipients, err := recipientList(to)
+	if err != nil {
+		return nil, err
+	}
+	if !s.UseV2 && len(recipients) != 1 {
+		return nil, errors.New("v1 API allows only one recipient of MMS")
+	}
+	if s.DeleteAfterDelivery && s.isClosed() {
+		return nil, ErrMMSSenderClosed
+	}
+	prepared := []*preparedAttachment{}
+	defer func() {
+		for _, p := range prepared {
+			p.close()
+		}
+	}()
+	var total int64
+	for _, attachment := range attachments {
+		p, err := s.prepare(attachment)
+		if err != nil {
+			return nil, err
+		}
+		prepared = append(prepared, p)
+		total += p.size
+		if s.MaxTotalSize > 0 && total > s.MaxTotalSize {
+			return nil, &MMSAttachmentError{Name: attachment.Name, Reason: fmt.Sprintf("total size of attachments exceeds %d bytes", s.MaxTotalSize)}
+		}
+	}
+	result := &MMSResult{Media: []string{}, MediaURLs: []string{}}
+	for _, p := range prepared {
+		if err := ctx.Err(); err != nil {
+			s.deleteMedia(result.Media)
+			return nil, err
+		}
+		var err error
+		options := &MediaUploadOptions{ContentType: p.contentType}
+		if s.UseV2 {
+			err = s.API.uploadMedia(ctx, messagingEndPoint([]string{s.V2EndPoint}), "v2", s.API.mediaV2Path(p.name), p.name, p.content, p.size, options)
+		} else {
+			err = s.API.UploadMedia(ctx, p.name, p.content, p.size, options)
+		}
+		if err != nil {
+			s.deleteMedia(result.Media)
+			return nil, err
+		}
+		result.Media = append(result.Media, p.name)
+		if s.UseV2 {
+			result.MediaURLs = append(result.MediaURLs, s.API.MediaFileV2URL(p.name, s.V2EndPoint))
+		} else {
+			result.MediaURLs = append(result.MediaURLs, s.API.prepareURL(fmt.Sprintf("%s/%s", s.API.concatUserPath(mediaPath), p.name), "v1"))
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		s.deleteMedia(result.Media)
+		return nil, err
+	}
+	var tracker *DeliveryTracker
+	if s.DeleteAfterDelivery {
+		tracker = s.Tracker
+		if tracker == nil {
+			tracker = NewDeliveryTracker(s.API)
+		}
+	}
+	if s.UseV2 {
+		data := &CreateMessageDataV2{From: from, To: NewRecipients(recipients...), Text: text, Media: result.MediaURLs, ApplicationID: s.ApplicationID}
+		var message *CreateMessageResultV2
+		if tracker != nil {
+			message, err = tracker.CreateMessageV2(data, s.V2EndPoint)
+		} else {
+			message, err = s.API.CreateMessageV2(data, s.V2EndPoint)
+		}
+		if err == nil {
+			result.MessageID = message.ID
+		}
+	} else {
+		data := &CreateMessageData{From: from, To: recipients[0], Text: text, Media: result.MediaURLs}
+		if tracker != nil {
+			result.MessageID, err = tracker.CreateMessage(data)
+		} else {
+			result.MessageID, err = s.API.CreateMessage(data)
+		}
+	}
+	if err != nil {
+		s.deleteMedia(result.Media)
+		return nil, err
+	}
+	if tracker != nil {
+		s.cleanupInBackground(tracker, result)
+	}
+	return result, nil
+}
+
+func (s *MMSSender) isClosed() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.closed
+}
+
+func (s *MMSSender) cleanupInBackground(tracker *DeliveryTracker, result *MMSResult) {
+	cleanup := make(chan error, 1)
+	result.Cleanup = cleanup
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.ctx == nil {
+		s.ctx, s.cancel = context.WithCancel(context.Background())
+	}
+	if s.closed {
+		// Close() has been called while the message was being sent
+		tracker.Forget(result.MessageID)
+		cleanup <- ErrMMSSenderClosed
+		return
+	}
+	base := s.ctx
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		cleanup <- s.cleanupMedia(base, tracker, result)
+	}()
+}
+
+// Close stops waiting for delivery of sent messages and waits until cleanup goroutines finish
+// Media of undelivered messages are kept (their Cleanup receives context.Canceled).
+func (s *MMSSender) Close() {
+	s.mutex.Lock()
+	s.closed = true
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.mutex.Unlock()
+	s.running.Wait()
+}
+
+// cleanupMedia waits for delivery of the message (up to Timeout of the tracker) and deletes its media
+// Media are kept if delivery status is unknown because the message can be still delivered.
+// The message is forgotten by the tracker after that.
+func (s *MMSSender) cleanupMedia(ctx context.Context, tracker *DeliveryTracker, result *MMSResult) error {
+	defer tracker.Forget(result.MessageID)
+	if tracker.Timeout <= 0 {
+		// Wait() is limited by Timeout of the tracker only
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultMMSCleanupTimeout)
+		defer cancel()
+	}
+	delivery, err := tracker.Wait(ctx, result.MessageID)
+	if err != nil {
+		return err
+	}
+	if delivery.Status != DeliveryStatusDelivered && delivery.Status != DeliveryStatusFailed {
+		return fmt.Errorf("Message %s has delivery status %s, media files are kept", result.MessageID, delivery.Status)
+	}
+	return s.deleteMedia(result.Media)
+}
@@ -0,0 +1,159 @@
+package bandwidth
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func startMMSMockServer(t *testing.T, failMessages bool) (*httptest.Server, *MMSSender, func() []string) {
+	handlers := []RequestHandler{
+		RequestHandler{
+			PathAndQuery:     "/v1/users/userId/messages",
+			Method:           http.MethodPost,
+			HeadersToSend:    map[string]string{"Location": "/v1/users/userId/messages/m1"},
+			StatusCodeToSend: http.StatusCreated},
+		RequestHandler{
+			PathAndQuery:  "/api/v2/users/userId/messages",
+			Method:        http.MethodPost,
+			ContentToSend: `{"id":"m1"}`}}
+	if failMessages {
+		handlers = []RequestHandler{
+			RequestHandler{PathAndQuery: "/v1/users/userId/messages", Method: http.MethodPost, StatusCodeToSend: http.StatusBadRequest},
+			RequestHandler{PathAndQuery: "/api/v2/users/userId/messages", Method: http.MethodPost, StatusCodeToSend: http.StatusBadRequest}}
+	}
+	// uploaded media files have random names
+	server, api, log := startMockServerWithHandlersAndLog(t, handlers, http.StatusOK)
+	sender := NewMMSSender(api)
+	sender.V2EndPoint = api.APIEndPoint
+	sender.Tracker.PollInterval = 0
+	return server, sender, log
+}
+
+var mediaNamePattern = regexp.MustCompile(`[0-9a-f]{24}-`)
+
+func normalizeMediaNames(log []string) []string {
+	list := []string{}
+	for _, line := range log {
+		list = append(list, mediaNamePattern.ReplaceAllString(line, "ID-"))
+	}
+	return list
+}
+
+func TestSendMMS(t *testing.T) {
+	server, sender, log := startMMSMockServer(t, false)
+	defer server.Close()
+	url := server.URL
+	result, err := sender.Send(context.Background(), "+19195550001", "+19195551111", "Look",
+		MMSFile("test.txt"), MMSReader("my photo.png", bytes.NewReader([]byte("png"))), MMSReader("", bytes.NewReader([]byte("GIF89a..."))))
+	expectNil(t, err)
+	expect(t, result.MessageID, "m1")
+	expect(t, len(result.Media), 3)
+	expect(t, result.Cleanup == nil, true)
+	expect(t, result.Media[0] != result.Media[1], true)
+	expect(t, normalizeMediaNames(log()), []string{
+		"PUT /v1/users/userId/media/ID-test.txt 1234",
+		"PUT /v1/users/userId/media/ID-my_photo.png png",
+		"PUT /v1/users/userId/media/" + result.Media[2][:24] + " GIF89a...",
+		fmt.Sprintf(`POST /v1/users/userId/messages {"from":"+19195550001","to":"+19195551111","text":"Look","media":["%[1]s/v1/users/userId/media/ID-test.txt","%[1]s/v1/users/userId/media/ID-my_photo.png","%[1]s/v1/users/userId/media/%[2]s"]}`, url, result.Media[2]),
+	})
+}
+
+func TestSendMMSV2WithDeleteAfterDelivery(t *testing.T) {
+	server, sender, log := startMMSMockServer(t, false)
+	defer server.Close()
+	url := server.URL
+	sender.UseV2 = true
+	sender.DeleteAfterDelivery = true
+	result, err := sender.Send(context.Background(), "+19195550001", []string{"+19195551111", "+19195552222"}, "Look", MMSReader("a.jpg", bytes.NewReader([]byte("jpg"))))
+	expectNil(t, err)
+	sender.Tracker.HandleCallbacksV2([]*MessageCallbackV2{&MessageCallbackV2{Type: MessageCallbackV2Delivered, Message: &CreateMessageResultV2{ID: "m1"}}})
+	expectNil(t, <-result.Cleanup)
+	_, ok := sender.Tracker.Delivery("m1")
+	expect(t, ok, false)
+	expect(t, normalizeMediaNames(log()), []string{
+		"PUT /api/v2/users/userId/media/ID-a.jpg jpg",
+		`POST /api/v2/users/userId/messages {"from":"+19195550001","to":["+19195551111","+19195552222"],"text":"Look","media":["` + url + `/api/v2/users/userId/media/ID-a.jpg"]}`,
+		"DELETE /api/v2/users/userId/media/ID-a.jpg",
+	})
+}
+
+func TestSendMMSFail(t *testing.T) {
+	server, sender, log := startMMSMockServer(t, true)
+	defer server.Close()
+	shouldFail(t, func() (interface{}, error) {
+		return sender.Send(context.Background(), "+19195550001", "+19195551111", "Look", MMSFile("test.txt"))
+	})
+	list := normalizeMediaNames(log())
+	expect(t, len(list), 3)
+	expect(t, list[0], "PUT /v1/users/userId/media/ID-test.txt 1234")
+	expect(t, strings.HasPrefix(list[1], "POST /v1/users/userId/messages "), true)
+	expect(t, list[2], "DELETE /v1/users/userId/media/ID-test.txt")
+}
+
+func TestSendMMSValidation(t *testing.T) {
+	server, sender, log := startMMSMockServer(t, false)
+	defer server.Close()
+	sender.MaxTotalSize = 5
+	send := func(to interface{}, attachments ...*MMSAttachment) error {
+		_, err := sender.Send(context.Background(), "+19195550001", to, "Look", attachments...)
+		return err
+	}
+	err := send("+19195551111", MMSReader("a.exe", bytes.NewReader([]byte("MZ")), "application/x-msdownload"))
+	expect(t, err.Error(), "Invalid MMS attachment a.exe: content type application/x-msdownload is not allowed")
+	err = send("+19195551111", MMSFile("test.txt"), MMSReader("b.txt", bytes.NewReader([]byte("56"))))
+	expect(t, err.Error(), "Invalid MMS attachment b.txt: total size of attachments exceeds 5 bytes")
+	_, ok := send("+19195551111", MMSReader("c.txt", nil)).(*MMSAttachmentError)
+	expect(t, ok, true)
+	expect(t, send("+19195551111", MMSFile("unknown.txt")) != nil, true)
+	expect(t, send([]string{"+19195551111", "+19195552222"}, MMSFile("test.txt")).Error(), "v1 API allows only one recipient of MMS")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = sender.Send(ctx, "+19195550001", "+19195551111", "Look", MMSFile("test.txt"))
+	expect(t, err, context.Canceled)
+	expect(t, len(log()), 0)
+}
+
+func TestMMSSenderClose(t *testing.T) {
+	server, sender, log := startMMSMockServer(t, false)
+	defer server.Close()
+	sender.DeleteAfterDelivery = true
+	sender.Tracker.Timeout = 0
+	result, err := sender.Send(context.Background(), "+19195550001", "+19195551111", "Look", MMSReader("a.jpg", bytes.NewReader([]byte("jpg"))))
+	expectNil(t, err)
+	sender.Close()
+	expect(t, <-result.Cleanup, context.Canceled)
+	_, ok := sender.Tracker.Delivery("m1")
+	expect(t, ok, false)
+	count := len(log())
+	_, err = sender.Send(context.Background(), "+19195550001", "+19195551111", "Look", MMSReader("a.jpg", bytes.NewReader([]byte("jpg"))))
+	expect(t, err, ErrMMSSenderClosed)
+	expect(t, len(log()), count)
+	for _, line := range log() {
+		if strings.HasPrefix(line, http.MethodDelete) {
+			t.Errorf("Unexpected request %s", line)
+		}
+	}
+}
+
+func TestSendMMSWithDeleteAfterDeliveryTimeout(t *testing.T) {
+	server, sender, log := startMMSMockServer(t, false)
+	defer server.Close()
+	sender.DeleteAfterDelivery = true
+	sender.Tracker.PollInterval = 0
+	sender.Tracker.Timeout = time.Millisecond
+	result, err := sender.Send(context.Background(), "+19195550001", "+19195551111", "Look", MMSReader("a.jpg", bytes.NewReader([]byte("jpg"))))
+	expectNil(t, err)
+	expect(t, (<-result.Cleanup).Error(), "Message m1 has delivery status timeout, media files are kept")
+	for _, line := range log() {
+		if strings.HasPrefix(line, http.MethodDelete) {
+			t.Errorf("Unexpected request %s", line)
+		}
+	}
+}
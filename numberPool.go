@@ -0,0 +1,300 @@
+package bandwidth
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultMaxStickyRecipients = 100000
+
+// ErrNumberPoolEmpty is returned when the pool has no numbers to send from
+var ErrNumberPoolEmpty = errors.New("Number pool has no available numbers")
+
+type pooledNumber struct {
+	number   string
+	areaCode string
+	failures int
+	sent     int
+	next     time.Time
+}
+
+// stickySender is an entry of the list of recently used recipients
+type stickySender struct {
+	key    string
+	number string
+}
+
+// NumberPool selects sender numbers for messages
+// A recipient gets messages from the same number (sticky sender). New recipients get a number
+// with the same area code if possible, otherwise the least loaded number.
+// Numbers which fail MaxFailures times in a row are removed from the pool.
+// Opt-outs of OptOutCompliance are recorded per sender number by default: set its Campaign when the pool is used,
+// otherwise an opted-out recipient can get messages from other numbers of the pool.
+type NumberPool struct {
+	API *Client
+	// MessagesPerSecond limits rate of messages sent from one number (0 means unlimited)
+	MessagesPerSecond float64
+	// MaxFailures is count of consecutive failures after which a number is removed (0 means never)
+	// Only errors of the sender (server errors, network errors and 403 responses) are counted.
+	MaxFailures int
+	// MaxStickyRecipients limits count of remembered sticky senders (100000 if it is 0)
+	// Senders of least recently used recipients are forgotten.
+	MaxStickyRecipients int
+
+	mutex   sync.Mutex
+	numbers map[string]*pooledNumber
+	// sticky contains elements of stickyOrder (*stickySender) by recipient keys
+	sticky      map[string]*list.Element
+	stickyOrder *list.List
+}
+
+// NewNumberPool creates new NumberPool instance with numbers of the account (see GetPhoneNumbers())
+// It returns NumberPool instance or error
+// example: pool, err := bandwidth.NewNumberPool(api, &bandwidth.GetPhoneNumbersQuery{Size: 1000})
+func NewNumberPool(api *Client, query ...*GetPhoneNumbersQuery) (*NumberPool, error) {
+	pool := &NumberPool{API: api, MessagesPerSecond: 1, MaxFailures: 3, numbers: map[string]*pooledNumber{}}
+	if err := pool.Load(query...); err != nil {
+		return nil, err
+	}
+	return pool, nil
+}
+
+// Load adds enabled numbers returned by GetPhoneNumbers() to the pool
+// It returns error object
+func (p *NumberPool) Load(query ...*GetPhoneNumbersQuery) error {
+	list, err := p.API.GetPhoneNumbers(query...)
+	if err != nil {
+		return err
+	}
+	for _, n := range list {
+		if n.NumberState == "" || n.NumberState == "enabled" {
+			p.Add(n.Number)
+		}
+	}
+	return nil
+}
+
+// Add adds the number to the pool
+func (p *NumberPool) Add(number string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.numbers == nil {
+		p.numbers = map[string]*pooledNumber{}
+	}
+	if _, ok := p.numbers[number]; !ok {
+		p.numbers[number] = &pooledNumber{number: number, areaCode: areaCode(number)}
+	}
+}
+
+// Remove removes the number from the pool (recipients which used it get other numbers)
+func (p *NumberPool) Remove(number string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.remove(number)
+}
+
+func (p *NumberPool) remove(number string) {
+	delete(p.numbers, number)
+	for key, element := range p.sticky {
+		if element.Value.(*stickySender).number == number {
+			p.stickyOrder.Remove(element)
+			delete(p.sticky, key)
+		}
+	}
+}
+
+// stick remembers the sender of the recipient key (the least recently used key is forgotten if there are too many keys)
+func (p *NumberPool) stick(key, number string) {
+	if p.sticky == nil {
+		p.sticky, p.stickyOrder = map[string]*list.Element{}, list.New()
+	}
+	if element, ok := p.sticky[key]; ok {
+		element.Value.(*stickySender).number = number
+		p.stickyOrder.MoveToFront(element)
+		return
+	}
+	p.sticky[key] = p.stickyOrder.PushFront(&stickySender{key: key, number: number})
+	max := p.MaxStickyRecipients
+	if max <= 0 {
+		max = defaultMaxStickyRecipients
+	}
+	for p.stickyOrder.Len() > max {
+		oldest := p.stickyOrder.Back()
+		p.stickyOrder.Remove(oldest)
+		delete(p.sticky, oldest.Value.(*stickySender).key)
+	}
+}
+
+// Numbers returns numbers of the pool
+func (p *NumberPool) Numbers() []string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	list := make([]string, 0, len(p.numbers))
+	for number := range p.numbers {
+		list = append(list, number)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// areaCode returns area code of a NANP number (or empty string for other numbers)
+func areaCode(number string) string {
+	parsed, err := ParseE164Number(number)
+	if err != nil {
+		return ""
+	}
+	return parsed.AreaCode()
+}
+
+// recipientKey returns key of sticky sender and number used to match area code
+func recipientKey(to interface{}) (string, string, error) {
+	recipients, err := recipientList(to)
+	if err != nil || len(recipients) == 0 {
+		return "", "", err
+	}
+	list := append([]string{}, recipients...)
+	sort.Strings(list)
+	return strings.Join(list, ","), recipients[0], nil
+}
+
+func (p *NumberPool) choose(key, to string) *pooledNumber {
+	if element, ok := p.sticky[key]; ok {
+		if number, ok := p.numbers[element.Value.(*stickySender).number]; ok {
+			return number
+		}
+	}
+	code := areaCode(to)
+	var best *pooledNumber
+	bestMatches := false
+	for _, n := range p.numbers {
+		matches := code != "" && n.areaCode == code
+		if best == nil || (matches && !bestMatches) || (matches == bestMatches && lessLoaded(n, best)) {
+			best, bestMatches = n, matches
+		}
+	}
+	return best
+}
+
+func lessLoaded(a, b *pooledNumber) bool {
+	if !a.next.Equal(b.next) {
+		return a.next.Before(b.next)
+	}
+	if a.sent != b.sent {
+		return a.sent < b.sent
+	}
+	return a.number < b.number
+}
+
+// Select returns sender number for the recipient(s)
+// It blocks until the number can send next message without exceeding MessagesPerSecond
+// It returns the number, ErrNumberPoolEmpty or error of invalid recipients
+func (p *NumberPool) Select(to interface{}) (string, error) {
+	return p.SelectContext(context.Background(), to)
+}
+
+// SelectContext is Select() which stops waiting for the number when ctx is done
+// It returns the number, ErrNumberPoolEmpty, error of invalid recipients or error of ctx
+func (p *NumberPool) SelectContext(ctx context.Context, to interface{}) (string, error) {
+	key, first, err := recipientKey(to)
+	if err != nil {
+		return "", err
+	}
+	p.mutex.Lock()
+	number := p.choose(key, first)
+	if number == nil {
+		p.mutex.Unlock()
+		return "", ErrNumberPoolEmpty
+	}
+	if key != "" {
+		p.stick(key, number.number)
+	}
+	now := time.Now()
+	at := number.next
+	if at.Before(now) {
+		at = now
+	}
+	if p.MessagesPerSecond > 0 {
+		number.next = at.Add(time.Duration(float64(time.Second) / p.MessagesPerSecond))
+	}
+	number.sent++
+	p.mutex.Unlock()
+	if err := sleepContext(ctx, at.Sub(now)); err != nil {
+		return "", err
+	}
+	return number.number, nil
+}
+
+// Report registers result of sending a message from the number
+// Rate limit errors are not counted as failures of the number
+func (p *NumberPool) Report(number string, err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	n, ok := p.numbers[number]
+	if !ok {
+		return
+	}
+	if err == nil {
+		n.failures = 0
+		return
+	}
+	if _, ok := err.(*RateLimitError); ok {
+		return
+	}
+	n.failures++
+	if p.MaxFailures > 0 && n.failures >= p.MaxFailures {
+		p.remove(number)
+	}
+}
+
+// reportSending passes result of sending to Report()
+// Only errors which can be caused by the sender are reported. Local validation errors (invalid numbers, opt-outs, etc)
+// and other client errors of the API (e.g. 400 for invalid recipient or content) are ignored.
+func (p *NumberPool) reportSending(number string, err error) {
+	switch e := err.(type) {
+	case nil, *RateLimitError, net.Error:
+		p.Report(number, err)
+	case *HTTPError:
+		if e.StatusCode >= 500 || e.StatusCode == http.StatusForbidden {
+			p.Report(number, err)
+		}
+	}
+}
+
+// CreateMessage sends a message (see Client.CreateMessage()) from a number of the pool if data.From is empty
+// It returns ID of created message or error
+func (p *NumberPool) CreateMessage(data *CreateMessageData) (string, error) {
+	message := *data
+	if message.From == "" {
+		from, err := p.Select(message.To)
+		if err != nil {
+			return "", err
+		}
+		message.From = from
+	}
+	id, err := p.API.CreateMessage(&message)
+	p.reportSending(message.From, err)
+	return id, err
+}
+
+// CreateMessageV2 sends a message (see Client.CreateMessageV2()) from a number of the pool if data.From is empty
+// It returns CreateMessageResultV2 instance or error
+func (p *NumberPool) CreateMessageV2(data *CreateMessageDataV2, other ...string) (*CreateMessageResultV2, error) {
+	message := *data
+	if message.From == "" {
+		from, err := p.Select(message.To)
+		if err != nil {
+			return nil, err
+		}
+		message.From = from
+	}
+	result, err := p.API.CreateMessageV2(&message, other...)
+	p.reportSending(message.From, err)
+	return result, err
+}
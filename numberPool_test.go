@@ -0,0 +1,191 @@
+package bandwidth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNewNumberPool(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery: "/v1/users/userId/phoneNumbers?size=10",
+		Method:       http.MethodGet,
+		ContentToSend: `[{"id":"1","number":"+19195551212","numberState":"enabled"},
+			{"id":"2","number":"+17045551212","numberState":"released"},
+			{"id":"3","number":"+17045551213"}]`}})
+	defer server.Close()
+	pool, err := NewNumberPool(api, &GetPhoneNumbersQuery{Size: 10})
+	if err != nil {
+		t.Error("Failed call of NewNumberPool()")
+		return
+	}
+	expect(t, pool.Numbers(), []string{"+17045551213", "+19195551212"})
+}
+
+func TestNewNumberPoolFail(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery:     "/v1/users/userId/phoneNumbers",
+		Method:           http.MethodGet,
+		StatusCodeToSend: http.StatusBadRequest}})
+	defer server.Close()
+	shouldFail(t, func() (interface{}, error) { return NewNumberPool(api) })
+}
+
+func newTestNumberPool(numbers ...string) *NumberPool {
+	pool := &NumberPool{MaxFailures: 2}
+	for _, n := range numbers {
+		pool.Add(n)
+	}
+	return pool
+}
+
+func TestNumberPoolSelect(t *testing.T) {
+	pool := newTestNumberPool("+19195550001", "+17045550001", "+17045550002")
+	number, _ := pool.Select("+17045551111")
+	expect(t, number, "+17045550001")
+	number, _ = pool.Select("+17045552222")
+	expect(t, number, "+17045550002")
+	number, _ = pool.Select("+13365551111")
+	expect(t, number, "+19195550001")
+	// sticky sender
+	number, _ = pool.Select("+17045551111")
+	expect(t, number, "+17045550001")
+	number, _ = pool.Select([]string{"+13365552222", "+13365553333"})
+	expect(t, number, "+17045550002")
+	number, _ = pool.Select([]interface{}{"+13365553333", "+13365552222"})
+	expect(t, number, "+17045550002")
+	_, err := newTestNumberPool().Select("+17045551111")
+	expect(t, err, ErrNumberPoolEmpty)
+	_, err = pool.Select([]interface{}{"+13365553333", 1})
+	expect(t, err != nil, true)
+}
+
+func TestNumberPoolSelectWithMaxStickyRecipients(t *testing.T) {
+	pool := newTestNumberPool("+19195550001")
+	pool.MaxStickyRecipients = 2
+	pool.Select("+17045551111")
+	pool.Select("+17045552222")
+	pool.Select("+17045551111")
+	pool.Select("+17045553333")
+	expect(t, len(pool.sticky), 2)
+	_, ok := pool.sticky["+17045551111"]
+	expect(t, ok, true)
+	_, ok = pool.sticky["+17045552222"]
+	expect(t, ok, false)
+	pool.Remove("+19195550001")
+	expect(t, len(pool.sticky), 0)
+	expect(t, pool.stickyOrder.Len(), 0)
+}
+
+func TestNumberPoolSelectContext(t *testing.T) {
+	pool := newTestNumberPool("+19195550001")
+	pool.MessagesPerSecond = 1
+	pool.Select("+17045551111")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := pool.SelectContext(ctx, "+17045551111")
+	expect(t, err, context.DeadlineExceeded)
+}
+
+func TestNumberPoolSelectWithRateLimit(t *testing.T) {
+	pool := newTestNumberPool("+19195550001")
+	pool.MessagesPerSecond = 20
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		pool.Select("+17045551111")
+	}
+	expect(t, time.Since(start) >= 100*time.Millisecond, true)
+}
+
+func TestNumberPoolReport(t *testing.T) {
+	pool := newTestNumberPool("+19195550001", "+19195550002")
+	pool.Select("+19195551111")
+	pool.Report("+19195550001", errors.New("error"))
+	pool.Report("+19195550001", nil)
+	pool.Report("+19195550001", errors.New("error"))
+	pool.Report("+19195550001", &RateLimitError{})
+	expect(t, pool.Numbers(), []string{"+19195550001", "+19195550002"})
+	pool.Report("+19195550001", errors.New("error"))
+	pool.Report("+19195559999", errors.New("error"))
+	expect(t, pool.Numbers(), []string{"+19195550002"})
+	number, _ := pool.Select("+19195551111")
+	expect(t, number, "+19195550002")
+}
+
+func TestNumberPoolCreateMessage(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery:     "/v1/users/userId/messages",
+		Method:           http.MethodPost,
+		EstimatedContent: `{"from":"+19195550001","to":"+19195551111","text":"hello"}`,
+		HeadersToSend:    map[string]string{"Location": "/v1/users/userId/messages/123"},
+		StatusCodeToSend: http.StatusCreated}})
+	defer server.Close()
+	pool := newTestNumberPool("+19195550001")
+	pool.API = api
+	data := &CreateMessageData{To: "+19195551111", Text: "hello"}
+	id, err := pool.CreateMessage(data)
+	expectNil(t, err)
+	expect(t, id, "123")
+	expect(t, data.From, "")
+}
+
+func TestNumberPoolCreateMessageV2(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery:     "/api/v2/users/userId/messages",
+		Method:           http.MethodPost,
+		EstimatedContent: `{"from":"+19195550001","to":"+19195551111","text":"hello"}`,
+		ContentToSend:    `{"id":"123"}`}})
+	defer server.Close()
+	pool := newTestNumberPool("+19195550001")
+	pool.API = api
+	message, err := pool.CreateMessageV2(&CreateMessageDataV2{To: "+19195551111", Text: "hello"}, api.APIEndPoint)
+	expectNil(t, err)
+	expect(t, message.ID, "123")
+}
+
+func TestNumberPoolCreateMessageFail(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{
+		RequestHandler{
+			PathAndQuery:     "/v1/users/userId/messages",
+			Method:           http.MethodPost,
+			StatusCodeToSend: http.StatusInternalServerError},
+		RequestHandler{
+			PathAndQuery:     "/api/v2/users/userId/messages",
+			Method:           http.MethodPost,
+			StatusCodeToSend: http.StatusBadRequest}})
+	defer server.Close()
+	pool := newTestNumberPool("+19195550001")
+	pool.API = api
+	pool.MaxFailures = 1
+	// client errors are caused by the message, not by the sender
+	shouldFail(t, func() (interface{}, error) {
+		return pool.CreateMessageV2(&CreateMessageDataV2{To: "+19195551111"}, api.APIEndPoint)
+	})
+	expect(t, len(pool.Numbers()), 1)
+	shouldFail(t, func() (interface{}, error) { return pool.CreateMessage(&CreateMessageData{To: "+19195551111"}) })
+	expect(t, len(pool.Numbers()), 0)
+	shouldFail(t, func() (interface{}, error) { return pool.CreateMessageV2(&CreateMessageDataV2{To: "+19195551111"}) })
+}
+
+func TestNumberPoolCreateMessageWithInvalidRecipient(t *testing.T) {
+	api := getAPI()
+	api.StrictNumbers = true
+	store := &MemoryOptOutStore{}
+	store.SetOptOut("+19195550001", "+19195551111", true)
+	api.MessageValidators = []MessageValidator{NewOptOutCompliance(api, store)}
+	pool := newTestNumberPool("+19195550001")
+	pool.API = api
+	pool.MaxFailures = 1
+	_, err := pool.CreateMessage(&CreateMessageData{To: "+19195551111", Text: "Hello"})
+	_, ok := err.(*OptedOutError)
+	expect(t, ok, true)
+	_, err = pool.CreateMessage(&CreateMessageData{To: "invalid", Text: "Hello"})
+	_, ok = err.(*InvalidPhoneNumberError)
+	expect(t, ok, true)
+	_, err = pool.CreateMessageV2(&CreateMessageDataV2{To: "+19195551111", Text: "Hello"})
+	_, ok = err.(*OptedOutError)
+	expect(t, ok, true)
+	expect(t, pool.Numbers(), []string{"+19195550001"})
+}
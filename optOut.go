@@ -0,0 +1,258 @@
+package bandwidth
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// OptOutKeyword is kind of carrier keyword received from a recipient
+type OptOutKeyword string
+
+const (
+	// OptOutKeywordStop means the recipient doesn't want to receive messages anymore
+	OptOutKeywordStop OptOutKeyword = "stop"
+	// OptOutKeywordStart means the recipient wants to receive messages again
+	OptOutKeywordStart OptOutKeyword = "start"
+	// OptOutKeywordHelp means the recipient asks for information about the program
+	OptOutKeywordHelp OptOutKeyword = "help"
+)
+
+// DefaultOptOutKeywords are standard carrier keywords (in upper case) recognized by DetectOptOutKeyword()
+var DefaultOptOutKeywords = map[string]OptOutKeyword{
+	"STOP": OptOutKeywordStop, "STOPALL": OptOutKeywordStop, "UNSUBSCRIBE": OptOutKeywordStop,
+	"CANCEL": OptOutKeywordStop, "END": OptOutKeywordStop, "QUIT": OptOutKeywordStop,
+	"START": OptOutKeywordStart, "UNSTOP": OptOutKeywordStart,
+	"HELP": OptOutKeywordHelp, "INFO": OptOutKeywordHelp,
+}
+
+// DetectOptOutKeyword returns carrier keyword of a message text (empty string for other texts)
+// A keyword should be the only word of the message (case and trailing punctuation are ignored)
+// example: bandwidth.DetectOptOutKeyword("Stop!") // bandwidth.OptOutKeywordStop
+func DetectOptOutKeyword(text string) OptOutKeyword {
+	return detectOptOutKeyword(text, DefaultOptOutKeywords)
+}
+
+func detectOptOutKeyword(text string, keywords map[string]OptOutKeyword) OptOutKeyword {
+	word := strings.ToUpper(strings.TrimRight(strings.TrimSpace(text), ".!?"))
+	return keywords[word]
+}
+
+// OptOutStore keeps opted-out numbers
+// scope is a sender number or a campaign ID (OptOutCompliance passes numbers in E.164 format)
+type OptOutStore interface {
+	SetOptOut(scope, number string, optedOut bool) error
+	IsOptedOut(scope, number string) (bool, error)
+}
+
+// MemoryOptOutStore keeps opt-outs in memory
+type MemoryOptOutStore struct {
+	mutex   sync.Mutex
+	optOuts map[string]bool
+}
+
+func optOutKey(scope, number string) string {
+	return normalizeOptOutNumber(scope) + "\n" + normalizeOptOutNumber(number)
+}
+
+// normalizeOptOutNumber returns the number in E.164 format, so opt-outs match numbers in any format
+// (values which are not valid numbers like campaign IDs are returned as is)
+func normalizeOptOutNumber(number string) string {
+	if parsed, err := ParseE164Number(number); err == nil {
+		return string(parsed)
+	}
+	return number
+}
+
+// SetOptOut records opt-out (or opt-in) of the number
+func (s *MemoryOptOutStore) SetOptOut(scope, number string, optedOut bool) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.optOuts == nil {
+		s.optOuts = map[string]bool{}
+	}
+	if optedOut {
+		s.optOuts[optOutKey(scope, number)] = true
+	} else {
+		delete(s.optOuts, optOutKey(scope, number))
+	}
+	return nil
+}
+
+// IsOptedOut returns true if the number has opted out
+func (s *MemoryOptOutStore) IsOptedOut(scope, number string) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.optOuts[optOutKey(scope, number)], nil
+}
+
+// OptedOutError is returned when a message is sent to an opted-out recipient
+type OptedOutError struct {
+	Number string
+	Scope  string
+}
+
+func (e *OptedOutError) Error() string {
+	return fmt.Sprintf("Recipient %s has opted out of messages from %s", e.Number, e.Scope)
+}
+
+// OptOutCompliance handles STOP/START/HELP keywords of inbound messages and refuses messages to opted-out recipients
+// Add it to Client.MessageValidators to check messages sent by CreateMessage(), CreateMessages() and CreateMessageV2()
+// example: compliance := bandwidth.NewOptOutCompliance(api, &bandwidth.MemoryOptOutStore{}); api.MessageValidators = append(api.MessageValidators, compliance)
+type OptOutCompliance struct {
+	API   *Client
+	Store OptOutStore
+	// Campaign is scope of opt-outs (opt-outs are recorded per sender number if it is empty)
+	// Set it when messages are sent from several numbers (e.g. by NumberPool): otherwise a recipient who has opted out
+	// of one sender number still receives messages from other numbers.
+	Campaign string
+	// Keywords are recognized keywords in upper case (DefaultOptOutKeywords by default)
+	// example: compliance.Keywords = map[string]bandwidth.OptOutKeyword{"STOP": bandwidth.OptOutKeywordStop, "YES": bandwidth.OptOutKeywordStart}
+	Keywords map[string]OptOutKeyword
+	// V2EndPoint is optional messaging endpoint used for replies to v2 messages
+	V2EndPoint string
+	// StopText, StartText and HelpText are automatic replies to keywords (empty text means no reply)
+	StopText  string
+	StartText string
+	HelpText  string
+}
+
+// NewOptOutCompliance creates new OptOutCompliance instance with default replies
+func NewOptOutCompliance(api *Client, store OptOutStore) *OptOutCompliance {
+	return &OptOutCompliance{
+		API:       api,
+		Store:     store,
+		StopText:  "You have been unsubscribed and will not receive more messages. Reply START to resubscribe.",
+		StartText: "You have been resubscribed. Reply STOP to unsubscribe.",
+		HelpText:  "Reply STOP to unsubscribe. Msg&data rates may apply.",
+	}
+}
+
+func (c *OptOutCompliance) scope(sender string) string {
+	if c.Campaign != "" {
+		return c.Campaign
+	}
+	return normalizeOptOutNumber(sender)
+}
+
+// HandleMessage inspects an inbound message, records opt-out or opt-in and sends automatic reply
+// It returns detected keyword (empty for other messages) and error
+func (c *OptOutCompliance) HandleMessage(event *CallbackEvent) (OptOutKeyword, error) {
+	if (event.EventType != CallbackEventSMS && event.EventType != CallbackEventMMS) || event.Direction == "out" {
+		return "", nil
+	}
+	return c.handle(event.From, event.To, event.Text, func(reply string) error {
+		// the reply is sent without MessageValidators: the confirmation should reach the recipient who has just opted out
+		_, _, err := c.API.makeRequest(http.MethodPost, c.API.concatUserPath(messagesPath), nil, &CreateMessageData{From: event.To, To: event.From, Text: reply})
+		return err
+	})
+}
+
+// HandleMessageV2 inspects an inbound message of v2 callback (message-received), records opt-out or opt-in and sends automatic reply
+// Opt-outs are recorded for the number which has received the message (callback's To).
+// It returns detected keyword (empty for other callbacks) and error
+func (c *OptOutCompliance) HandleMessageV2(callback *MessageCallbackV2) (OptOutKeyword, error) {
+	if callback.Type != MessageCallbackV2Received || callback.Message == nil {
+		return "", nil
+	}
+	message := callback.Message
+	to := callback.To
+	if recipients := message.Recipients(); to == "" && len(recipients) > 0 {
+		to = recipients[0]
+	}
+	return c.handle(message.From, to, message.Text, func(reply string) error {
+		data := &CreateMessageDataV2{From: to, To: Recipients(message.From), Text: reply, ApplicationID: message.ApplicationID}
+		_, _, err := c.API.makeRequestV2ToEndPoint(messagingEndPoint([]string{c.V2EndPoint}), http.MethodPost, c.API.concatUserPath(messagesPath), nil, data)
+		return err
+	})
+}
+
+// HandleCallbacksV2 handles messages of v2 callbacks (see HandleMessageV2())
+// It returns error of the first failed message (other messages are handled anyway)
+func (c *OptOutCompliance) HandleCallbacksV2(callbacks []*MessageCallbackV2) error {
+	var result error
+	for _, callback := range callbacks {
+		if _, err := c.HandleMessageV2(callback); err != nil && result == nil {
+			result = err
+		}
+	}
+	return result
+}
+
+// handle records opt-out or opt-in of the keyword of text sent from number "from" to number "to" and sends the reply
+func (c *OptOutCompliance) handle(from, to, text string, send func(reply string) error) (OptOutKeyword, error) {
+	keywords := c.Keywords
+	if keywords == nil {
+		keywords = DefaultOptOutKeywords
+	}
+	keyword := detectOptOutKeyword(text, keywords)
+	reply := ""
+	switch keyword {
+	case OptOutKeywordStop, OptOutKeywordStart:
+		if err := c.Store.SetOptOut(c.scope(to), normalizeOptOutNumber(from), keyword == OptOutKeywordStop); err != nil {
+			return keyword, err
+		}
+		reply = c.StopText
+		if keyword == OptOutKeywordStart {
+			reply = c.StartText
+		}
+	case OptOutKeywordHelp:
+		reply = c.HelpText
+	}
+	if reply == "" {
+		return keyword, nil
+	}
+	return keyword, send(reply)
+}
+
+// ServeHTTP allows to use OptOutCompliance as handler of v1 and v2 message callbacks
+func (c *OptOutCompliance) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	// v2 callbacks are sent as JSON array
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		callbacks, err := ParseMessageCallbacksV2(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := c.HandleCallbacksV2(callbacks); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	} else {
+		event, err := ParseCallbackEvent(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if _, err := c.HandleMessage(event); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// ValidateMessage refuses messages to opted-out recipients
+// It returns OptedOutError for the first opted-out recipient
+func (c *OptOutCompliance) ValidateMessage(message *OutgoingMessage) error {
+	scope := c.scope(message.From)
+	for _, number := range message.To {
+		optedOut, err := c.Store.IsOptedOut(scope, normalizeOptOutNumber(number))
+		if err != nil {
+			return err
+		}
+		if optedOut {
+			return &OptedOutError{Number: number, Scope: scope}
+		}
+	}
+	return nil
+}
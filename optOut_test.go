@@ -0,0 +1,142 @@
+package bandwidth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDetectOptOutKeyword(t *testing.T) {
+	expect(t, DetectOptOutKeyword("STOP"), OptOutKeywordStop)
+	expect(t, DetectOptOutKeyword(" unsubscribe! "), OptOutKeywordStop)
+	expect(t, DetectOptOutKeyword("Unstop"), OptOutKeywordStart)
+	expect(t, DetectOptOutKeyword("help?"), OptOutKeywordHelp)
+	expect(t, DetectOptOutKeyword("Yes"), OptOutKeyword(""))
+	expect(t, DetectOptOutKeyword("please stop"), OptOutKeyword(""))
+	expect(t, DetectOptOutKeyword(""), OptOutKeyword(""))
+}
+
+func TestMemoryOptOutStore(t *testing.T) {
+	store := &MemoryOptOutStore{}
+	optedOut, _ := store.IsOptedOut("+19195550001", "+19195551212")
+	expect(t, optedOut, false)
+	store.SetOptOut("+19195550001", "+19195551212", true)
+	optedOut, _ = store.IsOptedOut("+19195550001", "+19195551212")
+	expect(t, optedOut, true)
+	optedOut, _ = store.IsOptedOut("+19195550002", "+19195551212")
+	expect(t, optedOut, false)
+	store.SetOptOut("+19195550001", "+19195551212", false)
+	optedOut, _ = store.IsOptedOut("+19195550001", "+19195551212")
+	expect(t, optedOut, false)
+}
+
+func TestOptOutCompliance(t *testing.T) {
+	server, api, log := startMockServerWithLog(t, func(w http.ResponseWriter, r *http.Request, body string) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	defer server.Close()
+	compliance := NewOptOutCompliance(api, &MemoryOptOutStore{})
+	compliance.StopText = "Bye"
+	compliance.HelpText = "Info"
+	api.MessageValidators = []MessageValidator{compliance}
+	keyword, err := compliance.HandleMessage(&CallbackEvent{EventType: CallbackEventSMS, Direction: "in", From: "+19195551212", To: "+19195550001", Text: "Stop"})
+	expectNil(t, err)
+	expect(t, keyword, OptOutKeywordStop)
+	_, err = api.CreateMessage(&CreateMessageData{From: "+19195550001", To: "+19195551212", Text: "Hello"})
+	expect(t, err, &OptedOutError{Number: "+19195551212", Scope: "+19195550001"})
+	_, err = api.CreateMessageV2(&CreateMessageDataV2{From: "+19195550001", To: NewRecipients("+19195551213", "+19195551212"), Text: "Hello"}, api.APIEndPoint)
+	expect(t, err, &OptedOutError{Number: "+19195551212", Scope: "+19195550001"})
+	// other senders are not affected
+	_, err = api.CreateMessage(&CreateMessageData{From: "+19195550002", To: "+19195551212", Text: "Hello"})
+	expectNil(t, err)
+	compliance.HandleMessage(&CallbackEvent{EventType: CallbackEventSMS, Direction: "in", From: "+19195551212", To: "+19195550001", Text: "help"})
+	compliance.HandleMessage(&CallbackEvent{EventType: CallbackEventSMS, Direction: "in", From: "+19195551212", To: "+19195550001", Text: "START"})
+	keyword, _ = compliance.HandleMessage(&CallbackEvent{EventType: CallbackEventSMS, Direction: "in", From: "+19195551212", To: "+19195550001", Text: "Hello"})
+	expect(t, keyword, OptOutKeyword(""))
+	keyword, _ = compliance.HandleMessage(&CallbackEvent{EventType: CallbackEventSMS, Direction: "out", From: "+19195550001", To: "+19195551212", Text: "STOP"})
+	expect(t, keyword, OptOutKeyword(""))
+	_, err = api.CreateMessage(&CreateMessageData{From: "+19195550001", To: "+19195551212", Text: "Hello"})
+	expectNil(t, err)
+	expect(t, log(), []string{
+		`POST /v1/users/userId/messages {"from":"+19195550001","to":"+19195551212","text":"Bye"}`,
+		`POST /v1/users/userId/messages {"from":"+19195550002","to":"+19195551212","text":"Hello"}`,
+		`POST /v1/users/userId/messages {"from":"+19195550001","to":"+19195551212","text":"Info"}`,
+		`POST /v1/users/userId/messages {"from":"+19195550001","to":"+19195551212","text":"You have been resubscribed. Reply STOP to unsubscribe."}`,
+		`POST /v1/users/userId/messages {"from":"+19195550001","to":"+19195551212","text":"Hello"}`,
+	})
+}
+
+func TestOptOutComplianceHandleMessageV2(t *testing.T) {
+	server, api, log := startMockServerWithLog(t, func(w http.ResponseWriter, r *http.Request, body string) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+	defer server.Close()
+	compliance := NewOptOutCompliance(api, &MemoryOptOutStore{})
+	compliance.V2EndPoint = api.APIEndPoint
+	compliance.StopText = "Bye"
+	received := func(text string) *MessageCallbackV2 {
+		return &MessageCallbackV2{Type: MessageCallbackV2Received, To: "+19195550001", Message: &CreateMessageResultV2{
+			ID: "m1", From: "+19195551212", To: []interface{}{"+19195550001"}, Text: text, ApplicationID: "app", Direction: "in"}}
+	}
+	keyword, err := compliance.HandleMessageV2(received("STOP"))
+	expectNil(t, err)
+	expect(t, keyword, OptOutKeywordStop)
+	optedOut, _ := compliance.Store.IsOptedOut("+19195550001", "+19195551212")
+	expect(t, optedOut, true)
+	keyword, _ = compliance.HandleMessageV2(&MessageCallbackV2{Type: MessageCallbackV2Delivered, Message: &CreateMessageResultV2{Text: "STOP"}})
+	expect(t, keyword, OptOutKeyword(""))
+	keyword, _ = compliance.HandleMessageV2(received("yes"))
+	expect(t, keyword, OptOutKeyword(""))
+	compliance.Keywords = map[string]OptOutKeyword{"YES": OptOutKeywordStart}
+	compliance.StartText = ""
+	keyword, _ = compliance.HandleMessageV2(received("yes"))
+	expect(t, keyword, OptOutKeywordStart)
+	optedOut, _ = compliance.Store.IsOptedOut("+19195550001", "+19195551212")
+	expect(t, optedOut, false)
+	expect(t, log(), []string{
+		`POST /api/v2/users/userId/messages {"from":"+19195550001","to":"+19195551212","text":"Bye","applicationId":"app"}`,
+	})
+}
+
+func TestOptOutComplianceWithCampaign(t *testing.T) {
+	compliance := &OptOutCompliance{Store: &MemoryOptOutStore{}, Campaign: "campaign"}
+	_, err := compliance.HandleMessage(&CallbackEvent{EventType: CallbackEventSMS, From: "+19195551212", To: "+19195550001", Text: "STOP"})
+	expectNil(t, err)
+	err = compliance.ValidateMessage(&OutgoingMessage{From: "+19195550002", To: []string{"+19195551212"}})
+	expect(t, err, &OptedOutError{Number: "+19195551212", Scope: "campaign"})
+}
+
+func TestOptOutComplianceWithNumberFormats(t *testing.T) {
+	compliance := &OptOutCompliance{Store: &MemoryOptOutStore{}}
+	_, err := compliance.HandleMessage(&CallbackEvent{EventType: CallbackEventSMS, From: "+19195551212", To: "+19195550001", Text: "STOP"})
+	expectNil(t, err)
+	for _, number := range []string{"+19195551212", "9195551212", "(919) 555-1212", "1-919-555-1212"} {
+		err = compliance.ValidateMessage(&OutgoingMessage{From: "919-555-0001", To: []string{number}})
+		expect(t, err, &OptedOutError{Number: number, Scope: "+19195550001"})
+	}
+	// values which are not numbers are compared as is
+	store := &MemoryOptOutStore{}
+	store.SetOptOut("campaign", "short-code", true)
+	optedOut, _ := store.IsOptedOut("campaign", "short-code")
+	expect(t, optedOut, true)
+	optedOut, _ = store.IsOptedOut("campaign", "9195551212")
+	expect(t, optedOut, false)
+}
+
+func TestOptOutComplianceServeHTTP(t *testing.T) {
+	compliance := &OptOutCompliance{Store: &MemoryOptOutStore{}}
+	w := httptest.NewRecorder()
+	compliance.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"eventType":"sms","from":"+19195551212","to":"+19195550001","text":"stop"}`)))
+	expect(t, w.Code, http.StatusOK)
+	optedOut, _ := compliance.Store.IsOptedOut("+19195550001", "+19195551212")
+	expect(t, optedOut, true)
+	w = httptest.NewRecorder()
+	compliance.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`[{"type":"message-received","to":"+19195550001","message":{"from":"+19195551213","text":"stop"}}]`)))
+	expect(t, w.Code, http.StatusOK)
+	optedOut, _ = compliance.Store.IsOptedOut("+19195550001", "+19195551213")
+	expect(t, optedOut, true)
+	w = httptest.NewRecorder()
+	compliance.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`)))
+	expect(t, w.Code, http.StatusBadRequest)
+}
@@ -73,6 +73,9 @@ func (api *Client) GetPhoneNumbers(query ...*GetPhoneNumbersQuery) ([]*PhoneNumb
 // CreatePhoneNumber creates a new phone number
 // It returns ID of created phone number or error
 func (api *Client) CreatePhoneNumber(data *CreatePhoneNumberData) (string, error) {
+	if err := api.checkNumbers(data.Number, data.FallbackNumber); err != nil {
+		return "", err
+	}
 	_, headers, err := api.makeRequest(http.MethodPost, api.concatUserPath(phoneNumbersPath), nil, data)
 	if err != nil {
 		return "", err
@@ -83,6 +86,9 @@ func (api *Client) CreatePhoneNumber(data *CreatePhoneNumberData) (string, error
 // GetPhoneNumber returns information for phone number by id or number
 // It returns instance of PhoneNumber or error
 func (api *Client) GetPhoneNumber(idOrNumber string) (*PhoneNumber, error) {
+	if err := api.checkIDOrNumber(idOrNumber); err != nil {
+		return nil, err
+	}
 	result, _, err := api.makeRequest(http.MethodGet, fmt.Sprintf("%s/%s", api.concatUserPath(phoneNumbersPath), url.QueryEscape(idOrNumber)), &PhoneNumber{})
 	if err != nil {
 		return nil, err
@@ -93,6 +99,12 @@ func (api *Client) GetPhoneNumber(idOrNumber string) (*PhoneNumber, error) {
 // UpdatePhoneNumber makes changes to your number
 // It returns error object
 func (api *Client) UpdatePhoneNumber(idOrNumber string, data *UpdatePhoneNumberData) error {
+	if err := api.checkIDOrNumber(idOrNumber); err != nil {
+		return err
+	}
+	if err := api.checkNumbers(data.FallbackNumber); err != nil {
+		return err
+	}
 	_, _, err := api.makeRequest(http.MethodPost, fmt.Sprintf("%s/%s", api.concatUserPath(phoneNumbersPath), url.QueryEscape(idOrNumber)), nil, data)
 	return err
 }
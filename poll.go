@@ -0,0 +1,35 @@
+package bandwidth
+
+import (
+	"context"
+	"time"
+)
+
+// pollUntil calls check every interval until it reports completion, returns an error or ctx is done
+func pollUntil(ctx context.Context, interval time.Duration, check func() (bool, error)) error {
+	return pollWithBackoff(ctx, interval, interval, check)
+}
+
+// pollWithBackoff calls check until it reports completion, returns an error or ctx is done
+// Interval between checks starts from interval and is doubled after each check up to maxInterval.
+func pollWithBackoff(ctx context.Context, interval, maxInterval time.Duration, check func() (bool, error)) error {
+	for {
+		done, err := check()
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		if interval *= 2; interval > maxInterval {
+			interval = maxInterval
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package bandwidth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPollUntil(t *testing.T) {
+	calls := 0
+	err := pollUntil(context.Background(), time.Millisecond, func() (bool, error) {
+		calls++
+		return calls == 3, nil
+	})
+	expectNil(t, err)
+	expect(t, calls, 3)
+}
+
+func TestPollUntilFail(t *testing.T) {
+	err := pollUntil(context.Background(), time.Millisecond, func() (bool, error) {
+		return false, errors.New("error")
+	})
+	expect(t, err.Error(), "error")
+}
+
+func TestPollUntilWithCanceledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := pollUntil(ctx, time.Hour, func() (bool, error) {
+		return false, nil
+	})
+	expect(t, err, context.Canceled)
+}
+
+func TestPollWithBackoff(t *testing.T) {
+	calls := []time.Time{}
+	err := pollWithBackoff(context.Background(), 10*time.Millisecond, 20*time.Millisecond, func() (bool, error) {
+		calls = append(calls, time.Now())
+		return len(calls) == 4, nil
+	})
+	expectNil(t, err)
+	expect(t, len(calls), 4)
+	expect(t, calls[1].Sub(calls[0]) >= 10*time.Millisecond, true)
+	expect(t, calls[2].Sub(calls[1]) >= 20*time.Millisecond, true)
+	expect(t, calls[3].Sub(calls[2]) >= 20*time.Millisecond, true)
+}
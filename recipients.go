@@ -0,0 +1,90 @@
+package bandwidth
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// MaxGroupMessageRecipients is max count of recipients of a v2 group message
+const MaxGroupMessageRecipients = 10
+
+// Recipients is a number or comma-separated list of numbers of a v2 message
+// A single number is sent as JSON string, several numbers are sent as JSON array (group message).
+// Recipients can be decoded from a number or an array.
+// example: api.CreateMessageV2(&bandwidth.CreateMessageDataV2{From: "+19195551212", To: bandwidth.NewRecipients("+19195551213", "+19195551214"), Text: "Hi all"})
+type Recipients string
+
+const recipientsSeparator = ","
+
+// NewRecipients creates list of recipients (empty numbers and duplicates are removed)
+func NewRecipients(numbers ...string) Recipients {
+	return Recipients(strings.Join(uniqueNumbers(numbers), recipientsSeparator))
+}
+
+// uniqueNumbers returns trimmed numbers without empty values and duplicates
+func uniqueNumbers(numbers []string) []string {
+	seen := map[string]bool{}
+	list := []string{}
+	for _, number := range numbers {
+		number = strings.TrimSpace(number)
+		if number != "" && !seen[number] {
+			seen[number] = true
+			list = append(list, number)
+		}
+	}
+	return list
+}
+
+// List returns numbers of the recipients
+func (r Recipients) List() []string {
+	return uniqueNumbers(strings.Split(string(r), recipientsSeparator))
+}
+
+// IsGroup returns true for several recipients
+func (r Recipients) IsGroup() bool {
+	return len(r.List()) > 1
+}
+
+// Validate checks count of recipients
+// It returns TooManyRecipientsError if the group is larger than MaxGroupMessageRecipients
+func (r Recipients) Validate() error {
+	if count := len(r.List()); count > MaxGroupMessageRecipients {
+		return &TooManyRecipientsError{Count: count, Max: MaxGroupMessageRecipients}
+	}
+	return nil
+}
+
+// MarshalJSON writes a single recipient as JSON string and several recipients as JSON array
+func (r Recipients) MarshalJSON() ([]byte, error) {
+	list := r.List()
+	if len(list) == 1 {
+		return json.Marshal(list[0])
+	}
+	return json.Marshal(list)
+}
+
+// UnmarshalJSON reads recipients from a number or array of numbers
+func (r *Recipients) UnmarshalJSON(data []byte) error {
+	var number string
+	if err := json.Unmarshal(data, &number); err == nil {
+		*r = NewRecipients(number)
+		return nil
+	}
+	var numbers []string
+	if err := json.Unmarshal(data, &numbers); err != nil {
+		return err
+	}
+	*r = NewRecipients(numbers...)
+	return nil
+}
+
+// TooManyRecipientsError is returned when a group message has too many recipients
+type TooManyRecipientsError struct {
+	Count int
+	Max   int
+}
+
+func (e *TooManyRecipientsError) Error() string {
+	return fmt.Sprintf("Too many recipients of group message: %d (max %d)", e.Count, e.Max)
+}
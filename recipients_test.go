@@ -0,0 +1,69 @@
+package bandwidth
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestNewRecipients(t *testing.T) {
+	r := NewRecipients("+1", " +2 ", "", "+1")
+	expect(t, r, Recipients("+1,+2"))
+	expect(t, r.List(), []string{"+1", "+2"})
+	expect(t, r.IsGroup(), true)
+	expect(t, NewRecipients("+1").IsGroup(), false)
+	expect(t, Recipients("+1, +2,+1").List(), []string{"+1", "+2"})
+	expect(t, len(Recipients("").List()), 0)
+}
+
+func TestRecipientsJSON(t *testing.T) {
+	data, err := json.Marshal(NewRecipients("+1", "+1", "+2"))
+	expectNil(t, err)
+	expect(t, string(data), `["+1","+2"]`)
+	data, err = json.Marshal(Recipients("+1"))
+	expectNil(t, err)
+	expect(t, string(data), `"+1"`)
+	var r Recipients
+	expectNil(t, json.Unmarshal([]byte(`"+1"`), &r))
+	expect(t, r, Recipients("+1"))
+	expectNil(t, json.Unmarshal([]byte(`["+1","+2","+2"]`), &r))
+	expect(t, r, Recipients("+1,+2"))
+	expect(t, json.Unmarshal([]byte(`1`), &r) != nil, true)
+	data, err = json.Marshal(&CreateMessageDataV2{From: "+1"})
+	expectNil(t, err)
+	expect(t, string(data), `{"from":"+1"}`)
+}
+
+func TestRecipientsValidate(t *testing.T) {
+	numbers := []string{}
+	for i := 0; i < MaxGroupMessageRecipients; i++ {
+		numbers = append(numbers, string(rune('a'+i)))
+	}
+	expectNil(t, NewRecipients(numbers...).Validate())
+	err := NewRecipients(append(numbers, "z")...).Validate().(*TooManyRecipientsError)
+	expect(t, err.Count, MaxGroupMessageRecipients+1)
+	expect(t, err.Error(), "Too many recipients of group message: 11 (max 10)")
+}
+
+func TestCreateMessageV2WithRecipients(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery:     "/api/v2/users/userId/messages",
+		Method:           http.MethodPost,
+		EstimatedContent: `{"from":"+12345678901","to":["+12345678902","+12345678903"],"text":"text"}`,
+		ContentToSend:    `{"id":"123","to":"+12345678902"}`}})
+	defer server.Close()
+	data := &CreateMessageDataV2{From: "+12345678901", To: "+12345678902,+12345678903,+12345678902", Text: "text"}
+	message, err := api.CreateMessageV2(data, api.APIEndPoint)
+	expectNil(t, err)
+	expect(t, message.Recipients(), []string{"+12345678902"})
+	expect(t, data.To, Recipients("+12345678902,+12345678903,+12345678902"))
+}
+
+func TestCreateMessageV2WithTooManyRecipients(t *testing.T) {
+	api := getAPI()
+	to := Recipients(strings.Repeat("a,b,c,d,e,f,", 2) + "g,h,i,j,k")
+	err := shouldFail(t, func() (interface{}, error) { return api.CreateMessageV2(&CreateMessageDataV2{From: "+1", To: to}) })
+	_, ok := err.(*TooManyRecipientsError)
+	expect(t, ok, true)
+}
@@ -0,0 +1,314 @@
+package bandwidth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultArchiveTranscriptionTimeout = 24 * time.Hour
+
+// ArchiveStorage stores archived recordings (see FileArchiveStorage)
+// Names of objects are slash-separated paths.
+type ArchiveStorage interface {
+	// Exists returns true if the object is stored
+	Exists(name string) (bool, error)
+	// Put stores content as the object (existing object is replaced)
+	Put(name string, content io.Reader) error
+}
+
+// MemoryArchiveStorage keeps archived objects in memory
+type MemoryArchiveStorage struct {
+	mutex   sync.Mutex
+	objects map[string][]byte
+}
+
+// Exists returns true if the object is stored
+func (s *MemoryArchiveStorage) Exists(name string) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	_, ok := s.objects[name]
+	return ok, nil
+}
+
+// Put stores content as the object
+func (s *MemoryArchiveStorage) Put(name string, content io.Reader) error {
+	data, err := ioutil.ReadAll(content)
+	if err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.objects == nil {
+		s.objects = map[string][]byte{}
+	}
+	s.objects[name] = data
+	return nil
+}
+
+// Get returns content of the object (nil if it doesn't exist)
+func (s *MemoryArchiveStorage) Get(name string) []byte {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.objects[name]
+}
+
+// Names returns sorted names of stored objects
+func (s *MemoryArchiveStorage) Names() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	names := make([]string, 0, len(s.objects))
+	for name := range s.objects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FileArchiveStorage keeps archived objects as files of a local directory
+type FileArchiveStorage struct {
+	Dir string
+}
+
+func (s *FileArchiveStorage) path(name string) string {
+	return filepath.Join(s.Dir, filepath.FromSlash(name))
+}
+
+// Exists returns true if the file exists
+func (s *FileArchiveStorage) Exists(name string) (bool, error) {
+	_, err := os.Stat(s.path(name))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Put writes content to the file (the file is replaced atomically)
+func (s *FileArchiveStorage) Put(name string, content io.Reader) error {
+	path := s.path(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := ioutil.TempFile(filepath.Dir(path), ".archive")
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(file, content)
+	if e := file.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+	return nil
+}
+
+// RecordingMetadata is content of JSON sidecar of an archived recording
+type RecordingMetadata struct {
+	Recording *Recording `json:"recording"`
+	// MediaObject is name of the object with media of the recording
+	MediaObject string `json:"mediaObject"`
+	MediaSize   int64  `json:"mediaSize"`
+	// Transcriptions are completed transcriptions (their text is stored in objects TranscriptionObjects)
+	Transcriptions       []*Transcription `json:"transcriptions"`
+	TranscriptionObjects []string         `json:"transcriptionObjects"`
+	ArchivedTime         time.Time        `json:"archivedTime"`
+}
+
+// ArchiveResult is result of RecordingArchiver.Run()
+type ArchiveResult struct {
+	// Archived contains IDs of recordings archived by this run
+	Archived []string
+	// Skipped is count of recordings which have been archived before or have failed state
+	Skipped int
+	// Pending contains IDs of recordings which aren't completed yet or have transcriptions in progress (they are archived by next runs)
+	Pending []string
+	// Errors contains errors of recordings by their IDs
+	Errors map[string]error
+}
+
+// RecordingArchiver copies recordings with their transcriptions and JSON metadata to ArchiveStorage
+// For recording ID objects ID<media extension> (media), ID.TRANSCRIPTION_ID.txt (text of transcriptions) and ID.json (metadata) are stored.
+// The metadata is stored last: recordings with existing metadata are skipped, so the archiver can be run incrementally.
+type RecordingArchiver struct {
+	API     *Client
+	Storage ArchiveStorage
+	// Prefix is prepended to names of stored objects (e.g. "recordings/")
+	Prefix string
+	// TempDir is directory for temporary files of downloaded media (os.TempDir() by default)
+	TempDir string
+	// SkipTranscriptions disables archiving of transcriptions
+	SkipTranscriptions bool
+	// TranscriptionTimeout limits waiting for transcriptions in progress after end of the recording (24 hours if it is 0)
+	// Older recordings are archived without transcriptions in progress.
+	TranscriptionTimeout time.Duration
+	// OnArchive is called after each archived recording
+	OnArchive func(metadata *RecordingMetadata)
+}
+
+// NewRecordingArchiver creates new RecordingArchiver instance
+// example: archiver := bandwidth.NewRecordingArchiver(api, &bandwidth.FileArchiveStorage{Dir: "./archive"})
+func NewRecordingArchiver(api *Client, storage ArchiveStorage) *RecordingArchiver {
+	return &RecordingArchiver{API: api, Storage: storage}
+}
+
+func (a *RecordingArchiver) transcriptionTimeout() time.Duration {
+	if a.TranscriptionTimeout > 0 {
+		return a.TranscriptionTimeout
+	}
+	return defaultArchiveTranscriptionTimeout
+}
+
+// waitsForTranscriptions returns true if transcriptions in progress of the recording can still be completed
+// (recordings without valid end or start time are not waited for)
+func (a *RecordingArchiver) waitsForTranscriptions(recording *Recording) bool {
+	for _, value := range []string{recording.EndTime, recording.StartTime} {
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			return time.Since(t) < a.transcriptionTimeout()
+		}
+	}
+	return false
+}
+
+func (a *RecordingArchiver) metadataObject(recording *Recording) string {
+	return a.Prefix + recording.ID + ".json"
+}
+
+// Run archives all recordings which haven't been archived yet (all pages of GetRecordings() are requested)
+// Failures of single recordings don't stop the run, they are returned in ArchiveResult.Errors.
+// It returns ArchiveResult instance and error (if listing of recordings or some recordings have failed)
+// example: result, err := archiver.Run(ctx)
+func (a *RecordingArchiver) Run(ctx context.Context) (*ArchiveResult, error) {
+	result := &ArchiveResult{Archived: []string{}, Pending: []string{}, Errors: map[string]error{}}
+	recordings, err := a.API.GetAllRecordings()
+	if err != nil {
+		return result, err
+	}
+	for _, recording := range recordings {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		switch recording.State {
+		case "complete":
+		case "error":
+			result.Skipped++
+			continue
+		default:
+			result.Pending = append(result.Pending, recording.ID)
+			continue
+		}
+		exists, err := a.Storage.Exists(a.metadataObject(recording))
+		if err != nil {
+			result.Errors[recording.ID] = err
+			continue
+		}
+		if exists {
+			result.Skipped++
+			continue
+		}
+		metadata, err := a.Archive(ctx, recording)
+		switch {
+		case err != nil:
+			result.Errors[recording.ID] = err
+		case metadata == nil:
+			result.Pending = append(result.Pending, recording.ID)
+		default:
+			result.Archived = append(result.Archived, recording.ID)
+		}
+	}
+	if len(result.Errors) > 0 {
+		return result, fmt.Errorf("%d of %d recordings have not been archived", len(result.Errors), len(recordings))
+	}
+	return result, nil
+}
+
+// Archive stores media, transcriptions and metadata of the recording (the recording is stored again if it has been archived before)
+// Transcriptions in progress are waited for until TranscriptionTimeout after end of the recording, then they are ignored.
+// It returns stored metadata, nil if some transcriptions are in progress (nothing is stored in this case) or error
+func (a *RecordingArchiver) Archive(ctx context.Context, recording *Recording) (*RecordingMetadata, error) {
+	transcriptions := []*Transcription{}
+	if !a.SkipTranscriptions {
+		list, err := a.API.GetRecordingTranscriptions(recording.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, transcription := range list {
+			switch transcription.State {
+			case "completed":
+				transcriptions = append(transcriptions, transcription)
+			case "error":
+			default:
+				if a.waitsForTranscriptions(recording) {
+					return nil, nil
+				}
+			}
+		}
+	}
+	metadata := &RecordingMetadata{
+		Recording:            recording,
+		MediaObject:          a.Prefix + recording.ID + path.Ext(recordingMediaName(recording)),
+		Transcriptions:       transcriptions,
+		TranscriptionObjects: []string{},
+	}
+	size, err := a.archiveMedia(ctx, recording, metadata.MediaObject)
+	if err != nil {
+		return nil, err
+	}
+	metadata.MediaSize = size
+	for _, transcription := range transcriptions {
+		text, err := a.API.transcriptionText(ctx, transcription)
+		if err != nil {
+			return nil, err
+		}
+		name := fmt.Sprintf("%s%s.%s.txt", a.Prefix, recording.ID, transcription.ID)
+		if err := a.Storage.Put(name, strings.NewReader(text)); err != nil {
+			return nil, err
+		}
+		metadata.TranscriptionObjects = append(metadata.TranscriptionObjects, name)
+	}
+	metadata.ArchivedTime = time.Now().UTC()
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := a.Storage.Put(a.metadataObject(recording), bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	if a.OnArchive != nil {
+		a.OnArchive(metadata)
+	}
+	return metadata, nil
+}
+
+// archiveMedia downloads media to a temporary file and stores it
+func (a *RecordingArchiver) archiveMedia(ctx context.Context, recording *Recording, name string) (int64, error) {
+	file, err := ioutil.TempFile(a.TempDir, "recording")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+	size, _, err := a.API.downloadRecording(ctx, recording, file)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return size, a.Storage.Put(name, io.LimitReader(file, size))
+}
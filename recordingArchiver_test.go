@@ -0,0 +1,169 @@
+package bandwidth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func startArchiveMockServer(t *testing.T, failMedia bool) (*httptest.Server, *Client, func() []string) {
+	// handlers are set after start because content of transcription t2 refers to the server
+	var handlers []RequestHandler
+	server, api, log := startMockServerWithLog(t, func(w http.ResponseWriter, r *http.Request, body string) {
+		if !serveRequestHandlers(t, handlers, w, r, body) {
+			t.Logf("Unhandled request %s %s", r.Method, r.URL.String())
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	endTime := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	handlers = []RequestHandler{
+		RequestHandler{
+			PathAndQuery: "/v1/users/userId/recordings?size=1000",
+			ContentToSend: fmt.Sprintf(`[
+				{"id": "r1", "state": "complete", "call": "c1", "media": "https://api.catapult.inetwork.com/v1/users/userId/media/r1.wav"},
+				{"id": "r2", "state": "complete", "endTime": "%s", "media": "https://api.catapult.inetwork.com/v1/users/userId/media/r2.wav"},
+				{"id": "r3", "state": "recording"},
+				{"id": "r4", "state": "error"}]`, endTime)},
+		RequestHandler{
+			PathAndQuery: "/v1/users/userId/recordings/r1/transcriptions",
+			ContentToSend: fmt.Sprintf(`[
+				{"id": "t1", "state": "completed", "text": "hello"},
+				{"id": "t2", "state": "completed", "textUrl": "%s/v1/users/userId/recordings/r1/transcriptions/t2/text"},
+				{"id": "t3", "state": "error"}]`, server.URL)},
+		RequestHandler{
+			PathAndQuery:  "/v1/users/userId/recordings/r2/transcriptions",
+			ContentToSend: `[{"id": "t4", "state": "transcribing"}]`},
+		RequestHandler{
+			PathAndQuery:  "/v1/users/userId/recordings/r1/transcriptions/t2/text",
+			ContentToSend: "large text",
+			HeadersToSend: map[string]string{"Content-Type": "text/plain"}},
+		RequestHandler{
+			PathAndQuery:  "/v1/users/userId/media/r2.wav",
+			ContentToSend: "audio2",
+			HeadersToSend: map[string]string{"Content-Type": "audio/wav"}}}
+	if failMedia {
+		handlers = append(handlers, RequestHandler{PathAndQuery: "/v1/users/userId/media/r1.wav", StatusCodeToSend: http.StatusInternalServerError})
+	} else {
+		handlers = append(handlers, RequestHandler{PathAndQuery: "/v1/users/userId/media/r1.wav", ContentToSend: "audio", HeadersToSend: map[string]string{"Content-Type": "audio/wav"}})
+	}
+	return server, api, log
+}
+
+func TestRecordingArchiverRun(t *testing.T) {
+	server, api, _ := startArchiveMockServer(t, false)
+	defer server.Close()
+	storage := &MemoryArchiveStorage{}
+	archiver := NewRecordingArchiver(api, storage)
+	archiver.Prefix = "recordings/"
+	archived := []string{}
+	archiver.OnArchive = func(metadata *RecordingMetadata) {
+		archived = append(archived, metadata.Recording.ID)
+	}
+	result, err := archiver.Run(context.Background())
+	expectNil(t, err)
+	expect(t, result.Archived, []string{"r1"})
+	expect(t, result.Pending, []string{"r2", "r3"})
+	expect(t, result.Skipped, 1)
+	expect(t, archived, []string{"r1"})
+	expect(t, storage.Names(), []string{"recordings/r1.json", "recordings/r1.t1.txt", "recordings/r1.t2.txt", "recordings/r1.wav"})
+	expect(t, string(storage.Get("recordings/r1.wav")), "audio\n")
+	expect(t, string(storage.Get("recordings/r1.t1.txt")), "hello")
+	expect(t, string(storage.Get("recordings/r1.t2.txt")), "large text\n")
+	metadata := &RecordingMetadata{}
+	expectNil(t, json.Unmarshal(storage.Get("recordings/r1.json"), metadata))
+	expect(t, metadata.Recording.Call, "c1")
+	expect(t, metadata.MediaObject, "recordings/r1.wav")
+	expect(t, metadata.MediaSize, int64(6))
+	expect(t, len(metadata.Transcriptions), 2)
+	expect(t, metadata.TranscriptionObjects, []string{"recordings/r1.t1.txt", "recordings/r1.t2.txt"})
+	expect(t, metadata.ArchivedTime.IsZero(), false)
+
+	result, err = archiver.Run(context.Background())
+	expectNil(t, err)
+	expect(t, result.Archived, []string{})
+	expect(t, result.Skipped, 2)
+	expect(t, archived, []string{"r1"})
+}
+
+func TestRecordingArchiverRunWithoutTranscriptions(t *testing.T) {
+	server, api, log := startArchiveMockServer(t, false)
+	defer server.Close()
+	storage := &MemoryArchiveStorage{}
+	archiver := NewRecordingArchiver(api, storage)
+	archiver.SkipTranscriptions = true
+	result, err := archiver.Run(context.Background())
+	expectNil(t, err)
+	expect(t, result.Archived, []string{"r1", "r2"})
+	expect(t, storage.Names(), []string{"r1.json", "r1.wav", "r2.json", "r2.wav"})
+	for _, line := range log() {
+		if strings.Contains(line, "transcriptions") {
+			t.Errorf("Unexpected request %s", line)
+		}
+	}
+}
+
+func TestRecordingArchiverRunWithTranscriptionTimeout(t *testing.T) {
+	server, api, _ := startArchiveMockServer(t, false)
+	defer server.Close()
+	storage := &MemoryArchiveStorage{}
+	archiver := NewRecordingArchiver(api, storage)
+	archiver.TranscriptionTimeout = time.Minute
+	result, err := archiver.Run(context.Background())
+	expectNil(t, err)
+	expect(t, result.Archived, []string{"r1", "r2"})
+	expect(t, result.Pending, []string{"r3"})
+	metadata := &RecordingMetadata{}
+	expectNil(t, json.Unmarshal(storage.Get("r2.json"), metadata))
+	expect(t, len(metadata.Transcriptions), 0)
+	expect(t, metadata.MediaSize, int64(7))
+}
+
+func TestRecordingArchiverRunFail(t *testing.T) {
+	server, api, _ := startArchiveMockServer(t, true)
+	defer server.Close()
+	storage := &MemoryArchiveStorage{}
+	result, err := NewRecordingArchiver(api, storage).Run(context.Background())
+	expect(t, err.Error(), "1 of 4 recordings have not been archived")
+	expect(t, len(result.Errors), 1)
+	expect(t, result.Errors["r1"] != nil, true)
+	expect(t, storage.Names(), []string{})
+}
+
+func TestRecordingArchiverRunListFail(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery:     "/v1/users/userId/recordings?size=1000",
+		StatusCodeToSend: http.StatusBadRequest}})
+	defer server.Close()
+	_, err := NewRecordingArchiver(api, &MemoryArchiveStorage{}).Run(context.Background())
+	expect(t, err != nil, true)
+}
+
+func TestFileArchiveStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	storage := &FileArchiveStorage{Dir: dir}
+	exists, err := storage.Exists("recordings/r1.wav")
+	expectNil(t, err)
+	expect(t, exists, false)
+	expectNil(t, storage.Put("recordings/r1.wav", strings.NewReader("audio")))
+	exists, err = storage.Exists("recordings/r1.wav")
+	expectNil(t, err)
+	expect(t, exists, true)
+	expectNil(t, storage.Put("recordings/r1.wav", strings.NewReader("new audio")))
+	data, err := ioutil.ReadFile(filepath.Join(dir, "recordings", "r1.wav"))
+	expectNil(t, err)
+	expect(t, string(data), "new audio")
+	files, _ := ioutil.ReadDir(filepath.Join(dir, "recordings"))
+	expect(t, len(files), 1)
+}
@@ -0,0 +1,350 @@
+package bandwidth
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// RetentionPolicy selects recordings which should be deleted
+// A recording matches the policy if it is older than MaxAge and its call matches Tags and Numbers.
+type RetentionPolicy struct {
+	Name string
+	// MaxAge is max age of recordings (age is counted from EndTime, or StartTime if EndTime is empty)
+	MaxAge time.Duration
+	// Tags limits the policy to recordings of calls with these tags (empty list means any tag)
+	Tags []string
+	// Numbers limits the policy to recordings of calls from or to these numbers (empty list means any number)
+	Numbers []string
+}
+
+func (p *RetentionPolicy) needsCall() bool {
+	return len(p.Tags) > 0 || len(p.Numbers) > 0
+}
+
+func (p *RetentionPolicy) matches(age time.Duration, call *Call) bool {
+	if age < p.MaxAge {
+		return false
+	}
+	if len(p.Tags) > 0 && !containsString(p.Tags, call.Tag) {
+		return false
+	}
+	if len(p.Numbers) > 0 {
+		found := false
+		for _, number := range p.Numbers {
+			if sameNumber(number, call.From) || sameNumber(number, call.To) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// sameNumber compares phone numbers in E.164 form (other values are compared as is)
+func sameNumber(a, b string) bool {
+	if a == b {
+		return true
+	}
+	n1, err1 := ParseE164Number(a)
+	n2, err2 := ParseE164Number(b)
+	return err1 == nil && err2 == nil && n1 == n2
+}
+
+// LegalHoldStore keeps legal holds which exempt recordings from retention policies
+type LegalHoldStore interface {
+	// IsOnHold returns true if the recording must be kept (call is nil if the recording has no call)
+	IsOnHold(recording *Recording, call *Call) (bool, error)
+}
+
+// MemoryLegalHoldStore keeps legal holds of recordings, calls and phone numbers in memory
+type MemoryLegalHoldStore struct {
+	mutex sync.Mutex
+	holds map[string]bool
+}
+
+// SetHold places (or releases) legal hold of a recording ID, call ID or phone number
+func (s *MemoryLegalHoldStore) SetHold(id string, held bool) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.holds == nil {
+		s.holds = map[string]bool{}
+	}
+	if held {
+		s.holds[id] = true
+	} else {
+		delete(s.holds, id)
+	}
+	return nil
+}
+
+// IsOnHold returns true if the recording, its call or a number of the call is on hold
+func (s *MemoryLegalHoldStore) IsOnHold(recording *Recording, call *Call) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.holds[recording.ID] || s.holds[getIDFromLocation(recording.Call)] {
+		return true, nil
+	}
+	if call == nil {
+		return false, nil
+	}
+	for id := range s.holds {
+		if sameNumber(id, call.From) || sameNumber(id, call.To) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// RetentionAction is decision of retention job about a recording
+type RetentionAction string
+
+const (
+	// RetentionDelete means media of the recording is deleted
+	RetentionDelete RetentionAction = "delete"
+	// RetentionGone means the recording matches a policy but its media has been deleted before (e.g. by an earlier run)
+	RetentionGone RetentionAction = "gone"
+	// RetentionHold means the recording matches a policy but it is on legal hold
+	RetentionHold RetentionAction = "hold"
+	// RetentionError means the recording couldn't be checked or deleted
+	RetentionError RetentionAction = "error"
+)
+
+// RetentionItem describes a recording which matches a retention policy (it is also written to audit log)
+type RetentionItem struct {
+	RecordingID string          `json:"recordingId"`
+	CallID      string          `json:"callId,omitempty"`
+	MediaName   string          `json:"mediaName,omitempty"`
+	StartTime   string          `json:"startTime,omitempty"`
+	EndTime     string          `json:"endTime,omitempty"`
+	Tag         string          `json:"tag,omitempty"`
+	From        string          `json:"from,omitempty"`
+	To          string          `json:"to,omitempty"`
+	Policy      string          `json:"policy,omitempty"`
+	Action      RetentionAction `json:"action"`
+	Error       string          `json:"error,omitempty"`
+	DryRun      bool            `json:"dryRun,omitempty"`
+	Time        time.Time       `json:"time"`
+}
+
+// RetentionReport is result of RetentionJob.Run()
+type RetentionReport struct {
+	DryRun bool `json:"dryRun"`
+	// Checked is count of checked recordings
+	Checked int `json:"checked"`
+	// Items contains recordings which match policies (recordings which are kept are not included)
+	Items []*RetentionItem `json:"items"`
+}
+
+// Summary returns count of recordings per action
+func (r *RetentionReport) Summary() map[RetentionAction]int {
+	summary := map[RetentionAction]int{}
+	for _, item := range r.Items {
+		summary[item.Action]++
+	}
+	return summary
+}
+
+// WriteCSV writes the report in CSV format (one row per recording)
+func (r *RetentionReport) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"recordingId", "callId", "mediaName", "startTime", "endTime", "tag", "from", "to", "policy", "action", "error"})
+	for _, item := range r.Items {
+		writer.Write([]string{item.RecordingID, item.CallID, item.MediaName, item.StartTime, item.EndTime,
+			item.Tag, item.From, item.To, item.Policy, string(item.Action), item.Error})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteJSON writes the report in JSON format
+func (r *RetentionReport) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(r)
+}
+
+// RetentionJob deletes media of recordings which match retention policies
+// Calls of recordings are requested only for policies with Tags or Numbers and for checking of legal holds.
+// Recordings which aren't complete are kept.
+type RetentionJob struct {
+	API      *Client
+	Policies []*RetentionPolicy
+	// LegalHolds exempts recordings from deletion (optional)
+	LegalHolds LegalHoldStore
+	// AuditLog receives JSON line (RetentionItem) for each deleted recording and each failed deletion (optional)
+	AuditLog io.Writer
+	// DryRun only builds the report without deleting of recordings (nothing is written to AuditLog)
+	DryRun bool
+}
+
+// NewRetentionJob creates new RetentionJob instance
+// example: job := bandwidth.NewRetentionJob(api, &bandwidth.RetentionPolicy{Name: "90 days", MaxAge: 90 * 24 * time.Hour})
+func NewRetentionJob(api *Client, policies ...*RetentionPolicy) *RetentionJob {
+	return &RetentionJob{API: api, Policies: policies}
+}
+
+// recordingAge returns age of the recording by its EndTime (or StartTime)
+func recordingAge(recording *Recording, now time.Time) (time.Duration, error) {
+	value := recording.EndTime
+	if value == "" {
+		value = recording.StartTime
+	}
+	if value == "" {
+		return 0, fmt.Errorf("Recording %s has no time", recording.ID)
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return 0, err
+	}
+	return now.Sub(t), nil
+}
+
+// Run checks all recordings (all pages of GetRecordings() are requested) and deletes media of matched recordings
+// Failures of single recordings don't stop the job, but the job is stopped if AuditLog can't be written.
+// It returns RetentionReport instance and error (if listing of recordings, audit log or some recordings have failed)
+// example: report, err := job.Run(ctx)
+func (j *RetentionJob) Run(ctx context.Context) (*RetentionReport, error) {
+	report := &RetentionReport{DryRun: j.DryRun, Items: []*RetentionItem{}}
+	if len(j.Policies) == 0 {
+		return report, fmt.Errorf("No retention policies")
+	}
+	recordings, err := j.API.GetAllRecordings()
+	if err != nil {
+		return report, err
+	}
+	now := time.Now()
+	calls := map[string]*Call{}
+	failed := 0
+	for _, recording := range recordings {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		if recording.State != "complete" {
+			continue
+		}
+		report.Checked++
+		item := j.check(recording, now, calls)
+		if item == nil {
+			continue
+		}
+		report.Items = append(report.Items, item)
+		if item.Action == RetentionDelete && !j.DryRun {
+			if err := j.API.DeleteMediaFile(item.MediaName); isNotFoundError(err) {
+				item.Action = RetentionGone
+			} else if err != nil {
+				item.Action, item.Error = RetentionError, err.Error()
+			}
+			item.Time = time.Now().UTC()
+			if err := j.audit(item); err != nil {
+				return report, err
+			}
+		}
+		if item.Action == RetentionError {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return report, fmt.Errorf("%d of %d matched recordings have failed", failed, len(report.Items))
+	}
+	return report, nil
+}
+
+// check returns item of the recording if it matches a policy (nil otherwise)
+// calls caches requested calls by their IDs
+func (j *RetentionJob) check(recording *Recording, now time.Time, calls map[string]*Call) *RetentionItem {
+	item := &RetentionItem{
+		RecordingID: recording.ID,
+		CallID:      getIDFromLocation(recording.Call),
+		MediaName:   recordingMediaName(recording),
+		StartTime:   recording.StartTime,
+		EndTime:     recording.EndTime,
+		Action:      RetentionDelete,
+		DryRun:      j.DryRun,
+		Time:        now.UTC(),
+	}
+	fail := func(err error) *RetentionItem {
+		item.Action, item.Error = RetentionError, err.Error()
+		return item
+	}
+	age, err := recordingAge(recording, now)
+	if err != nil {
+		return fail(err)
+	}
+	var call *Call
+	getCall := func() error {
+		if call != nil {
+			return nil
+		}
+		if call = calls[item.CallID]; call == nil {
+			if item.CallID == "" {
+				return fmt.Errorf("Recording %s has no call", recording.ID)
+			}
+			if call, err = j.API.GetCall(item.CallID); err != nil {
+				return err
+			}
+			calls[item.CallID] = call
+		}
+		item.Tag, item.From, item.To = call.Tag, call.From, call.To
+		return nil
+	}
+	matched := false
+	for _, policy := range j.Policies {
+		if age < policy.MaxAge {
+			continue
+		}
+		if policy.needsCall() {
+			if err := getCall(); err != nil {
+				return fail(err)
+			}
+		}
+		if policy.matches(age, call) {
+			item.Policy, matched = policy.Name, true
+			break
+		}
+	}
+	if !matched {
+		return nil
+	}
+	if item.MediaName == "" {
+		return fail(fmt.Errorf("Recording %s has no media", recording.ID))
+	}
+	if j.LegalHolds != nil {
+		if item.CallID != "" {
+			// holds of phone numbers are checked by the call
+			if err := getCall(); err != nil {
+				return fail(err)
+			}
+		}
+		held, err := j.LegalHolds.IsOnHold(recording, call)
+		if err != nil {
+			return fail(err)
+		}
+		if held {
+			item.Action = RetentionHold
+		}
+	}
+	return item
+}
+
+// audit writes the item to AuditLog
+func (j *RetentionJob) audit(item *RetentionItem) error {
+	if j.AuditLog == nil {
+		return nil
+	}
+	return json.NewEncoder(j.AuditLog).Encode(item)
+}
@@ -0,0 +1,178 @@
+package bandwidth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func startRetentionMockServer(t *testing.T, failedMedia string) (*httptest.Server, *Client, func() []string) {
+	recent := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	handlers := []RequestHandler{
+		RequestHandler{
+			PathAndQuery:     "/v1/users/userId/media/" + failedMedia,
+			Method:           http.MethodDelete,
+			StatusCodeToSend: http.StatusInternalServerError},
+		RequestHandler{
+			// media of r1 have been deleted before
+			PathAndQuery:     "/v1/users/userId/media/r1.wav",
+			Method:           http.MethodDelete,
+			StatusCodeToSend: http.StatusNotFound},
+		RequestHandler{
+			PathAndQuery: "/v1/users/userId/recordings?size=1000",
+			ContentToSend: fmt.Sprintf(`[
+				{"id": "r1", "state": "complete", "call": "https://api.catapult.inetwork.com/v1/users/userId/calls/c1", "media": "https://api.catapult.inetwork.com/v1/users/userId/media/r1.wav", "startTime": "2017-01-01T10:00:00Z", "endTime": "2017-01-01T10:01:00Z"},
+				{"id": "r2", "state": "complete", "call": "https://api.catapult.inetwork.com/v1/users/userId/calls/c2", "media": "https://api.catapult.inetwork.com/v1/users/userId/media/r2.wav", "startTime": "2017-01-02T10:00:00Z"},
+				{"id": "r3", "state": "complete", "call": "https://api.catapult.inetwork.com/v1/users/userId/calls/c3", "media": "https://api.catapult.inetwork.com/v1/users/userId/media/r3.wav", "endTime": "%s"},
+				{"id": "r4", "state": "complete", "call": "https://api.catapult.inetwork.com/v1/users/userId/calls/c4", "media": "https://api.catapult.inetwork.com/v1/users/userId/media/r4.wav", "endTime": "2017-01-04T10:01:00Z"},
+				{"id": "r5", "state": "recording", "call": "https://api.catapult.inetwork.com/v1/users/userId/calls/c5"},
+				{"id": "r6", "state": "complete", "call": "https://api.catapult.inetwork.com/v1/users/userId/calls/c6", "media": "https://api.catapult.inetwork.com/v1/users/userId/media/r6.wav", "endTime": "2017-01-06T10:01:00Z"}]`, recent)},
+		RequestHandler{PathAndQuery: "/v1/users/userId/calls/c1", ContentToSend: `{"id": "c1", "tag": "support", "from": "+19195551111", "to": "+19195550000"}`},
+		RequestHandler{PathAndQuery: "/v1/users/userId/calls/c2", ContentToSend: `{"id": "c2", "tag": "sales", "from": "+19195550000", "to": "+19195552222"}`},
+		RequestHandler{PathAndQuery: "/v1/users/userId/calls/c3", ContentToSend: `{"id": "c3", "tag": "support", "from": "+19195551111", "to": "+19195550000"}`},
+		RequestHandler{PathAndQuery: "/v1/users/userId/calls/c4", ContentToSend: `{"id": "c4", "tag": "other", "from": "+19195553333", "to": "+19195550000"}`},
+		RequestHandler{PathAndQuery: "/v1/users/userId/calls/c6", ContentToSend: `{"id": "c6", "tag": "support", "from": "+19195554444", "to": "+19195550000"}`},
+	}
+	for _, name := range []string{"r2.wav", "r3.wav", "r4.wav", "r6.wav"} {
+		handlers = append(handlers, RequestHandler{PathAndQuery: "/v1/users/userId/media/" + name, Method: http.MethodDelete})
+	}
+	return startMockServerWithHandlersAndLog(t, handlers, http.StatusNotFound)
+}
+
+func retentionPolicies() []*RetentionPolicy {
+	return []*RetentionPolicy{
+		&RetentionPolicy{Name: "support", MaxAge: 30 * 24 * time.Hour, Tags: []string{"support"}},
+		&RetentionPolicy{Name: "vip", MaxAge: 24 * time.Hour, Numbers: []string{"(919) 555-2222"}},
+	}
+}
+
+func deleteRequests(log []string) []string {
+	list := []string{}
+	for _, line := range log {
+		if strings.HasPrefix(line, http.MethodDelete) {
+			list = append(list, line)
+		}
+	}
+	return list
+}
+
+func retentionActions(report *RetentionReport) map[string]string {
+	result := map[string]string{}
+	for _, item := range report.Items {
+		result[item.RecordingID] = string(item.Action) + ":" + item.Policy
+	}
+	return result
+}
+
+func TestRetentionJobRun(t *testing.T) {
+	server, api, log := startRetentionMockServer(t, "")
+	defer server.Close()
+	holds := &MemoryLegalHoldStore{}
+	holds.SetHold("r6", true)
+	audit := &bytes.Buffer{}
+	job := NewRetentionJob(api, retentionPolicies()...)
+	job.LegalHolds = holds
+	job.AuditLog = audit
+	report, err := job.Run(context.Background())
+	expectNil(t, err)
+	expect(t, report.Checked, 5)
+	expect(t, retentionActions(report), map[string]string{"r1": "gone:support", "r2": "delete:vip", "r6": "hold:support"})
+	expect(t, report.Summary(), map[RetentionAction]int{RetentionGone: 1, RetentionDelete: 1, RetentionHold: 1})
+	expect(t, deleteRequests(log()), []string{"DELETE /v1/users/userId/media/r1.wav", "DELETE /v1/users/userId/media/r2.wav"})
+	lines := strings.Split(strings.TrimSpace(audit.String()), "\n")
+	expect(t, len(lines), 2)
+	item := &RetentionItem{}
+	expectNil(t, json.Unmarshal([]byte(lines[0]), item))
+	expect(t, item.RecordingID, "r1")
+	expect(t, item.Action, RetentionGone)
+	item = &RetentionItem{}
+	expectNil(t, json.Unmarshal([]byte(lines[1]), item))
+	expect(t, item.RecordingID, "r2")
+	expect(t, item.CallID, "c2")
+	expect(t, item.To, "+19195552222")
+	expect(t, item.Policy, "vip")
+	expect(t, item.Action, RetentionDelete)
+	expect(t, item.DryRun, false)
+}
+
+func TestRetentionJobRunDryRun(t *testing.T) {
+	server, api, log := startRetentionMockServer(t, "")
+	defer server.Close()
+	audit := &bytes.Buffer{}
+	job := NewRetentionJob(api, retentionPolicies()...)
+	job.AuditLog = audit
+	job.DryRun = true
+	report, err := job.Run(context.Background())
+	expectNil(t, err)
+	expect(t, report.DryRun, true)
+	expect(t, retentionActions(report), map[string]string{"r1": "delete:support", "r2": "delete:vip", "r6": "delete:support"})
+	expect(t, report.Items[0].DryRun, true)
+	expect(t, deleteRequests(log()), []string{})
+	expect(t, audit.Len(), 0)
+}
+
+func TestRetentionJobRunByAge(t *testing.T) {
+	server, api, log := startRetentionMockServer(t, "")
+	defer server.Close()
+	job := NewRetentionJob(api, &RetentionPolicy{MaxAge: 365 * 24 * time.Hour})
+	job.DryRun = true
+	report, err := job.Run(context.Background())
+	expectNil(t, err)
+	expect(t, retentionActions(report), map[string]string{"r1": "delete:", "r2": "delete:", "r4": "delete:", "r6": "delete:"})
+	for _, line := range log() {
+		if strings.Contains(line, "/calls/") {
+			t.Errorf("Unexpected request %s", line)
+		}
+	}
+}
+
+func TestRetentionJobRunWithNumberHold(t *testing.T) {
+	server, api, _ := startRetentionMockServer(t, "")
+	defer server.Close()
+	holds := &MemoryLegalHoldStore{}
+	holds.SetHold("(919) 555-1111", true)
+	holds.SetHold("c2", true)
+	holds.SetHold("c2", false)
+	job := NewRetentionJob(api, &RetentionPolicy{MaxAge: 365 * 24 * time.Hour})
+	job.LegalHolds = holds
+	job.DryRun = true
+	report, err := job.Run(context.Background())
+	expectNil(t, err)
+	expect(t, retentionActions(report), map[string]string{"r1": "hold:", "r2": "delete:", "r4": "delete:", "r6": "delete:"})
+}
+
+func TestRetentionJobRunFail(t *testing.T) {
+	server, api, _ := startRetentionMockServer(t, "r2.wav")
+	defer server.Close()
+	audit := &bytes.Buffer{}
+	job := NewRetentionJob(api, retentionPolicies()...)
+	job.AuditLog = audit
+	report, err := job.Run(context.Background())
+	expect(t, err.Error(), "1 of 3 matched recordings have failed")
+	expect(t, retentionActions(report), map[string]string{"r1": "gone:support", "r2": "error:vip", "r6": "delete:support"})
+	expect(t, strings.Count(audit.String(), `"action":"error"`), 1)
+}
+
+func TestRetentionJobRunWithoutPolicies(t *testing.T) {
+	api := getAPI()
+	_, err := NewRetentionJob(api).Run(context.Background())
+	expect(t, err.Error(), "No retention policies")
+}
+
+func TestRetentionReportWriteCSV(t *testing.T) {
+	report := &RetentionReport{Items: []*RetentionItem{
+		&RetentionItem{RecordingID: "r1", CallID: "c1", MediaName: "r1.wav", EndTime: "2017-01-01T10:01:00Z", Tag: "support", Policy: "support", Action: RetentionDelete},
+		&RetentionItem{RecordingID: "r2", Action: RetentionError, Error: "error"},
+	}}
+	buffer := &bytes.Buffer{}
+	expectNil(t, report.WriteCSV(buffer))
+	expect(t, buffer.String(), "recordingId,callId,mediaName,startTime,endTime,tag,from,to,policy,action,error\n"+
+		"r1,c1,r1.wav,,2017-01-01T10:01:00Z,support,,,support,delete,\n"+
+		"r2,,,,,,,,,error,error\n")
+}
@@ -1,7 +1,9 @@
 package bandwidth
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"net/http"
 )
 
@@ -37,6 +39,31 @@ func (api *Client) GetRecordings(query ...*GetRecordingsQuery) ([]*Recording, er
 	return *(result.(*[]*Recording)), nil
 }
 
+// GetAllRecordings returns recordings of all pages
+// query.Size is size of requested pages (1000 by default), pages are requested from query.Page
+// It returns list of Recording instances or error
+func (api *Client) GetAllRecordings(query ...*GetRecordingsQuery) ([]*Recording, error) {
+	options := GetRecordingsQuery{}
+	if len(query) > 0 && query[0] != nil {
+		options = *query[0]
+	}
+	if options.Size == 0 {
+		options.Size = 1000
+	}
+	all := []*Recording{}
+	for {
+		list, err := api.GetRecordings(&options)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, list...)
+		if len(list) < options.Size {
+			return all, nil
+		}
+		options.Page++
+	}
+}
+
 // GetRecording returns  a single call recording
 // It a Recording instance or error
 func (api *Client) GetRecording(id string) (*Recording, error) {
@@ -46,3 +73,41 @@ func (api *Client) GetRecording(id string) (*Recording, error) {
 	}
 	return result.(*Recording), nil
 }
+
+// DownloadRecording downloads media of a recording to w
+// If w implements io.WriterAt (e.g. *os.File) media is downloaded with Range requests which are resumed after network failures (see DownloadMediaTo()).
+// It returns size of downloaded media or error
+// example: file, _ := os.Create("recording.wav"); size, err := api.DownloadRecording(ctx, "recordingId", file)
+func (api *Client) DownloadRecording(ctx context.Context, id string, w io.Writer) (int64, error) {
+	recording, err := api.GetRecording(id)
+	if err != nil {
+		return 0, err
+	}
+	size, _, err := api.downloadRecording(ctx, recording, w)
+	return size, err
+}
+
+// recordingMediaName returns name of media file of the recording
+func recordingMediaName(recording *Recording) string {
+	return getIDFromLocation(recording.Media)
+}
+
+// downloadRecording downloads media of the recording to w
+// It returns size and content type of the media (content type is empty if w is io.WriterAt) or error
+func (api *Client) downloadRecording(ctx context.Context, recording *Recording, w io.Writer) (int64, string, error) {
+	name := recordingMediaName(recording)
+	if name == "" {
+		return 0, "", fmt.Errorf("Recording %s has no media (state %s)", recording.ID, recording.State)
+	}
+	if writer, ok := w.(io.WriterAt); ok {
+		size, err := api.DownloadMediaTo(ctx, name, writer)
+		return size, "", err
+	}
+	download, err := api.DownloadMedia(ctx, name)
+	if err != nil {
+		return 0, "", err
+	}
+	defer download.Body.Close()
+	size, err := io.Copy(w, download.Body)
+	return size, download.ContentType, err
+}
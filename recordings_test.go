@@ -1,7 +1,11 @@
 package bandwidth
 
 import (
+	"bytes"
+	"context"
+	"io/ioutil"
 	"net/http"
+	"os"
 	"testing"
 )
 
@@ -79,3 +83,81 @@ func TestGetRecordingFail(t *testing.T) {
 	defer server.Close()
 	shouldFail(t, func() (interface{}, error) { return api.GetRecording("123") })
 }
+
+func TestGetAllRecordings(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery:  "/v1/users/userId/recordings?size=2",
+		Method:        http.MethodGet,
+		ContentToSend: `[{"id": "1"}, {"id": "2"}]`,
+	}, RequestHandler{
+		PathAndQuery:  "/v1/users/userId/recordings?page=1&size=2",
+		Method:        http.MethodGet,
+		ContentToSend: `[{"id": "3"}]`,
+	}})
+	defer server.Close()
+	result, err := api.GetAllRecordings(&GetRecordingsQuery{Size: 2})
+	expectNil(t, err)
+	expect(t, len(result), 3)
+	expect(t, result[2].ID, "3")
+}
+
+func TestGetAllRecordingsFail(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery:     "/v1/users/userId/recordings?size=1000",
+		Method:           http.MethodGet,
+		StatusCodeToSend: http.StatusBadRequest}})
+	defer server.Close()
+	shouldFail(t, func() (interface{}, error) { return api.GetAllRecordings() })
+}
+
+func TestDownloadRecording(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery:  "/v1/users/userId/recordings/123",
+		Method:        http.MethodGet,
+		ContentToSend: `{"id": "123", "state": "complete", "media": "https://api.catapult.inetwork.com/v1/users/userId/media/123.wav"}`,
+	}, RequestHandler{
+		PathAndQuery:  "/v1/users/userId/media/123.wav",
+		Method:        http.MethodGet,
+		HeadersToSend: map[string]string{"Content-Type": "audio/wav"},
+		ContentToSend: "audio",
+	}})
+	defer server.Close()
+	buffer := &bytes.Buffer{}
+	size, err := api.DownloadRecording(context.Background(), "123", buffer)
+	expectNil(t, err)
+	expect(t, size, int64(6))
+	expect(t, buffer.String(), "audio\n")
+	file, err := ioutil.TempFile("", "recording")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+	size, err = api.DownloadRecording(context.Background(), "123", file)
+	expectNil(t, err)
+	expect(t, size, int64(6))
+	data, _ := ioutil.ReadFile(file.Name())
+	expect(t, string(data), "audio\n")
+}
+
+func TestDownloadRecordingWithoutMedia(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery:  "/v1/users/userId/recordings/123",
+		Method:        http.MethodGet,
+		ContentToSend: `{"id": "123", "state": "recording"}`,
+	}})
+	defer server.Close()
+	_, err := api.DownloadRecording(context.Background(), "123", &bytes.Buffer{})
+	expect(t, err.Error(), "Recording 123 has no media (state recording)")
+}
+
+func TestDownloadRecordingFail(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery:     "/v1/users/userId/recordings/123",
+		Method:           http.MethodGet,
+		StatusCodeToSend: http.StatusNotFound,
+	}})
+	defer server.Close()
+	_, err := api.DownloadRecording(context.Background(), "123", &bytes.Buffer{})
+	expect(t, err != nil, true)
+}
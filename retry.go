@@ -0,0 +1,116 @@
+package bandwidth
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy describes retries of failed requests (see Client.RetryPolicy)
+type RetryPolicy struct {
+	// MaxRetries is max count of retries of a request
+	MaxRetries int
+	// Delay is delay before first retry (it is doubled for each next retry; rate limit errors are retried after reset time)
+	Delay time.Duration
+	// IsTransient returns true for errors which should be retried (IsTransientError is used by default)
+	IsTransient func(err error) bool
+}
+
+// IsTransientError returns true for errors which can disappear on retry: rate limit errors, 5xx http errors and
+// network errors like timeouts, refused or reset connections. Other network errors (invalid URL, TLS or DNS errors) are not transient.
+func IsTransientError(err error) bool {
+	switch e := err.(type) {
+	case *RateLimitError:
+		return true
+	case *HTTPError:
+		return e.StatusCode >= 500
+	case *url.Error:
+		return isTransientNetworkError(e.Err)
+	}
+	return isTransientNetworkError(err)
+}
+
+func isTransientNetworkError(err error) bool {
+	if e, ok := err.(net.Error); ok && (e.Timeout() || e.Temporary()) {
+		return true
+	}
+	switch e := err.(type) {
+	case *net.OpError:
+		return isTransientNetworkError(e.Err)
+	case *os.SyscallError:
+		return isTransientNetworkError(e.Err)
+	case syscall.Errno:
+		return e == syscall.ECONNREFUSED || e == syscall.ECONNRESET || e == syscall.ECONNABORTED || e == syscall.EPIPE
+	}
+	// a connection closed by the server while the response is read
+	return err == io.EOF || err == io.ErrUnexpectedEOF
+}
+
+// sleepContext waits for duration d or until ctx is done
+// It returns error of ctx if it is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (p *RetryPolicy) delay(attempt int, err error) time.Duration {
+	if e, ok := err.(*RateLimitError); ok {
+		return e.Reset.Sub(time.Now())
+	}
+	return p.Delay << uint(attempt-1)
+}
+
+func (p *RetryPolicy) shouldRetry(attempt int, err error) bool {
+	if p == nil || attempt > p.MaxRetries {
+		return false
+	}
+	isTransient := p.IsTransient
+	if isTransient == nil {
+		isTransient = IsTransientError
+	}
+	return isTransient(err)
+}
+
+// doWithRetry sends requests created by newRequest until a response is successful or RetryPolicy stops retries
+// newRequest is called for each attempt and returns false if the request can't be repeated
+// It returns successful response (its body should be closed by caller) or error
+func (api *Client) doWithRetry(ctx context.Context, newRequest func(attempt int) (*http.Request, bool, error)) (*http.Response, error) {
+	attempt := 0
+	for {
+		attempt++
+		request, canRetry, err := newRequest(attempt)
+		if err != nil {
+			return nil, err
+		}
+		response, err := api.HTTPClient.Do(request.WithContext(ctx))
+		if err == nil {
+			if response.StatusCode < 400 {
+				return response, nil
+			}
+			err = api.responseError(response)
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if !canRetry || !api.RetryPolicy.shouldRetry(attempt, err) {
+			return nil, err
+		}
+		if sleepContext(ctx, api.RetryPolicy.delay(attempt, err)) != nil {
+			return nil, err
+		}
+	}
+}
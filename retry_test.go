@@ -0,0 +1,47 @@
+package bandwidth
+
+import (
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestIsTransientError(t *testing.T) {
+	expect(t, IsTransientError(&RateLimitError{}), true)
+	expect(t, IsTransientError(&HTTPError{StatusCode: 503}), true)
+	expect(t, IsTransientError(&HTTPError{StatusCode: 400}), false)
+	expect(t, IsTransientError(&HTTPError{StatusCode: 500, Message: "Internal error"}), true)
+	expect(t, IsTransientError(errors.New("invalid number")), false)
+	urlError := func(err error) error {
+		return &url.Error{Op: "Post", URL: "https://api.catapult.inetwork.com", Err: err}
+	}
+	expect(t, IsTransientError(urlError(&net.DNSError{Err: "timeout", Name: "host", IsTimeout: true})), true)
+	expect(t, IsTransientError(urlError(&net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)})), true)
+	expect(t, IsTransientError(urlError(&net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)})), true)
+	expect(t, IsTransientError(urlError(io.EOF)), true)
+	expect(t, IsTransientError(urlError(&net.DNSError{Err: "no such host", Name: "host"})), false)
+	expect(t, IsTransientError(urlError(x509.UnknownAuthorityError{})), false)
+	expect(t, IsTransientError(urlError(errors.New("unsupported protocol scheme"))), false)
+}
+
+func TestRetryPolicy(t *testing.T) {
+	var policy *RetryPolicy
+	expect(t, policy.shouldRetry(1, &HTTPError{StatusCode: 500}), false)
+	policy = &RetryPolicy{MaxRetries: 2, Delay: time.Second}
+	expect(t, policy.shouldRetry(1, &HTTPError{StatusCode: 500}), true)
+	expect(t, policy.shouldRetry(2, &RateLimitError{}), true)
+	expect(t, policy.shouldRetry(3, &HTTPError{StatusCode: 500}), false)
+	expect(t, policy.shouldRetry(1, &HTTPError{StatusCode: 400}), false)
+	expect(t, policy.delay(1, &HTTPError{StatusCode: 500}), time.Second)
+	expect(t, policy.delay(3, &HTTPError{StatusCode: 500}), 4*time.Second)
+	reset := policy.delay(1, &RateLimitError{Reset: time.Now().Add(time.Minute)})
+	expect(t, reset > 50*time.Second && reset <= time.Minute, true)
+	policy.IsTransient = func(err error) bool { return true }
+	expect(t, policy.shouldRetry(1, &HTTPError{StatusCode: 400}), true)
+}
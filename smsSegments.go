@@ -0,0 +1,172 @@
+package bandwidth
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// SMSEncoding is encoding of SMS text
+type SMSEncoding string
+
+const (
+	// SMSEncodingGSM7 is GSM 03.38 7-bit default alphabet
+	SMSEncodingGSM7 SMSEncoding = "GSM-7"
+	// SMSEncodingUCS2 is UCS-2 (UTF-16) encoding used for texts with characters out of GSM-7 alphabet
+	SMSEncodingUCS2 SMSEncoding = "UCS-2"
+)
+
+const (
+	gsm7SingleSegmentSize = 160
+	gsm7MultiSegmentSize  = 153 // 7 septets are used by concatenation header
+	ucs2SingleSegmentSize = 70
+	ucs2MultiSegmentSize  = 67 // 3 characters are used by concatenation header
+)
+
+var gsm7Basic = map[rune]bool{}
+var gsm7Extension = map[rune]bool{}
+
+func init() {
+	for _, c := range "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+		"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà" {
+		gsm7Basic[c] = true
+	}
+	for _, c := range "\f^{}\\[~]|€" {
+		gsm7Extension[c] = true
+	}
+}
+
+var gsm7Transliterations = map[rune]string{
+	'‘': "'", '’': "'", '‚': "'", '‛': "'", '′': "'", '`': "'", '´': "'",
+	'“': "\"", '”': "\"", '„': "\"", '‟': "\"", '″': "\"", '«': "\"", '»': "\"",
+	'‐': "-", '‑': "-", '‒': "-", '–': "-", '—': "-", '―': "-", '−': "-",
+	'…': "...", '\u00a0': " ", '\u2009': " ", '\u202f': " ", '\t': " ", '•': "*", 'ˆ': "^", '˜': "~",
+}
+
+// SMSSegmentInfo describes how a text is sent as SMS
+type SMSSegmentInfo struct {
+	Encoding SMSEncoding
+	// Segments is count of SMS parts (0 for empty text)
+	Segments int
+	// Length is length of the text in encoding units (septets for GSM-7, UTF-16 code units for UCS-2)
+	Length int
+	// SegmentSize is max count of encoding units in one segment
+	SegmentSize int
+	// Remaining is count of encoding units which can be added to the last segment
+	Remaining int
+	// NonGSMCharacters is list of characters which forced UCS-2 encoding
+	NonGSMCharacters []rune
+}
+
+// CalculateSMSSegments detects encoding of the text and counts SMS segments required to send it
+// It returns SMSSegmentInfo instance
+// example: info := bandwidth.CalculateSMSSegments("Hello “world”")
+func CalculateSMSSegments(text string) *SMSSegmentInfo {
+	info := &SMSSegmentInfo{Encoding: SMSEncodingGSM7}
+	seen := map[rune]bool{}
+	for _, c := range text {
+		if !gsm7Basic[c] && !gsm7Extension[c] {
+			info.Encoding = SMSEncodingUCS2
+			if !seen[c] {
+				seen[c] = true
+				info.NonGSMCharacters = append(info.NonGSMCharacters, c)
+			}
+		}
+	}
+	single, multi := gsm7SingleSegmentSize, gsm7MultiSegmentSize
+	if info.Encoding == SMSEncodingUCS2 {
+		single, multi = ucs2SingleSegmentSize, ucs2MultiSegmentSize
+	}
+	sizes := []int{}
+	for _, c := range text {
+		sizes = append(sizes, info.unitSize(c))
+		info.Length += sizes[len(sizes)-1]
+	}
+	if info.Length == 0 {
+		info.SegmentSize, info.Remaining = single, single
+		return info
+	}
+	if info.Length <= single {
+		info.Segments, info.SegmentSize, info.Remaining = 1, single, single-info.Length
+		return info
+	}
+	// a character (GSM-7 escape sequence or UTF-16 surrogate pair) can't be split between segments
+	info.Segments, info.SegmentSize = 1, multi
+	used := 0
+	for _, size := range sizes {
+		if used+size > multi {
+			info.Segments++
+			used = 0
+		}
+		used += size
+	}
+	info.Remaining = multi - used
+	return info
+}
+
+func (info *SMSSegmentInfo) unitSize(c rune) int {
+	if info.Encoding == SMSEncodingGSM7 {
+		if gsm7Extension[c] {
+			return 2
+		}
+		return 1
+	}
+	if c > 0xFFFF {
+		return 2
+	}
+	return 1
+}
+
+// TransliterateToGSM7 replaces typographic characters (smart quotes, dashes, ellipsis, special spaces)
+// with their GSM-7 equivalents. Other characters are kept as is.
+// example: text := bandwidth.TransliterateToGSM7("It’s “fine” — really…") // It's "fine" - really...
+func TransliterateToGSM7(text string) string {
+	var buffer bytes.Buffer
+	for _, c := range text {
+		if replacement, ok := gsm7Transliterations[c]; ok {
+			buffer.WriteString(replacement)
+		} else {
+			buffer.WriteRune(c)
+		}
+	}
+	return buffer.String()
+}
+
+// SMSSegmentLimitError is returned by SMSSegmentLimit for texts which exceed the limits
+type SMSSegmentLimitError struct {
+	Info   *SMSSegmentInfo
+	Reason string
+}
+
+func (e *SMSSegmentLimitError) Error() string {
+	message := e.Reason
+	if len(e.Info.NonGSMCharacters) > 0 {
+		message += fmt.Sprintf(" (UCS-2 encoding is required by characters %q)", string(e.Info.NonGSMCharacters))
+	}
+	return message
+}
+
+// SMSSegmentLimit is a MessageValidator which limits count of segments and encoding of texts
+// Messages with media (MMS) are not checked
+// example: api.MessageValidators = append(api.MessageValidators, &bandwidth.SMSSegmentLimit{MaxSegments: 3})
+type SMSSegmentLimit struct {
+	// MaxSegments is max count of segments (0 means unlimited)
+	MaxSegments int
+	// RequireGSM7 rejects texts which require UCS-2 encoding
+	RequireGSM7 bool
+}
+
+// ValidateMessage checks the text of the message
+// It returns SMSSegmentLimitError for texts which exceed the limits
+func (l *SMSSegmentLimit) ValidateMessage(message *OutgoingMessage) error {
+	if len(message.Media) > 0 {
+		return nil
+	}
+	info := CalculateSMSSegments(message.Text)
+	if l.RequireGSM7 && info.Encoding != SMSEncodingGSM7 {
+		return &SMSSegmentLimitError{Info: info, Reason: "Text can't be encoded with GSM-7"}
+	}
+	if l.MaxSegments > 0 && info.Segments > l.MaxSegments {
+		return &SMSSegmentLimitError{Info: info, Reason: fmt.Sprintf("Text requires %d segments (max %d)", info.Segments, l.MaxSegments)}
+	}
+	return nil
+}
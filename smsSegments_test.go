@@ -0,0 +1,64 @@
+package bandwidth
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCalculateSMSSegments(t *testing.T) {
+	info := CalculateSMSSegments("")
+	expect(t, info.Segments, 0)
+	expect(t, info.Encoding, SMSEncodingGSM7)
+	info = CalculateSMSSegments("Hello")
+	expect(t, *info, SMSSegmentInfo{Encoding: SMSEncodingGSM7, Segments: 1, Length: 5, SegmentSize: 160, Remaining: 155})
+	expect(t, CalculateSMSSegments(strings.Repeat("a", 160)).Segments, 1)
+	info = CalculateSMSSegments(strings.Repeat("a", 161))
+	expect(t, info.Segments, 2)
+	expect(t, info.SegmentSize, 153)
+	expect(t, info.Remaining, 145)
+	// extension characters use 2 septets
+	info = CalculateSMSSegments(strings.Repeat("€", 80))
+	expect(t, info.Encoding, SMSEncodingGSM7)
+	expect(t, info.Length, 160)
+	expect(t, info.Segments, 1)
+	// escape sequence is not split between segments
+	info = CalculateSMSSegments(strings.Repeat("a", 152) + "[" + strings.Repeat("a", 10))
+	expect(t, info.Segments, 2)
+	expect(t, info.Remaining, 153-12)
+}
+
+func TestCalculateSMSSegmentsUCS2(t *testing.T) {
+	info := CalculateSMSSegments("It’s “fine”")
+	expect(t, info.Encoding, SMSEncodingUCS2)
+	expect(t, info.NonGSMCharacters, []rune{'’', '“', '”'})
+	expect(t, info.Segments, 1)
+	expect(t, info.SegmentSize, 70)
+	expect(t, CalculateSMSSegments(strings.Repeat("ж", 70)).Segments, 1)
+	info = CalculateSMSSegments(strings.Repeat("ж", 71))
+	expect(t, info.Segments, 2)
+	expect(t, info.SegmentSize, 67)
+	// surrogate pairs use 2 code units and are not split between segments
+	info = CalculateSMSSegments(strings.Repeat("ж", 66) + "😀" + strings.Repeat("ж", 3))
+	expect(t, info.Length, 71)
+	expect(t, info.Segments, 2)
+	expect(t, info.Remaining, 62)
+}
+
+func TestTransliterateToGSM7(t *testing.T) {
+	text := TransliterateToGSM7("It’s “fine” — really… ok")
+	expect(t, text, `It's "fine" - really... ok`)
+	expect(t, CalculateSMSSegments(text).Encoding, SMSEncodingGSM7)
+	expect(t, TransliterateToGSM7("привет"), "привет")
+}
+
+func TestSMSSegmentLimit(t *testing.T) {
+	limit := &SMSSegmentLimit{MaxSegments: 1}
+	expectNil(t, limit.ValidateMessage(&OutgoingMessage{Text: "Hello"}))
+	expectNil(t, limit.ValidateMessage(&OutgoingMessage{Text: strings.Repeat("a", 500), Media: []string{"http://localhost/1.png"}}))
+	err := limit.ValidateMessage(&OutgoingMessage{Text: strings.Repeat("a", 161)})
+	expect(t, err.Error(), "Text requires 2 segments (max 1)")
+	limit = &SMSSegmentLimit{RequireGSM7: true}
+	err = limit.ValidateMessage(&OutgoingMessage{Text: "“Hi”"})
+	expect(t, err.Error(), `Text can't be encoded with GSM-7 (UCS-2 encoding is required by characters "“”")`)
+	expect(t, err.(*SMSSegmentLimitError).Info.Encoding, SMSEncodingUCS2)
+}
@@ -1,15 +1,25 @@
 package bandwidth
 
 import (
+	"context"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"net/url"
+	"time"
 )
 
 const transcriptionsPath = "transcriptions"
 
+const (
+	defaultTranscriptionPollInterval    = time.Second
+	defaultTranscriptionMaxPollInterval = 30 * time.Second
+)
+
 // Transcription struct
 type Transcription struct {
 	ID                 string `json:"id"`
+	State              string `json:"state"`
 	ChargeableDuration int    `json:"chargeableDuration"`
 	Text               string `json:"text"`
 	TextSize           int    `json:"textSize"`
@@ -46,3 +56,149 @@ func (api *Client) GetRecordingTranscription(recordingID string, transcriptionID
 	}
 	return result.(*Transcription), nil
 }
+
+// TranscriptionOptions is optional parameters of TranscribeRecording()
+type TranscriptionOptions struct {
+	// PollInterval is interval before first check of the transcription state (1 second by default, it is doubled after each check)
+	PollInterval time.Duration
+	// MaxPollInterval limits interval between checks (30 seconds by default)
+	MaxPollInterval time.Duration
+	// Reuse enables use of an existing transcription of the recording (a transcription in progress is waited for)
+	// New transcription is created only if the recording has no completed or pending transcriptions.
+	Reuse bool
+}
+
+// TranscriptionResult is a completed transcription of a recording
+type TranscriptionResult struct {
+	ID          string
+	RecordingID string
+	// Text is full text of the transcription (it is downloaded from TextURL if the inline text is truncated)
+	Text               string
+	ChargeableDuration time.Duration
+	// Time is time of the transcription (zero if it is unknown)
+	Time time.Time
+	// Transcription is the transcription returned by API
+	Transcription *Transcription
+}
+
+// TranscriptionError is returned when a transcription has failed
+type TranscriptionError struct {
+	RecordingID     string
+	TranscriptionID string
+}
+
+func (e *TranscriptionError) Error() string {
+	return fmt.Sprintf("Transcription %s of recording %s has failed", e.TranscriptionID, e.RecordingID)
+}
+
+// TranscribeRecording creates a transcription of the recording and waits for its completion
+// State of the transcription is polled with increasing interval until it is completed, failed or ctx is done.
+// It returns TranscriptionResult instance, TranscriptionError if the transcription has failed or other error
+// example: result, err := api.TranscribeRecording(ctx, "recordingId")
+func (api *Client) TranscribeRecording(ctx context.Context, recordingID string, options ...*TranscriptionOptions) (*TranscriptionResult, error) {
+	o := TranscriptionOptions{}
+	if len(options) > 0 && options[0] != nil {
+		o = *options[0]
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = defaultTranscriptionPollInterval
+	}
+	if o.MaxPollInterval <= 0 {
+		o.MaxPollInterval = defaultTranscriptionMaxPollInterval
+	}
+	if o.MaxPollInterval < o.PollInterval {
+		o.MaxPollInterval = o.PollInterval
+	}
+	var transcription *Transcription
+	transcriptionID := ""
+	if o.Reuse {
+		existing, err := api.GetRecordingTranscriptions(recordingID)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range existing {
+			if t.State == "completed" {
+				transcription, transcriptionID = t, t.ID
+				break
+			}
+			if t.State != "error" && t.State != "failed" && transcriptionID == "" {
+				transcriptionID = t.ID
+			}
+		}
+	}
+	if transcriptionID == "" {
+		id, err := api.CreateRecordingTranscription(recordingID)
+		if err != nil {
+			return nil, err
+		}
+		transcriptionID = id
+	}
+	if transcription == nil {
+		var err error
+		err = pollWithBackoff(ctx, o.PollInterval, o.MaxPollInterval, func() (bool, error) {
+			if transcription, err = api.GetRecordingTranscription(recordingID, transcriptionID); err != nil {
+				return false, err
+			}
+			switch transcription.State {
+			case "completed":
+				return true, nil
+			case "error", "failed":
+				return false, &TranscriptionError{RecordingID: recordingID, TranscriptionID: transcriptionID}
+			}
+			return false, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	text, err := api.transcriptionText(ctx, transcription)
+	if err != nil {
+		return nil, err
+	}
+	result := &TranscriptionResult{
+		ID:                 transcriptionID,
+		RecordingID:        recordingID,
+		Text:               text,
+		ChargeableDuration: time.Duration(transcription.ChargeableDuration) * time.Second,
+		Transcription:      transcription,
+	}
+	// the text is already paid for, so an unexpected time format leaves Time zero instead of failing
+	if t, err := time.Parse(time.RFC3339, transcription.Time); err == nil {
+		result.Time = t
+	}
+	return result, nil
+}
+
+// isAPIURL checks whether the url belongs to APIEndPoint of the client
+func (api *Client) isAPIURL(u *url.URL) bool {
+	endPoint, err := url.Parse(api.APIEndPoint)
+	return err == nil && u.Scheme == endPoint.Scheme && u.Host == endPoint.Host
+}
+
+// transcriptionText returns full text of the transcription (text is downloaded from TextURL if it is longer than the inline text)
+func (api *Client) transcriptionText(ctx context.Context, transcription *Transcription) (string, error) {
+	if transcription.TextURL == "" || (transcription.Text != "" && transcription.TextSize <= len(transcription.Text)) {
+		return transcription.Text, nil
+	}
+	response, err := api.doWithRetry(ctx, func(attempt int) (*http.Request, bool, error) {
+		request, err := http.NewRequest(http.MethodGet, transcription.TextURL, nil)
+		if err != nil {
+			return nil, false, err
+		}
+		if api.isAPIURL(request.URL) {
+			// credentials are not sent to other hosts
+			request.SetBasicAuth(api.APIToken, api.APISecret)
+		}
+		request.Header.Set("User-Agent", fmt.Sprintf("go-bandwidth/v%s", Version))
+		return request, true, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	text, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return "", err
+	}
+	return string(text), nil
+}
@@ -0,0 +1,152 @@
+package bandwidth
+
+import (
+	"context"
+	"errors"
+)
+
+// TransferOptions is optional parameters of BlindTransfer()
+type TransferOptions struct {
+	TransferCallerID string
+	// WhisperAudio is played to the target before connecting
+	WhisperAudio *PlayAudioData
+	// Tracker is used to wait for an answer of the target (BlindTransfer doesn't wait if it is nil)
+	Tracker *CallTracker
+}
+
+// BlindTransfer transfers the call to another number.
+// If options.Tracker is set it waits until the target answers; if the target doesn't answer
+// the new leg is hung up and the original call stays with the application.
+// It returns ID of the new call (to the target) or error
+// example: id, err := api.BlindTransfer(ctx, "callId", "+19195551212", nil)
+func (api *Client) BlindTransfer(ctx context.Context, callID, to string, options *TransferOptions) (string, error) {
+	if options == nil {
+		options = &TransferOptions{}
+	}
+	newCallID, err := api.UpdateCall(callID, &UpdateCallData{
+		State:            "transferring",
+		TransferTo:       to,
+		TransferCallerID: options.TransferCallerID,
+		WhisperAudio:     options.WhisperAudio,
+	})
+	if err != nil {
+		return "", err
+	}
+	if newCallID == "" {
+		return "", errors.New("Missing ID of the new call in Location header")
+	}
+	if options.Tracker == nil {
+		return newCallID, nil
+	}
+	if err := options.Tracker.WaitForState(ctx, newCallID, CallStateActive); err != nil {
+		if state, ok := options.Tracker.State(newCallID); !ok || !state.IsFinal() {
+			api.HangUpCall(newCallID)
+		}
+		return newCallID, err
+	}
+	return newCallID, nil
+}
+
+// AttendedTransferData struct
+type AttendedTransferData struct {
+	// BridgeID is ID of the bridge between the customer and the agent
+	BridgeID       string
+	CustomerCallID string
+	AgentCallID    string
+	// TransferTo is number of the target (e.g. other agent)
+	TransferTo       string
+	TransferCallerID string
+	CallbackURL      string
+	// CallTimeout is time (in seconds) to wait for an answer of the target
+	CallTimeout int
+	// HoldAudio is played to the customer while the agent talks with the target
+	HoldAudio *PlayAudioData
+	// Tracker is used to wait for an answer of the target (callbacks of CallbackURL should be passed to it)
+	Tracker *CallTracker
+}
+
+// AttendedTransfer is a consultation between the agent and the target while the customer is on hold
+type AttendedTransfer struct {
+	Data            *AttendedTransferData
+	TargetCallID    string
+	ConsultBridgeID string
+}
+
+// AttendedTransfer puts the customer on hold, calls the target and connects the agent with the target.
+// If the target doesn't answer, the customer and the agent are bridged again.
+// Use CompleteAttendedTransfer() to connect the customer with the target or CancelAttendedTransfer() to return to the customer.
+// It returns AttendedTransfer instance or error
+func (api *Client) AttendedTransfer(ctx context.Context, data *AttendedTransferData) (*AttendedTransfer, error) {
+	if data.Tracker == nil {
+		return nil, errors.New("Tracker is required for attended transfer")
+	}
+	if err := api.UpdateBridge(data.BridgeID, &BridgeData{BridgeAudio: true, CallIDs: []string{data.CustomerCallID}}); err != nil {
+		return nil, err
+	}
+	rollback := func(err error) (*AttendedTransfer, error) {
+		if data.HoldAudio != nil {
+			api.stopCallAudio(data.CustomerCallID)
+		}
+		api.UpdateBridge(data.BridgeID, &BridgeData{BridgeAudio: true, CallIDs: []string{data.CustomerCallID, data.AgentCallID}})
+		return nil, err
+	}
+	if data.HoldAudio != nil {
+		holdAudio := *data.HoldAudio
+		holdAudio.LoopEnabled = true
+		if err := api.PlayAudioToCall(data.CustomerCallID, &holdAudio); err != nil {
+			return rollback(err)
+		}
+	}
+	targetCallID, err := api.CreateCall(&CreateCallData{
+		From:        data.TransferCallerID,
+		To:          data.TransferTo,
+		CallbackURL: data.CallbackURL,
+		CallTimeout: data.CallTimeout,
+	})
+	if err != nil {
+		return rollback(err)
+	}
+	data.Tracker.Track(targetCallID, CallStateStarted)
+	if err := data.Tracker.WaitForState(ctx, targetCallID, CallStateActive); err != nil {
+		if state, _ := data.Tracker.State(targetCallID); !state.IsFinal() {
+			api.HangUpCall(targetCallID)
+		}
+		return rollback(err)
+	}
+	consultBridgeID, err := api.CreateBridge(&BridgeData{BridgeAudio: true, CallIDs: []string{data.AgentCallID, targetCallID}})
+	if err != nil {
+		api.HangUpCall(targetCallID)
+		return rollback(err)
+	}
+	return &AttendedTransfer{Data: data, TargetCallID: targetCallID, ConsultBridgeID: consultBridgeID}, nil
+}
+
+// CompleteAttendedTransfer moves the target to the customer's bridge and hangs up the agent
+// It returns error object
+func (api *Client) CompleteAttendedTransfer(transfer *AttendedTransfer) error {
+	data := transfer.Data
+	if data.HoldAudio != nil {
+		if err := api.stopCallAudio(data.CustomerCallID); err != nil {
+			return err
+		}
+	}
+	if err := api.UpdateBridge(data.BridgeID, &BridgeData{BridgeAudio: true, CallIDs: []string{data.CustomerCallID, transfer.TargetCallID}}); err != nil {
+		return err
+	}
+	return api.HangUpCall(data.AgentCallID)
+}
+
+// CancelAttendedTransfer hangs up the target and returns the agent to the customer's bridge
+// It returns error object
+func (api *Client) CancelAttendedTransfer(transfer *AttendedTransfer) error {
+	data := transfer.Data
+	if err := api.HangUpCall(transfer.TargetCallID); err != nil {
+		return err
+	}
+	if data.HoldAudio != nil {
+		if err := api.stopCallAudio(data.CustomerCallID); err != nil {
+			return err
+		}
+	}
+	return api.UpdateBridge(data.BridgeID, &BridgeData{BridgeAudio: true, CallIDs: []string{data.CustomerCallID, data.AgentCallID}})
+}
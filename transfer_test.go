@@ -0,0 +1,128 @@
+package bandwidth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func startTransferMockServer(t *testing.T) (*Client, func() []string, func()) {
+	server, api, log := startMockServerWithHandlersAndLog(t, []RequestHandler{
+		RequestHandler{
+			PathAndQuery:     "/v1/users/userId/calls",
+			Method:           http.MethodPost,
+			HeadersToSend:    map[string]string{"Location": "/v1/users/userId/calls/c3"},
+			StatusCodeToSend: http.StatusCreated},
+		RequestHandler{
+			PathAndQuery:     "/v1/users/userId/calls/c1",
+			Method:           http.MethodPost,
+			HeadersToSend:    map[string]string{"Location": "/v1/users/userId/calls/c3"},
+			StatusCodeToSend: http.StatusCreated},
+		RequestHandler{
+			PathAndQuery:     "/v1/users/userId/bridges",
+			Method:           http.MethodPost,
+			HeadersToSend:    map[string]string{"Location": "/v1/users/userId/bridges/b2"},
+			StatusCodeToSend: http.StatusCreated}}, http.StatusCreated)
+	return api, log, server.Close
+}
+
+func TestBlindTransfer(t *testing.T) {
+	api, log, stop := startTransferMockServer(t)
+	defer stop()
+	id, err := api.BlindTransfer(context.Background(), "c1", "+19195551212", &TransferOptions{TransferCallerID: "+19195550000"})
+	if err != nil {
+		t.Error("Failed call of BlindTransfer()")
+		return
+	}
+	expect(t, id, "c3")
+	expect(t, log(), []string{`POST /v1/users/userId/calls/c1 {"transferCallerId":"+19195550000","transferTo":"+19195551212","state":"transferring"}`})
+}
+
+func TestBlindTransferWithUnansweredTarget(t *testing.T) {
+	api, log, stop := startTransferMockServer(t)
+	defer stop()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := api.BlindTransfer(ctx, "c1", "+19195551212", &TransferOptions{Tracker: NewCallTracker(nil)})
+	expect(t, err, context.DeadlineExceeded)
+	expect(t, log()[1], `POST /v1/users/userId/calls/c3 {"state":"completed"}`)
+}
+
+func TestBlindTransferFail(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery: "/v1/users/userId/calls/c1",
+		Method:       http.MethodPost}})
+	defer server.Close()
+	shouldFail(t, func() (interface{}, error) {
+		return api.BlindTransfer(context.Background(), "c1", "+19195551212", nil)
+	})
+}
+
+func TestAttendedTransfer(t *testing.T) {
+	api, log, stop := startTransferMockServer(t)
+	defer stop()
+	tracker := NewCallTracker(nil)
+	tracker.Track("c3", CallStateActive)
+	transfer, err := api.AttendedTransfer(context.Background(), &AttendedTransferData{
+		BridgeID:         "b1",
+		CustomerCallID:   "c1",
+		AgentCallID:      "c2",
+		TransferTo:       "+19195551212",
+		TransferCallerID: "+19195550000",
+		HoldAudio:        &PlayAudioData{FileURL: "http://localhost/music.mp3"},
+		Tracker:          tracker})
+	if err != nil {
+		t.Error("Failed call of AttendedTransfer()")
+		return
+	}
+	expect(t, transfer.TargetCallID, "c3")
+	expect(t, transfer.ConsultBridgeID, "b2")
+	expectNil(t, api.CompleteAttendedTransfer(transfer))
+	expect(t, log(), []string{
+		`POST /v1/users/userId/bridges/b1 {"bridgeAudio":true,"callIds":["c1"]}`,
+		`POST /v1/users/userId/calls/c1/audio {"fileUrl":"http://localhost/music.mp3","loopEnabled":true}`,
+		`POST /v1/users/userId/calls {"from":"+19195550000","to":"+19195551212"}`,
+		`POST /v1/users/userId/bridges {"bridgeAudio":true,"callIds":["c2","c3"]}`,
+		`POST /v1/users/userId/calls/c1/audio {"fileUrl":""}`,
+		`POST /v1/users/userId/bridges/b1 {"bridgeAudio":true,"callIds":["c1","c3"]}`,
+		`POST /v1/users/userId/calls/c2 {"state":"completed"}`,
+	})
+}
+
+func TestCancelAttendedTransfer(t *testing.T) {
+	api, log, stop := startTransferMockServer(t)
+	defer stop()
+	transfer := &AttendedTransfer{Data: &AttendedTransferData{BridgeID: "b1", CustomerCallID: "c1", AgentCallID: "c2"}, TargetCallID: "c3", ConsultBridgeID: "b2"}
+	expectNil(t, api.CancelAttendedTransfer(transfer))
+	expect(t, log(), []string{
+		`POST /v1/users/userId/calls/c3 {"state":"completed"}`,
+		`POST /v1/users/userId/bridges/b1 {"bridgeAudio":true,"callIds":["c1","c2"]}`,
+	})
+}
+
+func TestAttendedTransferWithUnansweredTarget(t *testing.T) {
+	api, log, stop := startTransferMockServer(t)
+	defer stop()
+	tracker := NewCallTracker(nil)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		tracker.HandleCallback(&CallbackEvent{EventType: CallbackEventTimeout, CallID: "c3"})
+	}()
+	_, err := api.AttendedTransfer(context.Background(), &AttendedTransferData{
+		BridgeID:       "b1",
+		CustomerCallID: "c1",
+		AgentCallID:    "c2",
+		TransferTo:     "+19195551212",
+		Tracker:        tracker})
+	_, ok := err.(*CallStateUnreachableError)
+	expect(t, ok, true)
+	expect(t, log(), []string{
+		`POST /v1/users/userId/bridges/b1 {"bridgeAudio":true,"callIds":["c1"]}`,
+		`POST /v1/users/userId/calls {"to":"+19195551212"}`,
+		`POST /v1/users/userId/bridges/b1 {"bridgeAudio":true,"callIds":["c1","c2"]}`,
+	})
+	shouldFail(t, func() (interface{}, error) {
+		return api.AttendedTransfer(context.Background(), &AttendedTransferData{})
+	})
+}
@@ -0,0 +1,281 @@
+package bandwidth
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const voicemailGreetingTag = "voicemail-greeting"
+
+const (
+	defaultVoicemailPollInterval  = 5 * time.Second
+	defaultVoicemailTimeout       = 10 * time.Minute
+	defaultVoicemailMaxDeliveries = 10
+)
+
+// ErrVoicemailBoxClosed is returned when a hang up is handled after VoicemailBox.Close()
+var ErrVoicemailBoxClosed = errors.New("Voicemail box is closed")
+
+// NoVoicemailError is returned when the caller has hung up before recording of a voicemail was started
+type NoVoicemailError struct {
+	CallID string
+}
+
+func (e *NoVoicemailError) Error() string {
+	return fmt.Sprintf("Call %s has no voicemail recording", e.CallID)
+}
+
+// Voicemail struct
+type Voicemail struct {
+	CallID        string
+	From          string
+	To            string
+	RecordingID   string
+	StartTime     string
+	EndTime       string
+	Transcription string
+	Audio         []byte
+	ContentType   string
+}
+
+// VoicemailSink receives collected voicemails
+type VoicemailSink interface {
+	DeliverVoicemail(voicemail *Voicemail) error
+}
+
+// VoicemailSinkFunc allows to use a function as VoicemailSink
+type VoicemailSinkFunc func(voicemail *Voicemail) error
+
+// DeliverVoicemail calls f(voicemail)
+func (f VoicemailSinkFunc) DeliverVoicemail(voicemail *Voicemail) error {
+	return f(voicemail)
+}
+
+// SMSVoicemailSink sends transcription of a voicemail as SMS
+type SMSVoicemailSink struct {
+	API  *Client
+	From string
+	To   string
+}
+
+// DeliverVoicemail sends SMS about the voicemail
+func (s *SMSVoicemailSink) DeliverVoicemail(voicemail *Voicemail) error {
+	_, err := s.API.CreateMessage(&CreateMessageData{
+		From: s.From,
+		To:   s.To,
+		Text: fmt.Sprintf("New voicemail from %s: %s", voicemail.From, voicemail.Transcription)})
+	return err
+}
+
+// Mailer sends emails (or email-like notifications)
+type Mailer interface {
+	SendMail(to, subject, body string, attachments map[string][]byte) error
+}
+
+// EmailVoicemailSink sends a voicemail with attached audio via Mailer
+type EmailVoicemailSink struct {
+	Mailer Mailer
+	To     string
+}
+
+// DeliverVoicemail sends email with the voicemail
+func (s *EmailVoicemailSink) DeliverVoicemail(voicemail *Voicemail) error {
+	attachments := map[string][]byte{}
+	if len(voicemail.Audio) > 0 {
+		attachments[voicemail.RecordingID] = voicemail.Audio
+	}
+	return s.Mailer.SendMail(s.To, fmt.Sprintf("New voicemail from %s", voicemail.From), voicemail.Transcription, attachments)
+}
+
+// VoicemailBox answers incoming calls, records messages and delivers them to Sink
+type VoicemailBox struct {
+	API      *Client
+	Greeting *PlayAudioData
+	Sink     VoicemailSink
+	// PollInterval is interval of checking recordings and transcriptions after hang up (5 seconds if it is 0)
+	PollInterval time.Duration
+	// Timeout limits time of collecting a voicemail after hang up (10 minutes if it is 0)
+	Timeout time.Duration
+	// OnError is called when a voicemail can't be collected or delivered after hang up
+	OnError func(callID string, err error)
+	// MaxDeliveries limits count of voicemails collected at the same time after hang up (10 if it is 0)
+	// Other voicemails wait for a free slot.
+	MaxDeliveries int
+
+	mutex   sync.Mutex
+	closed  bool
+	ctx     context.Context
+	cancel  func()
+	slots   chan struct{}
+	running sync.WaitGroup
+}
+
+// NewVoicemailBox creates new VoicemailBox instance
+// example: box := bandwidth.NewVoicemailBox(api, &bandwidth.PlayAudioData{Sentence: "Leave a message"}, sink)
+func NewVoicemailBox(api *Client, greeting *PlayAudioData, sink VoicemailSink) *VoicemailBox {
+	return &VoicemailBox{API: api, Greeting: greeting, Sink: sink, PollInterval: defaultVoicemailPollInterval, Timeout: defaultVoicemailTimeout}
+}
+
+func (v *VoicemailBox) pollInterval() time.Duration {
+	if v.PollInterval > 0 {
+		return v.PollInterval
+	}
+	return defaultVoicemailPollInterval
+}
+
+func (v *VoicemailBox) timeout() time.Duration {
+	if v.Timeout > 0 {
+		return v.Timeout
+	}
+	return defaultVoicemailTimeout
+}
+
+func (v *VoicemailBox) maxDeliveries() int {
+	if v.MaxDeliveries > 0 {
+		return v.MaxDeliveries
+	}
+	return defaultVoicemailMaxDeliveries
+}
+
+// HandleCallback drives the voicemail flow of a call:
+// incoming call is answered, the greeting is played, then recording is enabled.
+// After hang up the voicemail is collected and delivered in background (see MaxDeliveries and Close()).
+// It returns error object
+func (v *VoicemailBox) HandleCallback(event *CallbackEvent) error {
+	switch event.EventType {
+	case CallbackEventIncomingCall:
+		return v.API.AnswerIncomingCall(event.CallID)
+	case CallbackEventAnswer:
+		if v.Greeting == nil {
+			return v.API.SetCallRecodingEnabled(event.CallID, true)
+		}
+		greeting := *v.Greeting
+		greeting.Tag = voicemailGreetingTag
+		return v.API.PlayAudioToCall(event.CallID, &greeting)
+	case CallbackEventSpeak, CallbackEventPlayback:
+		if event.Status == "done" && event.Tag == voicemailGreetingTag {
+			return v.API.SetCallRecodingEnabled(event.CallID, true)
+		}
+	case CallbackEventHangup:
+		return v.deliverInBackground(event.CallID)
+	}
+	return nil
+}
+
+func (v *VoicemailBox) deliverInBackground(callID string) error {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	if v.closed {
+		return ErrVoicemailBoxClosed
+	}
+	if v.ctx == nil {
+		v.ctx, v.cancel = context.WithCancel(context.Background())
+		v.slots = make(chan struct{}, v.maxDeliveries())
+	}
+	base, slots := v.ctx, v.slots
+	v.running.Add(1)
+	go func() {
+		defer v.running.Done()
+		err := func() error {
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-base.Done():
+				return base.Err()
+			}
+			ctx, cancel := context.WithTimeout(base, v.timeout())
+			defer cancel()
+			_, err := v.Deliver(ctx, callID)
+			return err
+		}()
+		if err != nil && v.OnError != nil {
+			v.OnError(callID, err)
+		}
+	}()
+	return nil
+}
+
+// Close cancels collecting of voicemails in background and waits until their goroutines finish
+// Later hang ups are not handled (HandleCallback() returns ErrVoicemailBoxClosed).
+func (v *VoicemailBox) Close() {
+	v.mutex.Lock()
+	v.closed = true
+	if v.cancel != nil {
+		v.cancel()
+	}
+	v.mutex.Unlock()
+	v.running.Wait()
+}
+
+// Deliver collects the voicemail of the finished call and passes it to Sink
+// It returns delivered Voicemail or error
+func (v *VoicemailBox) Deliver(ctx context.Context, callID string) (*Voicemail, error) {
+	if v.Sink == nil {
+		return nil, fmt.Errorf("VoicemailBox has no Sink to deliver voicemail of call %s", callID)
+	}
+	voicemail, err := v.Collect(ctx, callID)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.Sink.DeliverVoicemail(voicemail); err != nil {
+		return nil, err
+	}
+	return voicemail, nil
+}
+
+// Collect waits for the recording and its transcription of the finished call and downloads its audio
+// An existing transcription of the recording is used if there is one (e.g. when the voicemail is collected again).
+// It returns Voicemail instance, NoVoicemailError if recording has not been started or other error
+func (v *VoicemailBox) Collect(ctx context.Context, callID string) (*Voicemail, error) {
+	call, err := v.API.GetCall(callID)
+	if err != nil {
+		return nil, err
+	}
+	var recording *Recording
+	err = pollUntil(ctx, v.pollInterval(), func() (bool, error) {
+		recordings, err := v.API.GetCallRecordings(callID)
+		if err != nil {
+			return false, err
+		}
+		if len(recordings) == 0 && !call.RecordingEnabled {
+			// the caller has hung up before end of the greeting
+			return false, &NoVoicemailError{CallID: callID}
+		}
+		for _, r := range recordings {
+			switch r.State {
+			case "complete":
+				recording = r
+			case "error":
+				return false, fmt.Errorf("Recording %s of call %s has failed", r.ID, callID)
+			}
+		}
+		return recording != nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	transcription, err := v.API.TranscribeRecording(ctx, recording.ID, &TranscriptionOptions{PollInterval: v.pollInterval(), MaxPollInterval: v.pollInterval(), Reuse: true})
+	if err != nil {
+		return nil, err
+	}
+	audio := &bytes.Buffer{}
+	_, contentType, err := v.API.downloadRecording(ctx, recording, audio)
+	if err != nil {
+		return nil, err
+	}
+	return &Voicemail{
+		CallID:        callID,
+		From:          call.From,
+		To:            call.To,
+		RecordingID:   recording.ID,
+		StartTime:     recording.StartTime,
+		EndTime:       recording.EndTime,
+		Transcription: transcription.Text,
+		Audio:         audio.Bytes(),
+		ContentType:   contentType,
+	}, nil
+}
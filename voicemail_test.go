@@ -0,0 +1,170 @@
+package bandwidth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func startVoicemailMockServer(t *testing.T, recordingState, transcriptionState string, transcriptions ...string) (*VoicemailBox, func()) {
+	existing := "[]"
+	if len(transcriptions) > 0 {
+		existing = transcriptions[0]
+	}
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery:  "/v1/users/userId/calls/123",
+		Method:        http.MethodGet,
+		ContentToSend: `{"id": "123", "from": "+19195551212", "to": "+19195551213", "state": "completed"}`,
+	}, RequestHandler{
+		PathAndQuery:  "/v1/users/userId/calls/123/recordings",
+		Method:        http.MethodGet,
+		ContentToSend: `[{"id": "456", "media": "https://.../v1/users/userId/media/c-123-r-456.wav", "state": "` + recordingState + `", "startTime": "2013-02-08T13:15:47.587Z", "endTime": "2013-02-08T13:15:55.887Z"}]`,
+	}, RequestHandler{
+		PathAndQuery:  "/v1/users/userId/recordings/456/transcriptions",
+		Method:        http.MethodGet,
+		ContentToSend: existing,
+	}, RequestHandler{
+		PathAndQuery:  "/v1/users/userId/recordings/456/transcriptions",
+		Method:        http.MethodPost,
+		HeadersToSend: map[string]string{"Location": "/v1/users/userId/recordings/456/transcriptions/789"},
+	}, RequestHandler{
+		PathAndQuery:  "/v1/users/userId/recordings/456/transcriptions/789",
+		Method:        http.MethodGet,
+		ContentToSend: `{"id": "789", "state": "` + transcriptionState + `", "text": "Call me back"}`,
+	}, RequestHandler{
+		PathAndQuery:  "/v1/users/userId/media/c-123-r-456.wav",
+		Method:        http.MethodGet,
+		ContentToSend: "audio",
+		HeadersToSend: map[string]string{"Content-Type": "audio/wav"},
+	}, RequestHandler{
+		PathAndQuery:     "/v1/users/userId/messages",
+		Method:           http.MethodPost,
+		EstimatedContent: `{"from":"+19195550000","to":"+19195551111","text":"New voicemail from +19195551212: Call me back"}`,
+		HeadersToSend:    map[string]string{"Location": "/v1/users/userId/messages/1"}}})
+	box := NewVoicemailBox(api, nil, &SMSVoicemailSink{API: api, From: "+19195550000", To: "+19195551111"})
+	box.PollInterval = time.Millisecond
+	return box, server.Close
+}
+
+func TestVoicemailBoxDeliver(t *testing.T) {
+	box, stop := startVoicemailMockServer(t, "complete", "completed")
+	defer stop()
+	voicemail, err := box.Deliver(context.Background(), "123")
+	if err != nil {
+		t.Error("Failed call of Deliver()")
+		return
+	}
+	expect(t, voicemail.From, "+19195551212")
+	expect(t, voicemail.RecordingID, "456")
+	expect(t, voicemail.Transcription, "Call me back")
+	expect(t, string(voicemail.Audio), "audio\n")
+	expect(t, voicemail.ContentType, "audio/wav")
+}
+
+func TestVoicemailBoxCollectWithExistingTranscription(t *testing.T) {
+	box, stop := startVoicemailMockServer(t, "complete", "error", `[{"id": "788", "state": "completed", "text": "Call me later"}]`)
+	defer stop()
+	voicemail, err := box.Collect(context.Background(), "123")
+	expectNil(t, err)
+	expect(t, voicemail.Transcription, "Call me later")
+}
+
+func TestVoicemailBoxClose(t *testing.T) {
+	box, stop := startVoicemailMockServer(t, "recording", "completed")
+	defer stop()
+	box.MaxDeliveries = 1
+	errs := make(chan error, 2)
+	box.OnError = func(callID string, err error) { errs <- err }
+	expectNil(t, box.HandleCallback(&CallbackEvent{EventType: CallbackEventHangup, CallID: "123"}))
+	expectNil(t, box.HandleCallback(&CallbackEvent{EventType: CallbackEventHangup, CallID: "123"}))
+	box.Close()
+	expect(t, <-errs, context.Canceled)
+	expect(t, <-errs, context.Canceled)
+	expect(t, box.HandleCallback(&CallbackEvent{EventType: CallbackEventHangup, CallID: "123"}), ErrVoicemailBoxClosed)
+}
+
+func TestVoicemailBoxCollectFail(t *testing.T) {
+	box, stop := startVoicemailMockServer(t, "error", "completed")
+	defer stop()
+	shouldFail(t, func() (interface{}, error) { return box.Collect(context.Background(), "123") })
+	box, stop = startVoicemailMockServer(t, "complete", "error")
+	defer stop()
+	shouldFail(t, func() (interface{}, error) { return box.Collect(context.Background(), "123") })
+	box, stop = startVoicemailMockServer(t, "recording", "completed")
+	defer stop()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := box.Collect(ctx, "123")
+	expect(t, err, context.DeadlineExceeded)
+}
+
+func TestVoicemailBoxCollectWithoutRecording(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery:  "/v1/users/userId/calls/123",
+		Method:        http.MethodGet,
+		ContentToSend: `{"id": "123", "state": "completed", "recordingEnabled": false}`,
+	}, RequestHandler{
+		PathAndQuery:  "/v1/users/userId/calls/123/recordings",
+		Method:        http.MethodGet,
+		ContentToSend: `[]`}})
+	defer server.Close()
+	box := &VoicemailBox{API: api}
+	_, err := box.Collect(context.Background(), "123")
+	expect(t, err, &NoVoicemailError{CallID: "123"})
+}
+
+func TestVoicemailBoxDeliverWithoutSink(t *testing.T) {
+	box := &VoicemailBox{API: getAPI()}
+	expect(t, box.pollInterval(), defaultVoicemailPollInterval)
+	expect(t, box.timeout(), defaultVoicemailTimeout)
+	_, err := box.Deliver(context.Background(), "123")
+	expect(t, err.Error(), "VoicemailBox has no Sink to deliver voicemail of call 123")
+}
+
+func TestVoicemailBoxHandleCallback(t *testing.T) {
+	server, api := startMockServer(t, []RequestHandler{RequestHandler{
+		PathAndQuery:     "/v1/users/userId/calls/123/audio",
+		Method:           http.MethodPost,
+		EstimatedContent: `{"sentence":"Leave a message","tag":"voicemail-greeting"}`,
+	}, RequestHandler{
+		PathAndQuery:     "/v1/users/userId/calls/123",
+		Method:           http.MethodPost,
+		EstimatedContent: `{"recordingEnabled":"true"}`}})
+	defer server.Close()
+	box := NewVoicemailBox(api, &PlayAudioData{Sentence: "Leave a message"}, nil)
+	expectNil(t, box.HandleCallback(&CallbackEvent{EventType: CallbackEventAnswer, CallID: "123"}))
+	expectNil(t, box.HandleCallback(&CallbackEvent{EventType: CallbackEventSpeak, CallID: "123", Status: "started", Tag: voicemailGreetingTag}))
+	expectNil(t, box.HandleCallback(&CallbackEvent{EventType: CallbackEventSpeak, CallID: "123", Status: "done", Tag: voicemailGreetingTag}))
+}
+
+type testMailer struct {
+	to, subject, body string
+	attachments       map[string][]byte
+}
+
+func (m *testMailer) SendMail(to, subject, body string, attachments map[string][]byte) error {
+	m.to, m.subject, m.body, m.attachments = to, subject, body, attachments
+	return nil
+}
+
+func TestEmailVoicemailSink(t *testing.T) {
+	mailer := &testMailer{}
+	sink := &EmailVoicemailSink{Mailer: mailer, To: "user@example.com"}
+	expectNil(t, sink.DeliverVoicemail(&Voicemail{From: "+19195551212", RecordingID: "456", Transcription: "Call me back", Audio: []byte("audio")}))
+	expect(t, mailer.to, "user@example.com")
+	expect(t, mailer.subject, "New voicemail from +19195551212")
+	expect(t, mailer.body, "Call me back")
+	expect(t, string(mailer.attachments["456"]), "audio")
+}
+
+func TestVoicemailSinkFunc(t *testing.T) {
+	var delivered *Voicemail
+	sink := VoicemailSinkFunc(func(voicemail *Voicemail) error {
+		delivered = voicemail
+		return nil
+	})
+	voicemail := &Voicemail{CallID: "123"}
+	expectNil(t, sink.DeliverVoicemail(voicemail))
+	expect(t, delivered, voicemail)
+}
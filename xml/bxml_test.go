@@ -66,3 +66,11 @@ func TestVerbs(t *testing.T) {
 	}}
 	expect(t, response.ToXML(), `<Response><Gather requestUrl="url"></Gather><Pause duration="10"></Pause><Hangup></Hangup><PlayAudio>url</PlayAudio><Record requestUrl="url"></Record><Redirect requestUrl="url"></Redirect><Reject reason="none"></Reject><SendMessage from="from" to="to">text</SendMessage><SpeakSentence>Hello</SpeakSentence><Transfer transferTo="number"><SpeakSentence>Please wait</SpeakSentence></Transfer></Response>`)
 }
+
+func TestGatherWithPrompt(t *testing.T) {
+	response := &Response{Verbs: []interface{}{
+		Gather{RequestURL: "url", MaxDigits: 1, SpeakSentence: &SpeakSentence{Sentence: "Press 1"}},
+		Gather{RequestURL: "url", Tag: "menu", PlayAudio: &PlayAudio{URL: "audio"}},
+	}}
+	expect(t, response.ToXML(), `<Response><Gather requestUrl="url" maxDigits="1"><SpeakSentence>Press 1</SpeakSentence></Gather><Gather requestUrl="url" tag="menu"><PlayAudio>audio</PlayAudio></Gather></Response>`)
+}